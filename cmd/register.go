@@ -4,13 +4,24 @@ import (
 	"consul-debug-read/cmd/cli"
 	"consul-debug-read/internal/read/commands/agent"
 	agentconfig "consul-debug-read/internal/read/commands/agent/config"
+	agentlint "consul-debug-read/internal/read/commands/agent/lint"
 	"consul-debug-read/internal/read/commands/agent/members"
 	"consul-debug-read/internal/read/commands/agent/raft"
 	agentsummary "consul-debug-read/internal/read/commands/agent/summary"
+	"consul-debug-read/internal/read/commands/bundle"
+	bundleAdd "consul-debug-read/internal/read/commands/bundle/add"
+	bundleInspect "consul-debug-read/internal/read/commands/bundle/inspect"
+	bundleList "consul-debug-read/internal/read/commands/bundle/list"
+	bundlePrune "consul-debug-read/internal/read/commands/bundle/prune"
+	bundleRemove "consul-debug-read/internal/read/commands/bundle/remove"
+	bundleTag "consul-debug-read/internal/read/commands/bundle/tag"
+	bundleUse "consul-debug-read/internal/read/commands/bundle/use"
+	"consul-debug-read/internal/read/commands/cluster"
 	"consul-debug-read/internal/read/commands/config"
 	"consul-debug-read/internal/read/commands/config/get"
 	"consul-debug-read/internal/read/commands/config/set"
 	"consul-debug-read/internal/read/commands/config/show"
+	"consul-debug-read/internal/read/commands/diff"
 	"consul-debug-read/internal/read/commands/log"
 	logdebug "consul-debug-read/internal/read/commands/log/parse/debug"
 	logerror "consul-debug-read/internal/read/commands/log/parse/error"
@@ -20,8 +31,17 @@ import (
 	logwarn "consul-debug-read/internal/read/commands/log/parse/warn"
 	logsummary "consul-debug-read/internal/read/commands/log/summary"
 	"consul-debug-read/internal/read/commands/metrics"
+	metricsAnomalies "consul-debug-read/internal/read/commands/metrics/anomalies"
+	metricsExport "consul-debug-read/internal/read/commands/metrics/export"
+	metricsList "consul-debug-read/internal/read/commands/metrics/list"
+	metricsQuery "consul-debug-read/internal/read/commands/metrics/query"
+	metricsServeAPI "consul-debug-read/internal/read/commands/metrics/serveapi"
 	metricsSummary "consul-debug-read/internal/read/commands/metrics/summary"
+	"consul-debug-read/internal/read/commands/serve"
 	"consul-debug-read/internal/read/commands/summary"
+	"consul-debug-read/internal/read/commands/telemetry"
+	telemetryUpdate "consul-debug-read/internal/read/commands/telemetry/update"
+	"consul-debug-read/internal/read/commands/timeline"
 	"fmt"
 	mcli "github.com/mitchellh/cli"
 )
@@ -33,14 +53,34 @@ func RegisteredCommands(ui cli.Ui) map[string]mcli.CommandFactory {
 		entry{"config current-path", func(ui mcli.Ui) (mcli.Command, error) { return get.New(ui) }},
 		entry{"config set-path", func(ui mcli.Ui) (mcli.Command, error) { return set.New(ui) }},
 		entry{"config show", func(ui mcli.Ui) (mcli.Command, error) { return show.New(ui) }},
+		entry{"bundle", func(mcli.Ui) (mcli.Command, error) { return bundle.New(), nil }},
+		entry{"bundle add", func(ui mcli.Ui) (mcli.Command, error) { return bundleAdd.New(ui) }},
+		entry{"bundle list", func(ui mcli.Ui) (mcli.Command, error) { return bundleList.New(ui) }},
+		entry{"bundle use", func(ui mcli.Ui) (mcli.Command, error) { return bundleUse.New(ui) }},
+		entry{"bundle remove", func(ui mcli.Ui) (mcli.Command, error) { return bundleRemove.New(ui) }},
+		entry{"bundle tag", func(ui mcli.Ui) (mcli.Command, error) { return bundleTag.New(ui) }},
+		entry{"bundle prune", func(ui mcli.Ui) (mcli.Command, error) { return bundlePrune.New(ui) }},
+		entry{"bundle inspect", func(ui mcli.Ui) (mcli.Command, error) { return bundleInspect.New(ui) }},
 		entry{"agent", func(mcli.Ui) (mcli.Command, error) { return agent.New(), nil }},
 		entry{"agent summary", func(ui mcli.Ui) (mcli.Command, error) { return agentsummary.New(ui) }},
 		entry{"agent config", func(ui mcli.Ui) (mcli.Command, error) { return agentconfig.New(ui) }},
+		entry{"agent lint", func(ui mcli.Ui) (mcli.Command, error) { return agentlint.New(ui) }},
 		entry{"agent members", func(ui mcli.Ui) (mcli.Command, error) { return members.New(ui) }},
 		entry{"agent raft-configuration", func(ui mcli.Ui) (mcli.Command, error) { return raft.New(ui) }},
 		entry{"metrics", func(mcli.Ui) (mcli.Command, error) { return metrics.New(ui) }},
+		entry{"metrics anomalies", func(mcli.Ui) (mcli.Command, error) { return metricsAnomalies.New(ui) }},
+		entry{"metrics export", func(mcli.Ui) (mcli.Command, error) { return metricsExport.New(ui) }},
+		entry{"metrics list", func(mcli.Ui) (mcli.Command, error) { return metricsList.New(ui) }},
+		entry{"metrics query", func(mcli.Ui) (mcli.Command, error) { return metricsQuery.New(ui) }},
+		entry{"metrics serve-api", func(mcli.Ui) (mcli.Command, error) { return metricsServeAPI.New(ui) }},
 		entry{"metrics summary", func(mcli.Ui) (mcli.Command, error) { return metricsSummary.New(ui) }},
+		entry{"serve", func(mcli.Ui) (mcli.Command, error) { return serve.New(ui) }},
 		entry{"summary", func(mcli.Ui) (mcli.Command, error) { return summary.New(ui) }},
+		entry{"telemetry", func(mcli.Ui) (mcli.Command, error) { return telemetry.New(), nil }},
+		entry{"telemetry update", func(ui mcli.Ui) (mcli.Command, error) { return telemetryUpdate.New(ui) }},
+		entry{"timeline", func(mcli.Ui) (mcli.Command, error) { return timeline.New(ui) }},
+		entry{"diff", func(mcli.Ui) (mcli.Command, error) { return diff.New(ui) }},
+		entry{"cluster", func(mcli.Ui) (mcli.Command, error) { return cluster.New(ui) }},
 		entry{"log", func(mcli.Ui) (mcli.Command, error) { return log.New(), nil }},
 		entry{"log summary", func(mcli.Ui) (mcli.Command, error) { return logsummary.New(ui) }},
 		entry{"log parse-rpc-counts", func(ui mcli.Ui) (mcli.Command, error) { return rpccounts.New(ui) }},
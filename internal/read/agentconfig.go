@@ -0,0 +1,256 @@
+package read
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ConfigSetting is a DebugConfig setting, named by its dotted path such as
+// Limits.HTTPMaxConnsPerClient or RetryJoinLAN[0].
+type ConfigSetting struct {
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value"`
+	// Default is Consul's default for the setting, set by NonDefaultSettings.
+	Default json.RawMessage `json:"default,omitempty"`
+}
+
+// AgentConfigDefault is Consul's default value of a DebugConfig setting, for the Consul versions
+// from Since up to but excluding Removed.
+type AgentConfigDefault struct {
+	Path    string          `json:"path"`
+	Value   json.RawMessage `json:"value"`
+	Since   string          `json:"since,omitempty"`
+	Removed string          `json:"removed,omitempty"`
+}
+
+// AgentConfigDefaults are the documented defaults of DebugConfig settings across Consul versions.
+type AgentConfigDefaults struct {
+	// DocsVersion is the Consul version of the configuration docs the defaults were last checked against.
+	DocsVersion string               `json:"docs_version"`
+	Defaults    []AgentConfigDefault `json:"defaults"`
+}
+
+//go:embed agentconfig/defaults.json
+var embeddedAgentConfigDefaults []byte
+
+// LoadAgentConfigDefaults returns the agent configuration defaults embedded at build time.
+func LoadAgentConfigDefaults() (AgentConfigDefaults, error) {
+	var defaults AgentConfigDefaults
+	if err := json.Unmarshal(embeddedAgentConfigDefaults, &defaults); err != nil {
+		return defaults, fmt.Errorf("failed to decode agent configuration defaults: %v", err)
+	}
+	return defaults, nil
+}
+
+// ForVersion returns the defaults of the given Consul agent version by setting path. An empty or
+// unparseable version returns every default not yet removed.
+func (d AgentConfigDefaults) ForVersion(agentVersion string) map[string]json.RawMessage {
+	version, ok := parseConsulVersion(agentVersion)
+	defaults := make(map[string]json.RawMessage)
+	for _, def := range d.Defaults {
+		if (!ok && def.Removed == "") || (ok && inVersionRange(version, def.Since, def.Removed)) {
+			defaults[def.Path] = def.Value
+		}
+	}
+	return defaults
+}
+
+// DebugConfigJSON returns the DebugConfig of agent.json, indented, as captured.
+func (a *Agent) DebugConfigJSON() (string, error) {
+	if len(a.RawDebugConfig) == 0 {
+		return "", errors.New("agent.json has no DebugConfig")
+	}
+	var out bytes.Buffer
+	if err := json.Indent(&out, a.RawDebugConfig, "", "    "); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+// QueryConfig returns the DebugConfig settings under the paths matching query, sorted by path. A
+// query is a dotted path, optionally prefixed by $. as in JSONPath, whose segments may be globs
+// matched case-insensitively and whose arrays are indexed with [N] or [*], e.g. DNS*, Limits.*,
+// TLS.*.VerifyIncoming or RetryJoinLAN[0]. An empty query returns every setting.
+func (a *Agent) QueryConfig(query string) ([]ConfigSetting, error) {
+	if len(a.RawDebugConfig) == 0 {
+		return nil, errors.New("agent.json has no DebugConfig")
+	}
+	segments, err := parseConfigQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	var tree interface{}
+	if err = json.Unmarshal(a.RawDebugConfig, &tree); err != nil {
+		return nil, err
+	}
+	flat := make(map[string]string)
+	if err = matchConfig("", tree, segments, flat); err != nil {
+		return nil, err
+	}
+	if len(flat) == 0 {
+		return nil, fmt.Errorf("no DebugConfig setting matches %q", query)
+	}
+	settings := make([]ConfigSetting, 0, len(flat))
+	for p, v := range flat {
+		settings = append(settings, ConfigSetting{Path: p, Value: json.RawMessage(v)})
+	}
+	sort.Slice(settings, func(i, j int) bool { return settings[i].Path < settings[j].Path })
+	return settings, nil
+}
+
+// NonDefaultSettings returns the settings whose value differs from Consul's documented default
+// for agentVersion, with Default set. Settings without a documented default are left out.
+func NonDefaultSettings(settings []ConfigSetting, agentVersion string) ([]ConfigSetting, error) {
+	catalog, err := LoadAgentConfigDefaults()
+	if err != nil {
+		return nil, err
+	}
+	defaults := catalog.ForVersion(agentVersion)
+	var changed []ConfigSetting
+	for _, s := range settings {
+		def, ok := defaults[s.Path]
+		if !ok || equalConfigValues(s.Value, def) {
+			continue
+		}
+		s.Default = def
+		changed = append(changed, s)
+	}
+	return changed, nil
+}
+
+// parseConfigQuery splits a config query into its path segments, array indexes becoming their
+// own [N] or [*] segment.
+func parseConfigQuery(query string) ([]string, error) {
+	q := strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(query), "$"), ".")
+	if q == "" {
+		return nil, nil
+	}
+	var segments []string
+	for _, part := range strings.Split(q, ".") {
+		name := part
+		var indexes []string
+		// Trailing [N] or [*] are indexes; any other brackets are a glob character class.
+		for strings.HasSuffix(name, "]") {
+			open := strings.LastIndex(name, "[")
+			if open < 0 {
+				break
+			}
+			inner := name[open+1 : len(name)-1]
+			if _, err := strconv.Atoi(inner); inner != "*" && err != nil {
+				break
+			}
+			indexes = append([]string{name[open:]}, indexes...)
+			name = name[:open]
+		}
+		if name == "" && len(indexes) == 0 {
+			return nil, fmt.Errorf("invalid config query %q: empty path segment", query)
+		}
+		if name != "" {
+			if _, err := path.Match(name, ""); err != nil {
+				return nil, fmt.Errorf("invalid config query %q: %v", query, err)
+			}
+			segments = append(segments, name)
+		}
+		segments = append(segments, indexes...)
+	}
+	return segments, nil
+}
+
+// matchConfig flattens the settings of value under the paths matching segments into flat.
+func matchConfig(prefix string, value interface{}, segments []string, flat map[string]string) error {
+	if len(segments) == 0 {
+		FlattenJSON(prefix, value, flat)
+		return nil
+	}
+	segment, rest := segments[0], segments[1:]
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if strings.HasPrefix(segment, "[") {
+			return nil
+		}
+		for key, child := range v {
+			if ok, _ := path.Match(strings.ToLower(segment), strings.ToLower(key)); !ok {
+				continue
+			}
+			p := key
+			if prefix != "" {
+				p = prefix + "." + key
+			}
+			if err := matchConfig(p, child, rest, flat); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for i, child := range v {
+			if segment != "*" && segment != "[*]" && segment != fmt.Sprintf("[%d]", i) {
+				continue
+			}
+			if err := matchConfig(fmt.Sprintf("%s[%d]", prefix, i), child, rest, flat); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// FlattenJSON flattens a decoded JSON value into dotted field paths under prefix and their JSON
+// values; empty objects and arrays are kept as {} and [].
+func FlattenJSON(prefix string, value interface{}, flat map[string]string) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if len(v) == 0 && prefix != "" {
+			flat[prefix] = "{}"
+		}
+		for k, child := range v {
+			key := k
+			if prefix != "" {
+				key = prefix + "." + k
+			}
+			FlattenJSON(key, child, flat)
+		}
+	case []interface{}:
+		if len(v) == 0 {
+			flat[prefix] = "[]"
+		}
+		for i, child := range v {
+			FlattenJSON(fmt.Sprintf("%s[%d]", prefix, i), child, flat)
+		}
+	case nil:
+		flat[prefix] = "null"
+	default:
+		// Leave <, > and & unescaped, keeping values such as <redacted> readable.
+		var raw bytes.Buffer
+		enc := json.NewEncoder(&raw)
+		enc.SetEscapeHTML(false)
+		_ = enc.Encode(v)
+		flat[prefix] = strings.TrimSuffix(raw.String(), "\n")
+	}
+}
+
+// equalConfigValues compares two JSON setting values, treating durations such as 72h and
+// 72h0m0s as equal.
+func equalConfigValues(a, b json.RawMessage) bool {
+	var av, bv interface{}
+	if json.Unmarshal(a, &av) != nil || json.Unmarshal(b, &bv) != nil {
+		return bytes.Equal(a, b)
+	}
+	as, aok := av.(string)
+	bs, bok := bv.(string)
+	if aok && bok {
+		ad, aerr := time.ParseDuration(as)
+		bd, berr := time.ParseDuration(bs)
+		if aerr == nil && berr == nil {
+			return ad == bd
+		}
+	}
+	return reflect.DeepEqual(av, bv)
+}
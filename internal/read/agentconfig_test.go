@@ -0,0 +1,197 @@
+package read
+
+import (
+	"encoding/json"
+	"github.com/hashicorp/hcl"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestQueryConfig(t *testing.T) {
+	agent := Agent{RawDebugConfig: []byte(`{
+		"DNSAllowStale": false,
+		"DNSMaxStale": "87600h",
+		"DNSSOA": {"Expire": 86400, "Retry": 600},
+		"NodeName": "server-1",
+		"RetryJoinLAN": ["10.0.0.2", "10.0.0.3"],
+		"TLS": {"GRPC": {"VerifyIncoming": false}, "InternalRPC": {"VerifyIncoming": true, "VerifyOutgoing": true}},
+		"Logging": {"LogLevel": "DEBUG"}
+	}`)}
+	cases := []struct {
+		query   string
+		paths   []string
+		wantErr bool
+	}{
+		{query: "DNS*", paths: []string{"DNSAllowStale", "DNSMaxStale", "DNSSOA.Expire", "DNSSOA.Retry"}},
+		{query: "$.dnssoa.*", paths: []string{"DNSSOA.Expire", "DNSSOA.Retry"}},
+		{query: "RetryJoinLAN[1]", paths: []string{"RetryJoinLAN[1]"}},
+		{query: "RetryJoinLAN[*]", paths: []string{"RetryJoinLAN[0]", "RetryJoinLAN[1]"}},
+		{query: "TLS.*.Verify[IO]*", paths: []string{"TLS.GRPC.VerifyIncoming", "TLS.InternalRPC.VerifyIncoming", "TLS.InternalRPC.VerifyOutgoing"}},
+		{query: "NodeName", paths: []string{"NodeName"}},
+		{query: "Limits.*", wantErr: true},
+		{query: "TLS..GRPC", wantErr: true},
+	}
+	for _, tc := range cases {
+		settings, err := agent.QueryConfig(tc.query)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("%q: expected error, got %v", tc.query, settings)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", tc.query, err)
+		}
+		var paths []string
+		for _, s := range settings {
+			paths = append(paths, s.Path)
+		}
+		if strings.Join(paths, ",") != strings.Join(tc.paths, ",") {
+			t.Errorf("%q: paths = %v, want %v", tc.query, paths, tc.paths)
+		}
+	}
+
+	all, err := agent.QueryConfig("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	changed, err := NonDefaultSettings(all, "1.17.2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	for _, s := range changed {
+		paths = append(paths, s.Path+"="+string(s.Value)+"/"+string(s.Default))
+	}
+	// DNSMaxStale matches the 87600h0m0s default; NodeName has no default.
+	want := "DNSAllowStale=false/true,Logging.LogLevel=\"DEBUG\"/\"INFO\",TLS.InternalRPC.VerifyIncoming=true/false,TLS.InternalRPC.VerifyOutgoing=true/false"
+	if strings.Join(paths, ",") != want {
+		t.Errorf("non-default settings = %v, want %s", paths, want)
+	}
+	if changed, _ = NonDefaultSettings(all, "1.11.0"); len(changed) != 2 {
+		t.Errorf("expected the TLS defaults to only apply from 1.12.0, got %v", changed)
+	}
+}
+
+func TestReconstructConfig(t *testing.T) {
+	agent := Agent{Config: Config{Version: "1.17.2"}, RawDebugConfig: []byte(`{
+		"Datacenter": "dc1",
+		"NodeName": "server-1",
+		"DataDir": "/opt/consul",
+		"ServerMode": true,
+		"BindAddr": "10.0.0.1",
+		"ClientAddrs": [],
+		"HTTPAddrs": ["tcp://0.0.0.0:8500", "unix:///var/run/consul.sock"],
+		"DNSAllowStale": true,
+		"DNSServiceTTL": {"*": "5s"},
+		"ConsulRaftElectionTimeout": "1s",
+		"EncryptKey": "hidden",
+		"ACLTokens": {"ACLAgentToken": "hidden", "ACLDefaultToken": ""},
+		"ConnectCAConfig": {"Address": "https://vault:8200", "Token": "hidden"},
+		"LeaveOnTerm": false,
+		"ReadReplica": false,
+		"Telemetry": {"AllowedPrefixes": ["consul.raft"], "BlockedPrefixes": ["consul.rpc"]},
+		"TLS": {"InternalRPC": {"VerifyIncoming": true, "CipherSuites": ["TLS_AES_128_GCM_SHA256"]}},
+		"NodeMeta": {"rack": "r1", "team-name": "core"},
+		"Watches": [
+			{"type": "key", "key": "app/${env}/config", "handler_type": "http", "http_handler_config": {"path": "https://hooks.internal/consul", "method": "POST"}},
+			{"type": "service", "service": "web", "args": ["/usr/local/bin/notify", "--service", "web"]}
+		]
+	}`)}
+	file, err := agent.ReconstructConfig()
+	if err != nil {
+		t.Fatalf("ReconstructConfig: %v", err)
+	}
+	if redacted := strings.Join(file.Redacted, ","); redacted != "acl.tokens.agent,connect.ca_config.Token,encrypt" {
+		t.Errorf("unexpected redacted keys %s", redacted)
+	}
+	got, err := file.JSON()
+	if err != nil {
+		t.Fatalf("JSON: %v", err)
+	}
+	for _, want := range []string{
+		`"http": "0.0.0.0 unix:///var/run/consul.sock"`,
+		`"raft_multiplier": 1`,
+		`"leave_on_terminate": false`,
+		`"prefix_filter": [`,
+		`"-consul.rpc"`,
+		`"tls_cipher_suites": "TLS_AES_128_GCM_SHA256"`,
+		`"Address": "https://vault:8200"`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %s in reconstructed config:\n%s", want, got)
+		}
+	}
+	for _, unwanted := range []string{"allow_stale", "client_addr", "read_replica", "hidden"} {
+		if strings.Contains(got, unwanted) {
+			t.Errorf("expected no %s in reconstructed config:\n%s", unwanted, got)
+		}
+	}
+
+	// Consul must be able to load both forms, to the same configuration.
+	var fromJSON, fromHCL map[string]interface{}
+	if err = json.Unmarshal([]byte(got), &fromJSON); err != nil {
+		t.Fatalf("reconstructed JSON does not decode: %v\n%s", err, got)
+	}
+	hclConfig := file.HCL()
+	if _, err = hcl.Parse(hclConfig); err != nil {
+		t.Fatalf("reconstructed HCL does not parse: %v\n%s", err, hclConfig)
+	}
+	if err = hcl.Decode(&fromHCL, hclConfig); err != nil {
+		t.Fatalf("reconstructed HCL does not decode: %v\n%s", err, hclConfig)
+	}
+	if !reflect.DeepEqual(normalizeConfig(fromJSON), normalizeConfig(fromHCL)) {
+		t.Errorf("HCL and JSON configurations differ:\n%s\n%s", hclConfig, got)
+	}
+	watches, _ := normalizeConfig(fromHCL).(map[string]interface{})["watches"].([]interface{})
+	if len(watches) != 2 || watches[0].(map[string]interface{})["key"] != "app/${env}/config" ||
+		watches[0].(map[string]interface{})["http_handler_config"].(map[string]interface{})["method"] != "POST" {
+		t.Errorf("unexpected watches %+v in HCL:\n%s", watches, hclConfig)
+	}
+
+	for _, want := range []string{
+		"#   encrypt\n",
+		"server = true\n",
+		"dns_config {\n  service_ttl {\n    \"*\" = \"5s\"\n  }\n}",
+		"tls {\n  internal_rpc {\n",
+		"node_meta {\n  rack = \"r1\"\n  team-name = \"core\"\n}",
+		"watches = [{\n  handler_type = \"http\"\n",
+		"  }\n}, {\n  args = [",
+	} {
+		if !strings.Contains(hclConfig, want) {
+			t.Errorf("expected %q in HCL:\n%s", want, hclConfig)
+		}
+	}
+}
+
+// normalizeConfig makes decoded HCL and JSON comparable: HCL decodes each block to a list of one
+// object, and numbers to ints.
+func normalizeConfig(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k, child := range v {
+			out[k] = normalizeConfig(child)
+		}
+		return out
+	case []map[string]interface{}:
+		if len(v) == 1 {
+			return normalizeConfig(v[0])
+		}
+		out := make([]interface{}, len(v))
+		for i, child := range v {
+			out[i] = normalizeConfig(child)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, child := range v {
+			out[i] = normalizeConfig(child)
+		}
+		return out
+	case int:
+		return float64(v)
+	}
+	return value
+}
@@ -0,0 +1,738 @@
+package read
+
+import (
+	"bytes"
+	"consul-debug-read/pkg/redact"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// configKeyKind is how a DebugConfig setting converts to its agent configuration file value.
+type configKeyKind int
+
+const (
+	// kindValue copies the setting as is.
+	kindValue configKeyKind = iota
+	// kindClientAddr joins the client addresses into client_addr's space separated list.
+	kindClientAddr
+	// kindListenAddrs converts the listener URLs of an addresses entry to their addresses, left
+	// out when they are the client addresses.
+	kindListenAddrs
+	// kindBindAddr strips the scheme and port of a serf bind address, left out when it is bind_addr.
+	kindBindAddr
+	// kindAdvertiseWAN is advertise_addr_wan, left out when it is advertise_addr.
+	kindAdvertiseWAN
+	// kindRaftMultiplier derives performance.raft_multiplier from the scaled election timeout.
+	kindRaftMultiplier
+	// kindPrefixFilter combines the allowed and blocked metric prefixes into telemetry.prefix_filter.
+	kindPrefixFilter
+	// kindRequestLimitsMode converts a numeric request limits mode to its name.
+	kindRequestLimitsMode
+	// kindCipherSuites joins a cipher suite list into the comma separated tls_cipher_suites.
+	kindCipherSuites
+	// kindMegabytes converts bytes to the megabytes of the configuration key.
+	kindMegabytes
+)
+
+// configKey maps a DebugConfig setting to its agent configuration file key.
+type configKey struct {
+	setting string
+	key     string
+	kind    configKeyKind
+	// always keys are written even when they hold Consul's default.
+	always bool
+	// enterprise keys are only written when set, as Consul CE warns about them.
+	enterprise bool
+}
+
+// configKeys maps the runtime DebugConfig settings back to the agent configuration file keys that
+// set them. Settings that only exist in some Consul versions are simply absent from the others.
+// Reference: https://developer.hashicorp.com/consul/docs/agent/config/config-files
+var configKeys = []configKey{
+	// Agent
+	{setting: "Datacenter", key: "datacenter", always: true},
+	{setting: "PrimaryDatacenter", key: "primary_datacenter"},
+	{setting: "NodeName", key: "node_name", always: true},
+	{setting: "NodeID", key: "node_id"},
+	{setting: "NodeMeta", key: "node_meta"},
+	{setting: "DataDir", key: "data_dir", always: true},
+	{setting: "ServerMode", key: "server", always: true},
+	{setting: "Bootstrap", key: "bootstrap"},
+	{setting: "BootstrapExpect", key: "bootstrap_expect"},
+	{setting: "ReadReplica", key: "read_replica", enterprise: true},
+	{setting: "SegmentName", key: "segment", enterprise: true},
+	{setting: "PidFile", key: "pid_file"},
+	{setting: "EncryptKey", key: "encrypt"},
+	{setting: "StaticRuntimeConfig.EncryptVerifyIncoming", key: "encrypt_verify_incoming"},
+	{setting: "StaticRuntimeConfig.EncryptVerifyOutgoing", key: "encrypt_verify_outgoing"},
+	{setting: "EncryptVerifyIncoming", key: "encrypt_verify_incoming"},
+	{setting: "EncryptVerifyOutgoing", key: "encrypt_verify_outgoing"},
+	{setting: "DisableAnonymousSignature", key: "disable_anonymous_signature"},
+	{setting: "DisableCoordinates", key: "disable_coordinates"},
+	{setting: "DisableHostNodeID", key: "disable_host_node_id"},
+	{setting: "DisableHTTPUnprintableCharFilter", key: "disable_http_unprintable_char_filter"},
+	{setting: "DisableKeyringFile", key: "disable_keyring_file"},
+	{setting: "DisableRemoteExec", key: "disable_remote_exec"},
+	{setting: "DisableUpdateCheck", key: "disable_update_check"},
+	{setting: "DiscardCheckOutput", key: "discard_check_output"},
+	{setting: "DiscoveryMaxStale", key: "discovery_max_stale"},
+	{setting: "EnableAgentTLSForChecks", key: "enable_agent_tls_for_checks"},
+	{setting: "EnableCentralServiceConfig", key: "enable_central_service_config"},
+	{setting: "EnableDebug", key: "enable_debug"},
+	{setting: "EnableLocalScriptChecks", key: "enable_local_script_checks"},
+	{setting: "EnableRemoteScriptChecks", key: "enable_script_checks"},
+	{setting: "CheckOutputMaxSize", key: "check_output_max_size"},
+	{setting: "CheckUpdateInterval", key: "check_update_interval"},
+	{setting: "DefaultQueryTime", key: "default_query_time"},
+	{setting: "MaxQueryTime", key: "max_query_time"},
+	{setting: "SessionTTLMin", key: "session_ttl_min"},
+	{setting: "UseStreamingBackend", key: "use_streaming_backend"},
+	{setting: "AutoReloadConfig", key: "auto_reload_config"},
+	{setting: "LeaveOnTerm", key: "leave_on_terminate"},
+	{setting: "SkipLeaveOnInt", key: "skip_leave_on_interrupt"},
+	{setting: "RejoinAfterLeave", key: "rejoin_after_leave"},
+	{setting: "EnterpriseRuntimeConfig.LicensePath", key: "license_path", enterprise: true},
+
+	// Logging
+	{setting: "Logging.LogLevel", key: "log_level"},
+	{setting: "Logging.LogJSON", key: "log_json"},
+	{setting: "Logging.LogFilePath", key: "log_file"},
+	{setting: "Logging.LogRotateBytes", key: "log_rotate_bytes"},
+	{setting: "Logging.LogRotateDuration", key: "log_rotate_duration"},
+	{setting: "Logging.LogRotateMaxFiles", key: "log_rotate_max_files"},
+	{setting: "Logging.EnableSyslog", key: "enable_syslog"},
+	{setting: "Logging.SyslogFacility", key: "syslog_facility"},
+
+	// Addresses
+	{setting: "BindAddr", key: "bind_addr"},
+	{setting: "ClientAddrs", key: "client_addr", kind: kindClientAddr},
+	{setting: "AdvertiseAddrLAN", key: "advertise_addr"},
+	{setting: "AdvertiseAddrWAN", key: "advertise_addr_wan", kind: kindAdvertiseWAN},
+	{setting: "AdvertiseReconnectTimeout", key: "advertise_reconnect_timeout"},
+	{setting: "SerfBindAddrLAN", key: "serf_lan", kind: kindBindAddr},
+	{setting: "SerfBindAddrWAN", key: "serf_wan", kind: kindBindAddr},
+	{setting: "SerfAllowedCIDRsLAN", key: "serf_lan_allowed_cidrs"},
+	{setting: "SerfAllowedCIDRsWAN", key: "serf_wan_allowed_cidrs"},
+	{setting: "TranslateWANAddrs", key: "translate_wan_addrs"},
+	{setting: "DNSAddrs", key: "addresses.dns", kind: kindListenAddrs},
+	{setting: "HTTPAddrs", key: "addresses.http", kind: kindListenAddrs},
+	{setting: "HTTPSAddrs", key: "addresses.https", kind: kindListenAddrs},
+	{setting: "GRPCAddrs", key: "addresses.grpc", kind: kindListenAddrs},
+	{setting: "GRPCTLSAddrs", key: "addresses.grpc_tls", kind: kindListenAddrs},
+	{setting: "UnixSocketUser", key: "unix_sockets.user"},
+	{setting: "UnixSocketGroup", key: "unix_sockets.group"},
+	{setting: "UnixSocketMode", key: "unix_sockets.mode"},
+
+	// Ports
+	{setting: "DNSPort", key: "ports.dns"},
+	{setting: "HTTPPort", key: "ports.http"},
+	{setting: "HTTPSPort", key: "ports.https"},
+	{setting: "GRPCPort", key: "ports.grpc"},
+	{setting: "GRPCTLSPort", key: "ports.grpc_tls"},
+	{setting: "SerfPortLAN", key: "ports.serf_lan"},
+	{setting: "SerfPortWAN", key: "ports.serf_wan"},
+	{setting: "ServerPort", key: "ports.server"},
+	{setting: "ConnectSidecarMinPort", key: "ports.sidecar_min_port"},
+	{setting: "ConnectSidecarMaxPort", key: "ports.sidecar_max_port"},
+	{setting: "ExposeMinPort", key: "ports.expose_min_port"},
+	{setting: "ExposeMaxPort", key: "ports.expose_max_port"},
+
+	// Joining and reconnecting
+	{setting: "RetryJoinLAN", key: "retry_join"},
+	{setting: "RetryJoinWAN", key: "retry_join_wan"},
+	{setting: "RetryJoinIntervalLAN", key: "retry_interval"},
+	{setting: "RetryJoinIntervalWAN", key: "retry_interval_wan"},
+	{setting: "RetryJoinMaxAttemptsLAN", key: "retry_max"},
+	{setting: "RetryJoinMaxAttemptsWAN", key: "retry_max_wan"},
+	{setting: "ReconnectTimeoutLAN", key: "reconnect_timeout"},
+	{setting: "ReconnectTimeoutWAN", key: "reconnect_timeout_wan"},
+	{setting: "PrimaryGateways", key: "primary_gateways"},
+	{setting: "PrimaryGatewaysInterval", key: "primary_gateways_interval"},
+
+	// ACLs
+	{setting: "ACLResolverSettings.ACLsEnabled", key: "acl.enabled"},
+	{setting: "ACLsEnabled", key: "acl.enabled"},
+	{setting: "ACLResolverSettings.ACLDefaultPolicy", key: "acl.default_policy"},
+	{setting: "ACLResolverSettings.ACLDownPolicy", key: "acl.down_policy"},
+	{setting: "ACLResolverSettings.ACLPolicyTTL", key: "acl.policy_ttl"},
+	{setting: "ACLResolverSettings.ACLRoleTTL", key: "acl.role_ttl"},
+	{setting: "ACLResolverSettings.ACLTokenTTL", key: "acl.token_ttl"},
+	{setting: "ACLTokenReplication", key: "acl.enable_token_replication"},
+	{setting: "ACLEnableKeyListPolicy", key: "acl.enable_key_list_policy"},
+	{setting: "ACLTokens.EnablePersistence", key: "acl.enable_token_persistence"},
+	{setting: "ACLInitialManagementToken", key: "acl.tokens.initial_management"},
+	{setting: "ACLTokens.ACLAgentRecoveryToken", key: "acl.tokens.agent_recovery"},
+	{setting: "ACLTokens.ACLAgentToken", key: "acl.tokens.agent"},
+	{setting: "ACLTokens.ACLDefaultToken", key: "acl.tokens.default"},
+	{setting: "ACLTokens.ACLReplicationToken", key: "acl.tokens.replication"},
+	{setting: "ACLTokens.ACLConfigFileRegistrationToken", key: "acl.tokens.config_file_service_registration"},
+	{setting: "ACLTokens.ACLDNSToken", key: "acl.tokens.dns"},
+
+	// Connect
+	{setting: "ConnectEnabled", key: "connect.enabled"},
+	{setting: "ConnectCAProvider", key: "connect.ca_provider"},
+	{setting: "ConnectCAConfig", key: "connect.ca_config"},
+	{setting: "ConnectMeshGatewayWANFederationEnabled", key: "connect.enable_mesh_gateway_wan_federation"},
+	{setting: "PeeringEnabled", key: "peering.enabled"},
+	{setting: "AutoEncryptTLS", key: "auto_encrypt.tls"},
+	{setting: "AutoEncryptAllowTLS", key: "auto_encrypt.allow_tls"},
+	{setting: "AutoEncryptDNSSAN", key: "auto_encrypt.dns_san"},
+	{setting: "AutoEncryptIPSAN", key: "auto_encrypt.ip_san"},
+	{setting: "AutoConfig.Enabled", key: "auto_config.enabled"},
+	{setting: "AutoConfig.IntroToken", key: "auto_config.intro_token"},
+	{setting: "AutoConfig.IntroTokenFile", key: "auto_config.intro_token_file"},
+	{setting: "AutoConfig.ServerAddresses", key: "auto_config.server_addresses"},
+	{setting: "AutoConfig.DNSSANs", key: "auto_config.dns_sans"},
+	{setting: "AutoConfig.IPSANs", key: "auto_config.ip_sans"},
+
+	// TLS, per listener since Consul 1.12
+	{setting: "ServerName", key: "server_name"},
+	{setting: "TLS.InternalRPC.CAFile", key: "tls.internal_rpc.ca_file"},
+	{setting: "TLS.InternalRPC.CAPath", key: "tls.internal_rpc.ca_path"},
+	{setting: "TLS.InternalRPC.CertFile", key: "tls.internal_rpc.cert_file"},
+	{setting: "TLS.InternalRPC.KeyFile", key: "tls.internal_rpc.key_file"},
+	{setting: "TLS.InternalRPC.CipherSuites", key: "tls.internal_rpc.tls_cipher_suites", kind: kindCipherSuites},
+	{setting: "TLS.InternalRPC.TLSMinVersion", key: "tls.internal_rpc.tls_min_version"},
+	{setting: "TLS.InternalRPC.VerifyIncoming", key: "tls.internal_rpc.verify_incoming"},
+	{setting: "TLS.InternalRPC.VerifyOutgoing", key: "tls.internal_rpc.verify_outgoing"},
+	{setting: "TLS.InternalRPC.VerifyServerHostname", key: "tls.internal_rpc.verify_server_hostname"},
+	{setting: "TLS.HTTPS.CAFile", key: "tls.https.ca_file"},
+	{setting: "TLS.HTTPS.CAPath", key: "tls.https.ca_path"},
+	{setting: "TLS.HTTPS.CertFile", key: "tls.https.cert_file"},
+	{setting: "TLS.HTTPS.KeyFile", key: "tls.https.key_file"},
+	{setting: "TLS.HTTPS.CipherSuites", key: "tls.https.tls_cipher_suites", kind: kindCipherSuites},
+	{setting: "TLS.HTTPS.TLSMinVersion", key: "tls.https.tls_min_version"},
+	{setting: "TLS.HTTPS.VerifyIncoming", key: "tls.https.verify_incoming"},
+	{setting: "TLS.HTTPS.VerifyOutgoing", key: "tls.https.verify_outgoing"},
+	{setting: "TLS.GRPC.CAFile", key: "tls.grpc.ca_file"},
+	{setting: "TLS.GRPC.CAPath", key: "tls.grpc.ca_path"},
+	{setting: "TLS.GRPC.CertFile", key: "tls.grpc.cert_file"},
+	{setting: "TLS.GRPC.KeyFile", key: "tls.grpc.key_file"},
+	{setting: "TLS.GRPC.CipherSuites", key: "tls.grpc.tls_cipher_suites", kind: kindCipherSuites},
+	{setting: "TLS.GRPC.TLSMinVersion", key: "tls.grpc.tls_min_version"},
+	{setting: "TLS.GRPC.VerifyIncoming", key: "tls.grpc.verify_incoming"},
+	{setting: "TLS.GRPC.UseAutoCert", key: "tls.grpc.use_auto_cert"},
+	// TLS before Consul 1.12
+	{setting: "CAFile", key: "ca_file"},
+	{setting: "CAPath", key: "ca_path"},
+	{setting: "CertFile", key: "cert_file"},
+	{setting: "KeyFile", key: "key_file"},
+	{setting: "TLSCipherSuites", key: "tls_cipher_suites", kind: kindCipherSuites},
+	{setting: "TLSMinVersion", key: "tls_min_version"},
+	{setting: "VerifyIncoming", key: "verify_incoming"},
+	{setting: "VerifyIncomingRPC", key: "verify_incoming_rpc"},
+	{setting: "VerifyIncomingHTTPS", key: "verify_incoming_https"},
+	{setting: "VerifyOutgoing", key: "verify_outgoing"},
+	{setting: "VerifyServerHostname", key: "verify_server_hostname"},
+
+	// DNS
+	{setting: "DNSDomain", key: "domain"},
+	{setting: "DNSAltDomain", key: "alt_domain"},
+	{setting: "DNSRecursors", key: "recursors"},
+	{setting: "DNSAllowStale", key: "dns_config.allow_stale"},
+	{setting: "DNSARecordLimit", key: "dns_config.a_record_limit"},
+	{setting: "DNSDisableCompression", key: "dns_config.disable_compression"},
+	{setting: "DNSEnableTruncate", key: "dns_config.enable_truncate"},
+	{setting: "DNSMaxStale", key: "dns_config.max_stale"},
+	{setting: "DNSNodeTTL", key: "dns_config.node_ttl"},
+	{setting: "DNSOnlyPassing", key: "dns_config.only_passing"},
+	{setting: "DNSRecursorStrategy", key: "dns_config.recursor_strategy"},
+	{setting: "DNSRecursorTimeout", key: "dns_config.recursor_timeout"},
+	{setting: "DNSServiceTTL", key: "dns_config.service_ttl"},
+	{setting: "DNSUDPAnswerLimit", key: "dns_config.udp_answer_limit"},
+	{setting: "DNSNodeMetaTXT", key: "dns_config.enable_additional_node_meta_txt"},
+	{setting: "DNSSOA.Refresh", key: "dns_config.soa.refresh"},
+	{setting: "DNSSOA.Retry", key: "dns_config.soa.retry"},
+	{setting: "DNSSOA.Expire", key: "dns_config.soa.expire"},
+	{setting: "DNSSOA.Minttl", key: "dns_config.soa.min_ttl"},
+	{setting: "DNSUseCache", key: "dns_config.use_cache"},
+	{setting: "DNSCacheMaxAge", key: "dns_config.cache_max_age"},
+	{setting: "EnterpriseRuntimeConfig.DNSPreferNamespace", key: "dns_config.prefer_namespace", enterprise: true},
+
+	// HTTP
+	{setting: "HTTPBlockEndpoints", key: "http_config.block_endpoints"},
+	{setting: "AllowWriteHTTPFrom", key: "http_config.allow_write_http_from"},
+	{setting: "HTTPResponseHeaders", key: "http_config.response_headers"},
+	{setting: "HTTPUseCache", key: "http_config.use_cache"},
+	{setting: "HTTPMaxHeaderBytes", key: "http_config.max_header_bytes"},
+
+	// Limits
+	{setting: "HTTPMaxConnsPerClient", key: "limits.http_max_conns_per_client"},
+	{setting: "HTTPSHandshakeTimeout", key: "limits.https_handshake_timeout"},
+	{setting: "RPCClientTimeout", key: "limits.rpc_client_timeout"},
+	{setting: "RPCHandshakeTimeout", key: "limits.rpc_handshake_timeout"},
+	{setting: "RPCMaxBurst", key: "limits.rpc_max_burst"},
+	{setting: "RPCMaxConnsPerClient", key: "limits.rpc_max_conns_per_client"},
+	{setting: "RPCRateLimit", key: "limits.rpc_rate"},
+	{setting: "KVMaxValueSize", key: "limits.kv_max_value_size"},
+	{setting: "TxnMaxReqLen", key: "limits.txn_max_req_len"},
+	{setting: "RequestLimitsMode", key: "limits.request_limits.mode", kind: kindRequestLimitsMode},
+	{setting: "RequestLimitsReadRate", key: "limits.request_limits.read_rate"},
+	{setting: "RequestLimitsWriteRate", key: "limits.request_limits.write_rate"},
+
+	// Performance
+	{setting: "ConsulRaftElectionTimeout", key: "performance.raft_multiplier", kind: kindRaftMultiplier},
+	{setting: "LeaveDrainTime", key: "performance.leave_drain_time"},
+	{setting: "RPCHoldTimeout", key: "performance.rpc_hold_timeout"},
+
+	// Raft
+	{setting: "RaftProtocol", key: "raft_protocol"},
+	{setting: "RaftSnapshotInterval", key: "raft_snapshot_interval"},
+	{setting: "RaftSnapshotThreshold", key: "raft_snapshot_threshold"},
+	{setting: "RaftTrailingLogs", key: "raft_trailing_logs"},
+	{setting: "RaftLogStoreConfig.Backend", key: "raft_logstore.backend"},
+	{setting: "RaftLogStoreConfig.DisableLogCache", key: "raft_logstore.disable_log_cache"},
+	{setting: "RaftLogStoreConfig.Verification.Enabled", key: "raft_logstore.verification.enabled"},
+	{setting: "RaftLogStoreConfig.Verification.Interval", key: "raft_logstore.verification.interval"},
+	{setting: "RaftLogStoreConfig.BoltDB.NoFreelistSync", key: "raft_logstore.boltdb.no_freelist_sync"},
+	{setting: "RaftLogStoreConfig.WAL.SegmentSize", key: "raft_logstore.wal.segment_size_mb", kind: kindMegabytes},
+
+	// Autopilot
+	{setting: "AutopilotCleanupDeadServers", key: "autopilot.cleanup_dead_servers"},
+	{setting: "AutopilotLastContactThreshold", key: "autopilot.last_contact_threshold"},
+	{setting: "AutopilotMaxTrailingLogs", key: "autopilot.max_trailing_logs"},
+	{setting: "AutopilotMinQuorum", key: "autopilot.min_quorum"},
+	{setting: "AutopilotServerStabilizationTime", key: "autopilot.server_stabilization_time"},
+	{setting: "AutopilotRedundancyZoneTag", key: "autopilot.redundancy_zone_tag", enterprise: true},
+	{setting: "AutopilotDisableUpgradeMigration", key: "autopilot.disable_upgrade_migration", enterprise: true},
+	{setting: "AutopilotUpgradeVersionTag", key: "autopilot.upgrade_version_tag", enterprise: true},
+
+	// Gossip
+	{setting: "GossipLANGossipInterval", key: "gossip_lan.gossip_interval"},
+	{setting: "GossipLANGossipNodes", key: "gossip_lan.gossip_nodes"},
+	{setting: "GossipLANProbeInterval", key: "gossip_lan.probe_interval"},
+	{setting: "GossipLANProbeTimeout", key: "gossip_lan.probe_timeout"},
+	{setting: "GossipLANRetransmitMult", key: "gossip_lan.retransmit_mult"},
+	{setting: "GossipLANSuspicionMult", key: "gossip_lan.suspicion_mult"},
+	{setting: "GossipWANGossipInterval", key: "gossip_wan.gossip_interval"},
+	{setting: "GossipWANGossipNodes", key: "gossip_wan.gossip_nodes"},
+	{setting: "GossipWANProbeInterval", key: "gossip_wan.probe_interval"},
+	{setting: "GossipWANProbeTimeout", key: "gossip_wan.probe_timeout"},
+	{setting: "GossipWANRetransmitMult", key: "gossip_wan.retransmit_mult"},
+	{setting: "GossipWANSuspicionMult", key: "gossip_wan.suspicion_mult"},
+
+	// Telemetry
+	{setting: "Telemetry.MetricsPrefix", key: "telemetry.metrics_prefix"},
+	{setting: "Telemetry.DisableHostname", key: "telemetry.disable_hostname"},
+	{setting: "Telemetry.EnableHostMetrics", key: "telemetry.enable_host_metrics"},
+	{setting: "Telemetry.FilterDefault", key: "telemetry.filter_default"},
+	{setting: "Telemetry.AllowedPrefixes", key: "telemetry.prefix_filter", kind: kindPrefixFilter},
+	{setting: "Telemetry.RetryFailedConfiguration", key: "telemetry.retry_failed_connection"},
+	{setting: "Telemetry.PrometheusOpts.Expiration", key: "telemetry.prometheus_retention_time"},
+	{setting: "Telemetry.StatsdAddr", key: "telemetry.statsd_address"},
+	{setting: "Telemetry.StatsiteAddr", key: "telemetry.statsite_address"},
+	{setting: "Telemetry.DogstatsdAddr", key: "telemetry.dogstatsd_addr"},
+	{setting: "Telemetry.DogstatsdTags", key: "telemetry.dogstatsd_tags"},
+	{setting: "Telemetry.CirconusAPIToken", key: "telemetry.circonus_api_token"},
+	{setting: "Telemetry.CirconusAPIApp", key: "telemetry.circonus_api_app"},
+	{setting: "Telemetry.CirconusAPIURL", key: "telemetry.circonus_api_url"},
+	{setting: "Telemetry.CirconusBrokerID", key: "telemetry.circonus_broker_id"},
+	{setting: "Telemetry.CirconusBrokerSelectTag", key: "telemetry.circonus_broker_select_tag"},
+	{setting: "Telemetry.CirconusCheckDisplayName", key: "telemetry.circonus_check_display_name"},
+	{setting: "Telemetry.CirconusCheckForceMetricActivation", key: "telemetry.circonus_check_force_metric_activation"},
+	{setting: "Telemetry.CirconusCheckID", key: "telemetry.circonus_check_id"},
+	{setting: "Telemetry.CirconusCheckInstanceID", key: "telemetry.circonus_check_instance_id"},
+	{setting: "Telemetry.CirconusCheckSearchTag", key: "telemetry.circonus_check_search_tag"},
+	{setting: "Telemetry.CirconusCheckTags", key: "telemetry.circonus_check_tags"},
+	{setting: "Telemetry.CirconusSubmissionInterval", key: "telemetry.circonus_submission_interval"},
+	{setting: "Telemetry.CirconusSubmissionURL", key: "telemetry.circonus_submission_url"},
+
+	// UI
+	{setting: "UIConfig.Enabled", key: "ui_config.enabled"},
+	{setting: "UIConfig.Dir", key: "ui_config.dir"},
+	{setting: "UIConfig.ContentPath", key: "ui_config.content_path"},
+	{setting: "UIConfig.MetricsProvider", key: "ui_config.metrics_provider"},
+	{setting: "UIConfig.MetricsProviderFiles", key: "ui_config.metrics_provider_files"},
+	{setting: "UIConfig.MetricsProviderOptionsJSON", key: "ui_config.metrics_provider_options_json"},
+	{setting: "UIConfig.MetricsProxy.BaseURL", key: "ui_config.metrics_proxy.base_url"},
+	{setting: "UIConfig.MetricsProxy.PathAllowlist", key: "ui_config.metrics_proxy.path_allowlist"},
+	{setting: "UIConfig.DashboardURLTemplates", key: "ui_config.dashboard_url_templates"},
+
+	// Miscellaneous
+	{setting: "Cache.EntryFetchMaxBurst", key: "cache.entry_fetch_max_burst"},
+	{setting: "Cache.EntryFetchRate", key: "cache.entry_fetch_rate"},
+	{setting: "RPCConfig.EnableStreaming", key: "rpc.enable_streaming"},
+	{setting: "XDSUpdateRateLimit", key: "xds.update_max_per_second"},
+	{setting: "Cloud.ClientID", key: "cloud.client_id"},
+	{setting: "Cloud.ClientSecret", key: "cloud.client_secret"},
+	{setting: "Cloud.ResourceID", key: "cloud.resource_id"},
+	{setting: "Cloud.Hostname", key: "cloud.hostname"},
+	{setting: "Cloud.AuthURL", key: "cloud.auth_url"},
+	{setting: "Cloud.ScadaAddress", key: "cloud.scada_address"},
+	{setting: "Watches", key: "watches"},
+}
+
+// AgentConfigFile is an agent configuration file reconstructed from a DebugConfig.
+type AgentConfigFile struct {
+	// Config holds the configuration keys, nested by block.
+	Config map[string]interface{}
+	// Redacted are the dotted keys of secrets that were set but redacted in the DebugConfig, by
+	// Consul or a redact.Redactor, and so are left out of Config.
+	Redacted []string
+}
+
+// ReconstructConfig maps the DebugConfig of agent.json back to the agent configuration that
+// produces it. Settings holding Consul's documented default for the agent version are left out,
+// as are settings without a documented default that are unset, except booleans, which are kept
+// since their default varies. Service, check and config entry definitions are not reconstructed.
+func (a *Agent) ReconstructConfig() (*AgentConfigFile, error) {
+	if len(a.RawDebugConfig) == 0 {
+		return nil, errors.New("agent.json has no DebugConfig")
+	}
+	dec := json.NewDecoder(bytes.NewReader(a.RawDebugConfig))
+	dec.UseNumber()
+	var tree map[string]interface{}
+	if err := dec.Decode(&tree); err != nil {
+		return nil, err
+	}
+	catalog, err := LoadAgentConfigDefaults()
+	if err != nil {
+		return nil, err
+	}
+	defaults := catalog.ForVersion(a.Config.Version)
+
+	file := &AgentConfigFile{Config: make(map[string]interface{})}
+	for _, k := range configKeys {
+		raw, ok := lookupSetting(tree, k.setting)
+		if !ok {
+			continue
+		}
+		value, ok := convertSetting(k, raw, tree)
+		if !ok {
+			continue
+		}
+		if value, ok = file.dropRedacted(k.key, value); !ok {
+			continue
+		}
+		if !k.always {
+			if def, ok := defaults[k.setting]; ok {
+				if isConfigDefault(raw, def) || isConfigDefault(value, def) {
+					continue
+				}
+			} else if _, isBool := value.(bool); (!isBool || k.enterprise) && zeroConfigValue(value) {
+				continue
+			}
+		}
+		setConfigKey(file.Config, k.key, value)
+	}
+	sort.Strings(file.Redacted)
+	return file, nil
+}
+
+// lookupSetting returns the value of the DebugConfig setting at a dotted path.
+func lookupSetting(tree map[string]interface{}, setting string) (interface{}, bool) {
+	var value interface{} = tree
+	for _, name := range strings.Split(setting, ".") {
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if value, ok = obj[name]; !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+func convertSetting(k configKey, raw interface{}, tree map[string]interface{}) (interface{}, bool) {
+	switch k.kind {
+	case kindClientAddr:
+		addrs := configStrings(raw)
+		if len(addrs) == 0 || (len(addrs) == 1 && addrs[0] == "127.0.0.1") {
+			return nil, false
+		}
+		return strings.Join(addrs, " "), true
+	case kindListenAddrs:
+		var addrs []string
+		for _, listener := range configStrings(raw) {
+			if addr := listenerAddr(listener); addr != "" && !containsString(addrs, addr) {
+				addrs = append(addrs, addr)
+			}
+		}
+		clientAddrs, _ := lookupSetting(tree, "ClientAddrs")
+		if len(addrs) == 0 || sameStrings(addrs, configStrings(clientAddrs)) {
+			return nil, false
+		}
+		return strings.Join(addrs, " "), true
+	case kindBindAddr:
+		s, _ := raw.(string)
+		addr := listenerAddr(s)
+		bind, _ := lookupSetting(tree, "BindAddr")
+		if addr == "" || addr == bind {
+			return nil, false
+		}
+		return addr, true
+	case kindAdvertiseWAN:
+		if lan, _ := lookupSetting(tree, "AdvertiseAddrLAN"); raw == lan {
+			return nil, false
+		}
+		return raw, true
+	case kindRaftMultiplier:
+		// Consul scales the 1s base election timeout by the raft multiplier.
+		s, _ := raw.(string)
+		timeout, err := time.ParseDuration(s)
+		if err != nil || timeout < time.Second || timeout%time.Second != 0 {
+			return nil, false
+		}
+		return json.Number(strconv.Itoa(int(timeout / time.Second))), true
+	case kindPrefixFilter:
+		var filter []string
+		for _, prefix := range configStrings(raw) {
+			filter = append(filter, "+"+prefix)
+		}
+		blocked, _ := lookupSetting(tree, "Telemetry.BlockedPrefixes")
+		for _, prefix := range configStrings(blocked) {
+			filter = append(filter, "-"+prefix)
+		}
+		if len(filter) == 0 {
+			return nil, false
+		}
+		return stringsToValues(filter), true
+	case kindRequestLimitsMode:
+		if n, ok := raw.(json.Number); ok {
+			modes := []string{"disabled", "permissive", "enforcing"}
+			i, err := n.Int64()
+			if err != nil || i < 0 || int(i) >= len(modes) {
+				return nil, false
+			}
+			return modes[i], true
+		}
+		return raw, true
+	case kindCipherSuites:
+		suites := configStrings(raw)
+		if len(suites) == 0 {
+			return nil, false
+		}
+		return strings.Join(suites, ","), true
+	case kindMegabytes:
+		n, ok := raw.(json.Number)
+		if !ok {
+			return nil, false
+		}
+		bytes, err := n.Int64()
+		if err != nil || bytes <= 0 {
+			return nil, false
+		}
+		return json.Number(strconv.FormatInt(bytes/(1024*1024), 10)), true
+	}
+	return raw, true
+}
+
+// dropRedacted leaves redacted secrets out of the value of key, listing them in Redacted. It
+// reports false when the whole value was redacted.
+func (f *AgentConfigFile) dropRedacted(key string, value interface{}) (interface{}, bool) {
+	switch v := value.(type) {
+	case string:
+		if redact.IsRedacted(v) {
+			f.Redacted = append(f.Redacted, key)
+			return nil, false
+		}
+	case map[string]interface{}:
+		kept := make(map[string]interface{}, len(v))
+		for name, entry := range v {
+			if s, ok := entry.(string); ok && redact.IsRedacted(s) {
+				f.Redacted = append(f.Redacted, key+"."+name)
+				continue
+			}
+			kept[name] = entry
+		}
+		return kept, true
+	}
+	return value, true
+}
+
+// listenerAddr returns the address of a DebugConfig listener such as tcp://0.0.0.0:8500, keeping
+// unix sockets as their unix:// URL.
+func listenerAddr(listener string) string {
+	u, err := url.Parse(listener)
+	if err != nil || u.Scheme == "" {
+		return listener
+	}
+	if u.Scheme == "unix" {
+		return listener
+	}
+	return u.Hostname()
+}
+
+func configStrings(value interface{}) []string {
+	list, _ := value.([]interface{})
+	var out []string
+	for _, v := range list {
+		if s, ok := v.(string); ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+func stringsToValues(list []string) []interface{} {
+	values := make([]interface{}, len(list))
+	for i, s := range list {
+		values[i] = s
+	}
+	return values
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func sameStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, s := range a {
+		if !containsString(b, s) {
+			return false
+		}
+	}
+	return true
+}
+
+// isConfigDefault reports whether a setting's DebugConfig or converted value is its default.
+func isConfigDefault(value interface{}, def json.RawMessage) bool {
+	encoded, err := json.Marshal(value)
+	return err == nil && equalConfigValues(encoded, def)
+}
+
+func zeroConfigValue(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case bool:
+		return !v
+	case json.Number:
+		f, err := v.Float64()
+		return err == nil && f == 0
+	case []interface{}:
+		return len(v) == 0
+	case map[string]interface{}:
+		return len(v) == 0
+	}
+	return false
+}
+
+// setConfigKey sets the value of a dotted key, creating its blocks.
+func setConfigKey(config map[string]interface{}, key string, value interface{}) {
+	names := strings.Split(key, ".")
+	for _, name := range names[:len(names)-1] {
+		block, ok := config[name].(map[string]interface{})
+		if !ok {
+			block = make(map[string]interface{})
+			config[name] = block
+		}
+		config = block
+	}
+	config[names[len(names)-1]] = value
+}
+
+// JSON renders the configuration as an agent configuration JSON file.
+func (f *AgentConfigFile) JSON() (string, error) {
+	out, err := json.MarshalIndent(f.Config, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// HCL renders the configuration as an agent configuration HCL file, noting the redacted secrets.
+func (f *AgentConfigFile) HCL() string {
+	var b strings.Builder
+	if len(f.Redacted) > 0 {
+		b.WriteString("# Secrets redacted, set these before use:\n")
+		for _, key := range f.Redacted {
+			fmt.Fprintf(&b, "#   %s\n", key)
+		}
+		b.WriteString("\n")
+	}
+	writeHCLBody(&b, f.Config, "")
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// writeHCLBody writes the attributes of block, then its nested blocks, each sorted by name.
+func writeHCLBody(b *strings.Builder, block map[string]interface{}, indent string) {
+	var attrs, blocks []string
+	for name, value := range block {
+		if _, ok := value.(map[string]interface{}); ok {
+			blocks = append(blocks, name)
+		} else {
+			attrs = append(attrs, name)
+		}
+	}
+	sort.Strings(attrs)
+	sort.Strings(blocks)
+	for _, name := range attrs {
+		fmt.Fprintf(b, "%s%s = %s\n", indent, hclKey(name), hclValue(block[name], indent))
+	}
+	for _, name := range blocks {
+		if indent == "" && b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(b, "%s%s {\n", indent, hclKey(name))
+		writeHCLBody(b, block[name].(map[string]interface{}), indent+"  ")
+		fmt.Fprintf(b, "%s}\n", indent)
+	}
+}
+
+var hclIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+func hclKey(name string) string {
+	if hclIdentifier.MatchString(name) {
+		return name
+	}
+	return hclString(name)
+}
+
+func hclString(s string) string {
+	// Consul parses HCL 1, which has no interpolation, so ${ is left unescaped.
+	return strconv.Quote(s)
+}
+
+func hclValue(value interface{}, indent string) string {
+	switch v := value.(type) {
+	case nil:
+		return `""`
+	case string:
+		return hclString(v)
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	case []interface{}:
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = hclValue(item, indent)
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case map[string]interface{}:
+		var b strings.Builder
+		b.WriteString("{\n")
+		writeHCLBody(&b, v, indent+"  ")
+		b.WriteString(indent + "}")
+		return b.String()
+	}
+	return hclString(fmt.Sprint(value))
+}
+
+// AgentConfigFull returns the agent configuration reconstructed from the DebugConfig as JSON.
+func (a *Agent) AgentConfigFull() (string, error) {
+	file, err := a.ReconstructConfig()
+	if err != nil {
+		return "", err
+	}
+	return file.JSON()
+}
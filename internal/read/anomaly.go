@@ -0,0 +1,266 @@
+package read
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// AnomalyKind classifies the change detected in a metric series.
+type AnomalyKind string
+
+const (
+	AnomalySpike      AnomalyKind = "spike"
+	AnomalyLevelShift AnomalyKind = "level-shift"
+	AnomalyGrowth     AnomalyKind = "growth"
+	AnomalyDrop       AnomalyKind = "drop"
+)
+
+const (
+	// minAnomalySamples is the fewest captures a series needs before it is analyzed.
+	minAnomalySamples = 6
+	// spikeThreshold is the robust z-score a single capture must reach to count as a spike.
+	spikeThreshold = 6.0
+	// shiftThreshold is the t-statistic between the means before and after a level shift.
+	shiftThreshold = 6.0
+	// trendConsistency is the share of changing captures that must move in the trend direction.
+	trendConsistency = 0.9
+	// minRelativeChange ignores changes smaller than this fraction of the baseline.
+	minRelativeChange = 0.1
+)
+
+// Anomaly is the most significant change detected in one metric series during the capture window.
+type Anomaly struct {
+	Name   string            `json:"name"`
+	Labels map[string]string `json:"labels"`
+	Kind   AnomalyKind       `json:"kind"`
+	// Severity is the size of the change relative to the series baseline (1 = 100%).
+	Severity float64 `json:"severity"`
+	// Start and End bound the change: the spike capture, the first capture after a level shift,
+	// or the first and last captures of a trend.
+	Start  time.Time `json:"start"`
+	End    time.Time `json:"end"`
+	Before float64   `json:"before"`
+	After  float64   `json:"after"`
+}
+
+// DetectAnomalies scans every series of the metrics index for spikes, level shifts and monotonic
+// growth or drops, returning at most top anomalies (all when top <= 0) ordered by severity.
+// Only series matching the selector are scanned; a nil selector scans everything.
+func (m Metrics) DetectAnomalies(selector *MetricSelector, top int) ([]Anomaly, error) {
+	return m.DetectAnomaliesBetween(selector, time.Time{}, time.Time{}, top)
+}
+
+// DetectAnomaliesBetween is DetectAnomalies over the captures from since to until, either of which
+// may be zero to leave that side open. Baselines are computed from the window alone, so a change
+// inside it is not hidden by captures outside it, and top ranks only the window's anomalies.
+func (m Metrics) DetectAnomaliesBetween(selector *MetricSelector, since, until time.Time, top int) ([]Anomaly, error) {
+	if selector == nil {
+		selector = &MetricSelector{}
+	}
+	series, err := m.Select(selector)
+	if err != nil {
+		return nil, err
+	}
+	var anomalies []Anomaly
+	for _, s := range series {
+		if !since.IsZero() || !until.IsZero() {
+			s.Samples = samplesBetween(s.Samples, since, until)
+		}
+		if a, ok := detectSeriesAnomaly(s); ok {
+			anomalies = append(anomalies, a)
+		}
+	}
+	sort.SliceStable(anomalies, func(i, j int) bool { return anomalies[i].Severity > anomalies[j].Severity })
+	if top > 0 && len(anomalies) > top {
+		anomalies = anomalies[:top]
+	}
+	return anomalies, nil
+}
+
+// samplesBetween returns the samples captured from since to until, either of which may be zero.
+func samplesBetween(samples []SeriesSample, since, until time.Time) []SeriesSample {
+	var windowed []SeriesSample
+	for _, sample := range samples {
+		if (!since.IsZero() && sample.Timestamp.Before(since)) || (!until.IsZero() && sample.Timestamp.After(until)) {
+			continue
+		}
+		windowed = append(windowed, sample)
+	}
+	return windowed
+}
+
+// detectSeriesAnomaly returns the most severe change of a single series. Trends are checked first,
+// since a steadily growing series would otherwise also register as a level shift.
+func detectSeriesAnomaly(s Series) (Anomaly, bool) {
+	samples := append([]SeriesSample(nil), s.Samples...)
+	sort.SliceStable(samples, func(i, j int) bool { return samples[i].Timestamp.Before(samples[j].Timestamp) })
+	if len(samples) < minAnomalySamples {
+		return Anomaly{}, false
+	}
+	values := make([]float64, len(samples))
+	for i, sample := range samples {
+		values[i] = sample.Value
+	}
+
+	base := Anomaly{Name: s.Name, Labels: s.Labels}
+	if a, ok := detectTrend(samples, values); ok {
+		a.Name, a.Labels = base.Name, base.Labels
+		return a, true
+	}
+
+	var best Anomaly
+	found := false
+	for _, detect := range []func([]SeriesSample, []float64) (Anomaly, bool){detectSpike, detectLevelShift} {
+		if a, ok := detect(samples, values); ok && (!found || a.Severity > best.Severity) {
+			best, found = a, true
+		}
+	}
+	best.Name, best.Labels = base.Name, base.Labels
+	return best, found
+}
+
+// detectSpike finds the capture deviating furthest from the series median, measured in median
+// absolute deviations.
+func detectSpike(samples []SeriesSample, values []float64) (Anomaly, bool) {
+	median := medianOf(values)
+	deviations := make([]float64, len(values))
+	for i, v := range values {
+		deviations[i] = math.Abs(v - median)
+	}
+	scale := 1.4826 * medianOf(deviations)
+	if floor := 0.01 * math.Abs(median); scale < floor {
+		scale = floor
+	}
+	if scale == 0 {
+		scale = 1e-9
+	}
+
+	peak := 0
+	for i := range values {
+		if deviations[i] > deviations[peak] {
+			peak = i
+		}
+	}
+	if deviations[peak]/scale < spikeThreshold {
+		return Anomaly{}, false
+	}
+	severity := relativeChange(median, values[peak])
+	if severity < minRelativeChange {
+		return Anomaly{}, false
+	}
+	return Anomaly{
+		Kind:     AnomalySpike,
+		Severity: severity,
+		Start:    samples[peak].Timestamp,
+		End:      samples[peak].Timestamp,
+		Before:   median,
+		After:    values[peak],
+	}, true
+}
+
+// detectLevelShift finds the split point maximizing the difference between the mean before and
+// after it, relative to the variance on either side.
+func detectLevelShift(samples []SeriesSample, values []float64) (Anomaly, bool) {
+	const minSegment = 3
+	bestT, bestSplit := 0.0, -1
+	var bestBefore, bestAfter float64
+	for split := minSegment; split <= len(values)-minSegment; split++ {
+		m1, v1 := meanVariance(values[:split])
+		m2, v2 := meanVariance(values[split:])
+		floor := 0.01 * math.Max(math.Abs(m1), math.Abs(m2))
+		stderr := math.Sqrt(v1/float64(split) + v2/float64(len(values)-split) + floor*floor)
+		if stderr == 0 {
+			stderr = 1e-9
+		}
+		if t := math.Abs(m2-m1) / stderr; t > bestT {
+			bestT, bestSplit, bestBefore, bestAfter = t, split, m1, m2
+		}
+	}
+	if bestSplit < 0 || bestT < shiftThreshold {
+		return Anomaly{}, false
+	}
+	severity := relativeChange(bestBefore, bestAfter)
+	if severity < minRelativeChange {
+		return Anomaly{}, false
+	}
+	return Anomaly{
+		Kind:     AnomalyLevelShift,
+		Severity: severity,
+		Start:    samples[bestSplit].Timestamp,
+		End:      samples[bestSplit].Timestamp,
+		Before:   bestBefore,
+		After:    bestAfter,
+	}, true
+}
+
+// detectTrend reports series that move consistently in one direction across the capture window,
+// such as a leaking heap or a draining log store.
+func detectTrend(samples []SeriesSample, values []float64) (Anomaly, bool) {
+	var up, down int
+	for i := 1; i < len(values); i++ {
+		switch {
+		case values[i] > values[i-1]:
+			up++
+		case values[i] < values[i-1]:
+			down++
+		}
+	}
+	changes := up + down
+	// Most captures must change, otherwise a single step would look like a trend.
+	if changes < (len(values)-1)/2 {
+		return Anomaly{}, false
+	}
+	first, last := values[0], values[len(values)-1]
+	kind := AnomalyGrowth
+	if float64(up) < trendConsistency*float64(changes) {
+		if float64(down) < trendConsistency*float64(changes) {
+			return Anomaly{}, false
+		}
+		kind = AnomalyDrop
+	}
+	if (kind == AnomalyGrowth && last <= first) || (kind == AnomalyDrop && last >= first) {
+		return Anomaly{}, false
+	}
+	severity := relativeChange(first, last)
+	if severity < minRelativeChange {
+		return Anomaly{}, false
+	}
+	return Anomaly{
+		Kind:     kind,
+		Severity: severity,
+		Start:    samples[0].Timestamp,
+		End:      samples[len(samples)-1].Timestamp,
+		Before:   first,
+		After:    last,
+	}, true
+}
+
+// relativeChange returns |after-before| as a fraction of the baseline, treating baselines below
+// one as one so changes from zero remain finite.
+func relativeChange(before, after float64) float64 {
+	return math.Abs(after-before) / math.Max(math.Abs(before), 1)
+}
+
+func medianOf(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+func meanVariance(values []float64) (float64, float64) {
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+	var sq float64
+	for _, v := range values {
+		sq += (v - mean) * (v - mean)
+	}
+	return mean, sq / float64(len(values))
+}
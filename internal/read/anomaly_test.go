@@ -0,0 +1,68 @@
+package read
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestDetectAnomalies(t *testing.T) {
+	start := time.Date(2024, 2, 7, 12, 40, 0, 0, time.UTC)
+	series := map[string][]float64{
+		"consul.flat":       {5, 5, 5, 5, 5, 5, 5, 5, 5, 5},
+		"consul.spike":      {3, 3, 4, 3, 3, 30, 3, 4, 3, 3},
+		"consul.shift":      {10, 11, 10, 10, 11, 40, 41, 40, 40, 41},
+		"consul.growth":     {100, 110, 120, 130, 140, 150, 160, 170, 180, 190},
+		"consul.oldest_log": {900, 800, 700, 600, 500, 400, 300, 300, 200, 100},
+	}
+	metrics := Metrics{MetricsMap: map[string][]map[string]interface{}{}}
+	for name, values := range series {
+		for i, v := range values {
+			metrics.MetricsMap[name] = append(metrics.MetricsMap[name], map[string]interface{}{
+				"timestamp": start.Add(time.Duration(i) * 10 * time.Second).Format(MetricsTimestampLayout),
+				"value":     v,
+				"labels":    map[string]string{},
+				"type":      "gauge",
+			})
+		}
+	}
+
+	anomalies, err := metrics.DetectAnomalies(nil, 0)
+	if err != nil {
+		t.Fatalf("DetectAnomalies: %v", err)
+	}
+	got := make(map[string]Anomaly)
+	var order []string
+	for _, a := range anomalies {
+		got[a.Name] = a
+		order = append(order, a.Name)
+	}
+	want := map[string]AnomalyKind{
+		"consul.spike":      AnomalySpike,
+		"consul.shift":      AnomalyLevelShift,
+		"consul.growth":     AnomalyGrowth,
+		"consul.oldest_log": AnomalyDrop,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("detected %v, want %d anomalies", order, len(want))
+	}
+	for name, kind := range want {
+		if got[name].Kind != kind {
+			t.Errorf("%s: kind = %q, want %q", name, got[name].Kind, kind)
+		}
+	}
+	if when := got["consul.spike"].Start; !when.Equal(start.Add(50 * time.Second)) {
+		t.Errorf("spike at %s, want %s", when, start.Add(50*time.Second))
+	}
+	if when := got["consul.shift"].Start; !when.Equal(start.Add(50 * time.Second)) {
+		t.Errorf("shift at %s, want %s", when, start.Add(50*time.Second))
+	}
+	if fmt.Sprint(order[:2]) != "[consul.spike consul.shift]" {
+		t.Errorf("ranking = %v, want spike then shift first", order)
+	}
+
+	top, _ := metrics.DetectAnomalies(nil, 1)
+	if len(top) != 1 || top[0].Name != "consul.spike" {
+		t.Errorf("top 1 = %+v, want consul.spike", top)
+	}
+}
@@ -0,0 +1,191 @@
+package read
+
+import (
+	"errors"
+	"fmt"
+	"github.com/mattn/go-isatty"
+	"github.com/ryanuber/columnize"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ExtractBundle extracts a bundle .tar.gz archive into its directory, returning the extracted bundle root.
+func ExtractBundle(archive string) (string, error) {
+	root, err := extractTarGz(archive, filepath.Dir(archive))
+	if err != nil {
+		return "", fmt.Errorf("error extracting %s: %v", archive, err)
+	}
+	if root == "" {
+		return "", fmt.Errorf("%s does not contain a consul debug bundle (no index.json)", archive)
+	}
+	return root, nil
+}
+
+// ListBundleArchives returns the consul debug bundle .tar.gz archives in dir.
+func ListBundleArchives(dir string) ([]os.DirEntry, error) {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read debug-path directory %s\n%v\n", dir, err)
+	}
+	var bundles []os.DirEntry
+	for _, file := range files {
+		name := file.Name()
+		if !file.IsDir() && strings.HasSuffix(name, ".tar.gz") && bundleRegex.FindStringSubmatch(name) != nil {
+			bundles = append(bundles, file)
+		}
+	}
+	return bundles, nil
+}
+
+// Strategies of a BundleSelection.
+const (
+	SelectPrompt = ""
+	SelectLatest = "latest"
+	SelectOldest = "oldest"
+	SelectName   = "name"
+	SelectIndex  = "index"
+)
+
+// SelectUsage describes the -select flag accepted wherever a directory of bundle archives is read.
+const SelectUsage = "How to pick one archive of a directory holding several bundle archives: latest, oldest, name=<glob> or index=<N> (prompts when unset)"
+
+// archiveTimeReg matches the capture time consul debug names its archives with, e.g. consul-debug-2023-10-04T18-29-47Z.
+var archiveTimeReg = regexp.MustCompile(`\d{4}-\d{2}-\d{2}T\d{2}-\d{2}-\d{2}(?:Z|[-+]\d{4})`)
+
+// stdinIsTerminal reports whether the bundle menu can be prompted for.
+var stdinIsTerminal = func() bool {
+	return isatty.IsTerminal(os.Stdin.Fd()) || isatty.IsCygwinTerminal(os.Stdin.Fd())
+}
+
+// BundleSelection picks one archive of a directory holding several bundle archives.
+type BundleSelection struct {
+	Strategy string
+	// Pattern is the glob archive names are matched against with SelectName.
+	Pattern string
+	// Index is the 1-based position of the archive, as numbered in the selection menu, with SelectIndex.
+	Index int
+}
+
+// ParseBundleSelection parses a -select value: latest, oldest, name=<glob> or index=<N>. An empty
+// value prompts for the archive.
+func ParseBundleSelection(value string) (BundleSelection, error) {
+	key, arg, hasArg := strings.Cut(strings.TrimSpace(value), "=")
+	switch {
+	case key == "" && !hasArg:
+		return BundleSelection{Strategy: SelectPrompt}, nil
+	case (key == SelectLatest || key == SelectOldest) && !hasArg:
+		return BundleSelection{Strategy: key}, nil
+	case key == SelectName && arg != "":
+		if _, err := filepath.Match(arg, ""); err != nil {
+			return BundleSelection{}, fmt.Errorf("invalid -select name pattern %q: %v", arg, err)
+		}
+		return BundleSelection{Strategy: SelectName, Pattern: arg}, nil
+	case key == SelectIndex && arg != "":
+		index, err := strconv.Atoi(arg)
+		if err != nil || index < 1 {
+			return BundleSelection{}, fmt.Errorf("invalid -select index %q: must be a number from 1", arg)
+		}
+		return BundleSelection{Strategy: SelectIndex, Index: index}, nil
+	}
+	return BundleSelection{}, fmt.Errorf("invalid -select %q: must be one of latest, oldest, name=<glob> or index=<N>", value)
+}
+
+// Select picks one of the bundle archives found in dir. A lone archive is picked whatever the
+// strategy. Without a strategy the archive is prompted for, which fails when stdin is not a terminal.
+func (s BundleSelection) Select(dir string, archives []os.DirEntry) (os.DirEntry, error) {
+	if len(archives) == 0 {
+		return nil, fmt.Errorf("no bundle archives found in %s", dir)
+	}
+	switch s.Strategy {
+	case SelectLatest, SelectOldest:
+		sorted := append([]os.DirEntry(nil), archives...)
+		sort.SliceStable(sorted, func(i, j int) bool { return archiveTime(sorted[i]).Before(archiveTime(sorted[j])) })
+		if s.Strategy == SelectLatest {
+			return sorted[len(sorted)-1], nil
+		}
+		return sorted[0], nil
+	case SelectName:
+		var matches []os.DirEntry
+		var names []string
+		for _, a := range archives {
+			if ok, _ := filepath.Match(s.Pattern, a.Name()); ok {
+				matches = append(matches, a)
+				names = append(names, a.Name())
+			}
+		}
+		switch len(matches) {
+		case 0:
+			return nil, fmt.Errorf("no bundle archive in %s matches %q", dir, s.Pattern)
+		case 1:
+			return matches[0], nil
+		}
+		return nil, fmt.Errorf("%d bundle archives in %s match %q: %s", len(matches), dir, s.Pattern, strings.Join(names, ", "))
+	case SelectIndex:
+		if s.Index > len(archives) {
+			return nil, fmt.Errorf("-select index=%d is out of range: %s holds %d bundle archives", s.Index, dir, len(archives))
+		}
+		return archives[s.Index-1], nil
+	}
+	if len(archives) == 1 {
+		return archives[0], nil
+	}
+	if !stdinIsTerminal() {
+		return nil, fmt.Errorf("%d bundle archives found in %s and stdin is not a terminal to prompt for one: pass -select latest, oldest, name=<glob> or index=<N>", len(archives), dir)
+	}
+	return promptBundleArchive(archives)
+}
+
+// archiveTime returns the capture time in the archive name, falling back to its modification time.
+func archiveTime(archive os.DirEntry) time.Time {
+	if t, ok := nameCaptureTime(archive.Name()); ok {
+		return t
+	}
+	if info, err := archive.Info(); err == nil {
+		return info.ModTime()
+	}
+	return time.Time{}
+}
+
+// nameCaptureTime returns the capture time consul debug names bundle archives and directories with.
+func nameCaptureTime(name string) (time.Time, bool) {
+	if m := archiveTimeReg.FindString(name); m != "" {
+		if t, err := time.Parse("2006-01-02T15-04-05Z0700", m); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func promptBundleArchive(bundles []os.DirEntry) (os.DirEntry, error) {
+	// Build extraction tool title
+	title := "Consul Debug Bundle Extraction Tool"
+	ul := fmt.Sprintf(strings.Repeat("-", len(title)))
+	menu := []string{fmt.Sprintf("\x1f%s\x1f", title)}
+	menu = append(menu, fmt.Sprintf("\x1f%s\x1f", ul))
+
+	// Print columnized output for user to select
+	menu = append(menu, fmt.Sprintf("Option\x1fBundle Name\x1fSize\x1f"))
+	conv := ByteConverter{}
+	for i, bundle := range bundles {
+		info, _ := bundle.Info()
+		bundleSize := conv.ConvertToReadableBytes(info.Size())
+		menu = append(menu, fmt.Sprintf("%d\x1f%s\x1f%s\x1f", i+1, bundle.Name(), bundleSize))
+	}
+	output := columnize.Format(menu, &columnize.Config{Delim: string([]byte{0x1f}), Glue: " "})
+	fmt.Printf("\n%s\n\n", output)
+	fmt.Print("Enter the file option number to extract: ")
+	var selected int
+	if _, err := fmt.Scanf("%d", &selected); err != nil {
+		return nil, err
+	}
+
+	if selected < 1 || selected > len(bundles) {
+		return nil, errors.New("invalid selection: option out of range")
+	}
+	return bundles[selected-1], nil
+}
@@ -0,0 +1,62 @@
+package read
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBundleSelection(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{
+		"124722consul-debug-2023-10-04T18-29-47Z.tar.gz",
+		"consul-debug-2023-10-05T09-00-00Z.tar.gz",
+		"consul-debug-2023-10-03T23-59-59Z.tar.gz",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	archives, err := ListBundleArchives(dir)
+	if err != nil || len(archives) != 3 {
+		t.Fatalf("expected 3 archives, got %v, %v", archives, err)
+	}
+	defer func(f func() bool) { stdinIsTerminal = f }(stdinIsTerminal)
+	stdinIsTerminal = func() bool { return false }
+
+	cases := []struct {
+		value, want, err string
+	}{
+		{value: "latest", want: "consul-debug-2023-10-05T09-00-00Z.tar.gz"},
+		{value: "oldest", want: "consul-debug-2023-10-03T23-59-59Z.tar.gz"},
+		{value: "name=124722*", want: "124722consul-debug-2023-10-04T18-29-47Z.tar.gz"},
+		{value: "name=*2023-10-0[45]*", err: "2 bundle archives"},
+		{value: "name=*2022*", err: "no bundle archive"},
+		{value: "index=2", want: archives[1].Name()},
+		{value: "index=4", err: "out of range"},
+		{value: "", err: "stdin is not a terminal"},
+	}
+	for _, tc := range cases {
+		s, err := ParseBundleSelection(tc.value)
+		if err != nil {
+			t.Fatalf("ParseBundleSelection(%q): %v", tc.value, err)
+		}
+		got, err := s.Select(dir, archives)
+		switch {
+		case tc.err != "" && (err == nil || !strings.Contains(err.Error(), tc.err)):
+			t.Errorf("-select %q: expected error containing %q, got %v", tc.value, tc.err, err)
+		case tc.err == "" && (err != nil || got.Name() != tc.want):
+			t.Errorf("-select %q: expected %s, got %v, %v", tc.value, tc.want, got, err)
+		}
+	}
+
+	if got, err := (BundleSelection{}).Select(dir, archives[:1]); err != nil || got != archives[0] {
+		t.Errorf("expected a lone archive to be picked without prompting, got %v, %v", got, err)
+	}
+	for _, value := range []string{"newest", "index=0", "index=x", "name=", "latest=1"} {
+		if _, err := ParseBundleSelection(value); err == nil {
+			t.Errorf("expected -select %q to be rejected", value)
+		}
+	}
+}
@@ -0,0 +1,401 @@
+package cluster
+
+import (
+	"consul-debug-read/internal/read"
+	"consul-debug-read/internal/read/compare"
+	"fmt"
+	"github.com/ryanuber/columnize"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// indexLagThreshold is the number of raft entries a server may trail the most advanced server by
+// before it is called out, matching autopilot's default MaxTrailingLogs.
+const indexLagThreshold = 250
+
+// nodeSpecificConfig are DebugConfig fields expected to differ between agents, left out of the
+// drift report. Versions are reported as skew instead.
+var nodeSpecificConfig = map[string]bool{
+	"AdvertiseAddrLAN":     true,
+	"AdvertiseAddrWAN":     true,
+	"BindAddr":             true,
+	"ClientAddrs":          true,
+	"DNSAddrs":             true,
+	"GRPCAddrs":            true,
+	"GRPCTLSAddrs":         true,
+	"HTTPAddrs":            true,
+	"HTTPSAddrs":           true,
+	"NodeID":               true,
+	"NodeName":             true,
+	"RPCAdvertiseAddr":     true,
+	"RPCBindAddr":          true,
+	"SerfAdvertiseAddrLAN": true,
+	"SerfAdvertiseAddrWAN": true,
+	"SerfBindAddrLAN":      true,
+	"SerfBindAddrWAN":      true,
+	"TaggedAddresses":      true,
+	"Revision":             true,
+	"Version":              true,
+	"VersionMetadata":      true,
+	"VersionPrerelease":    true,
+}
+
+// Bundle is one agent's decoded debug bundle.
+type Bundle struct {
+	// Node is the agent's node name, used to label the bundle throughout the report.
+	Node  string
+	Path  string
+	Debug *read.Debug
+}
+
+// Server is the raft state of one server agent as captured in its bundle.
+type Server struct {
+	Node         string `json:"node"`
+	Version      string `json:"version"`
+	State        string `json:"state"`
+	LeaderAddr   string `json:"leader_addr"`
+	AppliedIndex uint64 `json:"applied_index"`
+	CommitIndex  uint64 `json:"commit_index"`
+	// Lag is the number of entries the server's applied index trails the highest commit index
+	// captured across all servers.
+	Lag uint64 `json:"lag"`
+	// RaftConfiguration is the server's latest raft configuration as sorted "address (suffrage)" peers.
+	RaftConfiguration []string `json:"raft_configuration"`
+}
+
+// ConfigDrift is a DebugConfig field whose value differs between agents.
+type ConfigDrift struct {
+	Field string `json:"field"`
+	// Values maps each agent's node name to its value of the field.
+	Values map[string]string `json:"values"`
+}
+
+// MemberView is one serf member as seen by every agent of the cluster.
+type MemberView struct {
+	Node    string `json:"node"`
+	Address string `json:"address"`
+	Type    string `json:"type"`
+	// Status maps each agent's node name to the member status it reported, "<missing>" when the
+	// agent did not know the member.
+	Status     map[string]string `json:"status"`
+	Consistent bool              `json:"consistent"`
+}
+
+// Report is the cross-check of a set of agent bundles.
+type Report struct {
+	// Nodes are the node names of the analyzed bundles, in report order.
+	Nodes   []string      `json:"nodes"`
+	Servers []Server      `json:"servers"`
+	Drift   []ConfigDrift `json:"config_drift"`
+	Members []MemberView  `json:"members"`
+	// Findings are the inconsistencies found, most significant first.
+	Findings []string `json:"findings"`
+}
+
+// Analyze cross-checks the bundles of several agents of one cluster: the raft configuration and
+// leader each server reports, raft index lag between servers, version skew, DebugConfig drift and
+// the serf member status each agent reports.
+func Analyze(bundles []Bundle) (*Report, error) {
+	if len(bundles) < 2 {
+		return nil, fmt.Errorf("at least two bundles are required, got %d", len(bundles))
+	}
+	bundles = append([]Bundle(nil), bundles...)
+	sort.SliceStable(bundles, func(i, j int) bool { return bundles[i].Node < bundles[j].Node })
+	seen := make(map[string]string)
+	r := &Report{}
+	for _, b := range bundles {
+		if previous, ok := seen[b.Node]; ok {
+			return nil, fmt.Errorf("bundles %s and %s were both captured from node %s", previous, b.Path, b.Node)
+		}
+		seen[b.Node] = b.Path
+		r.Nodes = append(r.Nodes, b.Node)
+	}
+
+	servers, err := serverStates(bundles)
+	if err != nil {
+		return nil, err
+	}
+	r.Servers = servers
+	r.Findings = append(r.Findings, leaderFindings(servers)...)
+	r.Findings = append(r.Findings, raftConfigFindings(servers)...)
+	r.Findings = append(r.Findings, lagFindings(servers)...)
+	r.Findings = append(r.Findings, versionFindings(bundles)...)
+
+	if r.Drift, err = configDrift(bundles); err != nil {
+		return nil, err
+	}
+	if len(r.Drift) > 0 {
+		r.Findings = append(r.Findings, fmt.Sprintf("%d DebugConfig field(s) differ between agents", len(r.Drift)))
+	}
+
+	r.Members = memberViews(bundles)
+	for _, m := range r.Members {
+		if !m.Consistent {
+			r.Findings = append(r.Findings, fmt.Sprintf("agents disagree on the status of member %s: %s", m.Node, formatValues(r.Nodes, m.Status)))
+		}
+	}
+	return r, nil
+}
+
+func serverStates(bundles []Bundle) ([]Server, error) {
+	var servers []Server
+	var highest uint64
+	for _, b := range bundles {
+		if !b.Debug.Agent.Config.Server {
+			continue
+		}
+		peers, err := b.Debug.RaftPeers()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse raft configuration of %s: %v", b.Node, err)
+		}
+		s := Server{
+			Node:       b.Node,
+			Version:    b.Debug.Agent.Config.Version,
+			State:      b.Debug.Agent.Stats.Raft.State,
+			LeaderAddr: b.Debug.Agent.Stats.Consul.LeaderAddr,
+		}
+		s.AppliedIndex, _ = strconv.ParseUint(b.Debug.Agent.Stats.Raft.AppliedIndex, 10, 64)
+		s.CommitIndex, _ = strconv.ParseUint(b.Debug.Agent.Stats.Raft.CommitIndex, 10, 64)
+		if s.CommitIndex > highest {
+			highest = s.CommitIndex
+		}
+		for _, p := range peers {
+			suffrage := "voter"
+			if !p.Voter {
+				suffrage = "nonvoter"
+			}
+			s.RaftConfiguration = append(s.RaftConfiguration, fmt.Sprintf("%s (%s)", p.Address, suffrage))
+		}
+		sort.Strings(s.RaftConfiguration)
+		servers = append(servers, s)
+	}
+	for i := range servers {
+		if servers[i].AppliedIndex < highest {
+			servers[i].Lag = highest - servers[i].AppliedIndex
+		}
+	}
+	return servers, nil
+}
+
+func leaderFindings(servers []Server) []string {
+	leaders := make(map[string][]string)
+	var unknown []string
+	for _, s := range servers {
+		if s.LeaderAddr == "" {
+			unknown = append(unknown, s.Node)
+			continue
+		}
+		leaders[s.LeaderAddr] = append(leaders[s.LeaderAddr], s.Node)
+	}
+	var findings []string
+	if len(leaders) > 1 {
+		findings = append(findings, "servers disagree on the leader: "+formatGroups(leaders))
+	}
+	if len(unknown) > 0 {
+		findings = append(findings, "no known leader on: "+strings.Join(unknown, ", "))
+	}
+	return findings
+}
+
+func raftConfigFindings(servers []Server) []string {
+	configs := make(map[string][]string)
+	for _, s := range servers {
+		key := "[" + strings.Join(s.RaftConfiguration, ", ") + "]"
+		configs[key] = append(configs[key], s.Node)
+	}
+	if len(configs) <= 1 {
+		return nil
+	}
+	return []string{"servers report different raft configurations: " + formatGroups(configs)}
+}
+
+func lagFindings(servers []Server) []string {
+	var findings []string
+	for _, s := range servers {
+		if s.Lag >= indexLagThreshold {
+			findings = append(findings, fmt.Sprintf("%s applied index %d trails the highest commit index by %d entries", s.Node, s.AppliedIndex, s.Lag))
+		}
+	}
+	return findings
+}
+
+func versionFindings(bundles []Bundle) []string {
+	versions := make(map[string][]string)
+	for _, b := range bundles {
+		versions[b.Debug.Agent.Config.Version] = append(versions[b.Debug.Agent.Config.Version], b.Node)
+	}
+	if len(versions) <= 1 {
+		return nil
+	}
+	return []string{"version skew between agents: " + formatGroups(versions)}
+}
+
+func configDrift(bundles []Bundle) ([]ConfigDrift, error) {
+	configs := make([]map[string]string, len(bundles))
+	fields := make(map[string]struct{})
+	for i, b := range bundles {
+		flat, err := compare.FlattenConfig(b.Debug.Agent.DebugConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to flatten DebugConfig of %s: %v", b.Node, err)
+		}
+		configs[i] = flat
+		for field := range flat {
+			if !nodeSpecificConfig[topLevelField(field)] {
+				fields[field] = struct{}{}
+			}
+		}
+	}
+	var drift []ConfigDrift
+	for _, field := range sortedKeys(fields) {
+		values := make(map[string]string, len(bundles))
+		distinct := make(map[string]struct{})
+		for i, b := range bundles {
+			v, ok := configs[i][field]
+			if !ok {
+				v = "<none>"
+			}
+			values[b.Node] = v
+			distinct[v] = struct{}{}
+		}
+		if len(distinct) > 1 {
+			drift = append(drift, ConfigDrift{Field: field, Values: values})
+		}
+	}
+	return drift, nil
+}
+
+// topLevelField returns the DebugConfig field of a flattened path, e.g. TLS.InternalRPC.CAFile => TLS.
+func topLevelField(path string) string {
+	if i := strings.IndexAny(path, ".["); i >= 0 {
+		return path[:i]
+	}
+	return path
+}
+
+func memberViews(bundles []Bundle) []MemberView {
+	views := make(map[string]*MemberView)
+	for _, b := range bundles {
+		for _, m := range b.Debug.Agent.MemberSummaries() {
+			view, ok := views[m.Node]
+			if !ok {
+				view = &MemberView{Node: m.Node, Address: m.Address, Type: m.Type, Status: make(map[string]string)}
+				views[m.Node] = view
+			}
+			view.Status[b.Node] = m.Status
+		}
+	}
+	names := make(map[string]struct{}, len(views))
+	for name := range views {
+		names[name] = struct{}{}
+	}
+	members := make([]MemberView, 0, len(views))
+	for _, name := range sortedKeys(names) {
+		view := views[name]
+		distinct := make(map[string]struct{})
+		for _, b := range bundles {
+			if _, ok := view.Status[b.Node]; !ok {
+				view.Status[b.Node] = "<missing>"
+			}
+			distinct[view.Status[b.Node]] = struct{}{}
+		}
+		view.Consistent = len(distinct) == 1
+		members = append(members, *view)
+	}
+	return members
+}
+
+// Summary renders the report as tables of servers, config drift and members followed by the findings.
+func Summary(r *Report) string {
+	var out []string
+	section := func(title string, rows []string) {
+		out = append(out, fmt.Sprintf("%s\n%s\n%s", title, strings.Repeat("-", len(title)), formatRows(rows)))
+	}
+
+	if len(r.Servers) > 0 {
+		rows := []string{"Node\x1fVersion\x1fState\x1fLeader\x1fAppliedIndex\x1fCommitIndex\x1fLag\x1fRaft Configuration"}
+		for _, s := range r.Servers {
+			leader := s.LeaderAddr
+			if leader == "" {
+				leader = "<none>"
+			}
+			rows = append(rows, fmt.Sprintf("%s\x1f%s\x1f%s\x1f%s\x1f%d\x1f%d\x1f%d\x1f%s",
+				s.Node, s.Version, s.State, leader, s.AppliedIndex, s.CommitIndex, s.Lag, strings.Join(s.RaftConfiguration, ", ")))
+		}
+		section("Servers:", rows)
+	}
+
+	if len(r.Drift) > 0 {
+		rows := []string{"Field\x1f" + strings.Join(r.Nodes, "\x1f")}
+		for _, d := range r.Drift {
+			row := []string{d.Field}
+			for _, node := range r.Nodes {
+				row = append(row, d.Values[node])
+			}
+			rows = append(rows, strings.Join(row, "\x1f"))
+		}
+		section("Config Drift:", rows)
+	}
+
+	if len(r.Members) > 0 {
+		rows := []string{" \x1fMember\x1fAddress\x1fType\x1f" + strings.Join(r.Nodes, "\x1f")}
+		for _, m := range r.Members {
+			marker := " "
+			if !m.Consistent {
+				marker = "!"
+			}
+			row := []string{marker, m.Node, m.Address, m.Type}
+			for _, node := range r.Nodes {
+				row = append(row, m.Status[node])
+			}
+			rows = append(rows, strings.Join(row, "\x1f"))
+		}
+		section("Members:", rows)
+	}
+
+	title := "Findings:"
+	findings := "no inconsistencies found between agents"
+	if len(r.Findings) > 0 {
+		findings = "- " + strings.Join(r.Findings, "\n- ")
+	}
+	out = append(out, fmt.Sprintf("%s\n%s\n%s", title, strings.Repeat("-", len(title)), findings))
+	return strings.Join(out, "\n\n")
+}
+
+// formatGroups renders values with the nodes reporting each, e.g. "10.0.0.1:8300 (server-1, server-2)".
+func formatGroups(groups map[string][]string) string {
+	keys := make(map[string]struct{}, len(groups))
+	for k := range groups {
+		keys[k] = struct{}{}
+	}
+	var parts []string
+	for _, k := range sortedKeys(keys) {
+		value := k
+		if value == "" {
+			value = "<none>"
+		}
+		parts = append(parts, fmt.Sprintf("%s (%s)", value, strings.Join(groups[k], ", ")))
+	}
+	return strings.Join(parts, "; ")
+}
+
+func formatValues(nodes []string, values map[string]string) string {
+	parts := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		parts = append(parts, fmt.Sprintf("%s=%s", node, values[node]))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func sortedKeys(m map[string]struct{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func formatRows(rows []string) string {
+	return columnize.Format(rows, &columnize.Config{Delim: string([]byte{0x1f}), Glue: " "})
+}
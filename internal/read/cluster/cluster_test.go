@@ -0,0 +1,55 @@
+package cluster
+
+import (
+	"consul-debug-read/internal/read"
+	"strings"
+	"testing"
+)
+
+func clientBundle(node, version string, allowStale bool, statuses map[string]int) Bundle {
+	b := &read.Debug{}
+	b.Agent.Config.NodeName = node
+	b.Agent.Config.Version = version
+	b.Agent.DebugConfig.NodeName = node
+	b.Agent.DebugConfig.DNSAllowStale = allowStale
+	for name, status := range statuses {
+		m := read.Member{Name: name, Addr: "10.0.0.1", Port: 8301, Status: status}
+		m.Tags.Role = "node"
+		b.Agent.Members = append(b.Agent.Members, m)
+	}
+	return Bundle{Node: node, Path: node + ".tar.gz", Debug: b}
+}
+
+func TestAnalyze(t *testing.T) {
+	bundles := []Bundle{
+		clientBundle("client-2", "1.17.3", false, map[string]int{"client-1": 4, "client-2": 1}),
+		clientBundle("client-1", "1.17.2", true, map[string]int{"client-1": 1, "client-2": 1}),
+	}
+	r, err := Analyze(bundles)
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+	if strings.Join(r.Nodes, ",") != "client-1,client-2" {
+		t.Errorf("expected nodes sorted by name, got %v", r.Nodes)
+	}
+	if len(r.Servers) != 0 {
+		t.Errorf("expected no servers, got %v", r.Servers)
+	}
+	// NodeName differs by design and must not be reported as drift
+	if len(r.Drift) != 1 || r.Drift[0].Field != "DNSAllowStale" || r.Drift[0].Values["client-2"] != "false" {
+		t.Errorf("expected DNSAllowStale drift only, got %+v", r.Drift)
+	}
+	if len(r.Members) != 2 || r.Members[0].Consistent || !r.Members[1].Consistent {
+		t.Errorf("expected client-1 inconsistent and client-2 consistent, got %+v", r.Members)
+	}
+	findings := strings.Join(r.Findings, "\n")
+	for _, want := range []string{"version skew", "1 DebugConfig field(s) differ", "status of member client-1: client-1=Alive, client-2=Failed"} {
+		if !strings.Contains(findings, want) {
+			t.Errorf("expected finding %q in:\n%s", want, findings)
+		}
+	}
+
+	if _, err := Analyze([]Bundle{bundles[0], bundles[0]}); err == nil {
+		t.Error("expected an error for two bundles of the same node")
+	}
+}
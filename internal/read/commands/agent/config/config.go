@@ -5,10 +5,14 @@ import (
 	"consul-debug-read/internal/read/commands"
 	"consul-debug-read/internal/read/commands/config/get"
 	"consul-debug-read/internal/read/commands/flags"
+	"consul-debug-read/internal/read/loader"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"github.com/hashicorp/go-hclog"
 	"github.com/mitchellh/cli"
+	"github.com/ryanuber/columnize"
+	"strings"
 )
 
 type cmd struct {
@@ -16,6 +20,11 @@ type cmd struct {
 	flags     *flag.FlagSet
 	pathFlags *flags.DebugReadFlags
 
+	query      string
+	full       bool
+	nonDefault bool
+	format     string
+
 	verbose bool
 	silent  bool
 }
@@ -26,6 +35,10 @@ func New(ui cli.Ui) (cli.Command, error) {
 		pathFlags: &flags.DebugReadFlags{},
 		flags:     flag.NewFlagSet("", flag.ContinueOnError),
 	}
+	c.flags.StringVar(&c.query, "query", "", "DebugConfig settings to show by dotted path, globs and [N] indexes allowed, e.g. DNS*, Limits.* or RetryJoinLAN[0]")
+	c.flags.BoolVar(&c.full, "full", false, "Print the complete DebugConfig JSON of agent.json as captured")
+	c.flags.BoolVar(&c.nonDefault, "non-default", false, "Only show DebugConfig settings that differ from Consul's documented defaults for the agent version")
+	c.flags.StringVar(&c.format, "format", "", "Output format: hcl or json for the reconstructed agent configuration (default json), table or json for -query and -non-default (default table)")
 	c.flags.BoolVar(&c.silent, "silent", false, "Disables all normal log output")
 	c.flags.BoolVar(&c.verbose, "verbose", false, "Enable verbose debugging output")
 
@@ -48,6 +61,30 @@ func (c *cmd) Run(args []string) int {
 		return 1
 	}
 
+	if c.full && (c.query != "" || c.nonDefault) {
+		c.ui.Error("Cannot specify -full with -query or -non-default")
+		return 1
+	}
+	switch {
+	case c.full:
+	case c.query != "" || c.nonDefault:
+		if c.format == "" {
+			c.format = "table"
+		}
+		if c.format != "table" && c.format != "json" {
+			c.ui.Error(fmt.Sprintf("Invalid -format %q: must be one of table or json", c.format))
+			return 1
+		}
+	default:
+		if c.format == "" {
+			c.format = "json"
+		}
+		if c.format != "hcl" && c.format != "json" {
+			c.ui.Error(fmt.Sprintf("Invalid -format %q: must be one of hcl or json", c.format))
+			return 1
+		}
+	}
+
 	level := hclog.Info
 	if c.verbose {
 		level = hclog.Debug
@@ -60,42 +97,117 @@ func (c *cmd) Run(args []string) int {
 	var ok bool
 	var err error
 	var path string
-	if path, ok = get.RenderPathFromConfig(); !ok {
+	if path, ok = get.RenderPath(c.pathFlags); !ok {
 		hclog.L().Error("error rendering debug filepath", "filepath", path, "error", err)
 		return 1
 	}
 
-	var data read.Debug
-	if err = data.DecodeJSON(path, "agent"); err != nil {
-		hclog.L().Error("failed to decode agent.json", "error", err)
+	b, ok := commands.OpenBundle(c.pathFlags, path, loader.PartAgent)
+	if !ok {
 		return 1
 	}
-	//if err = data.DecodeJSON(path, "members"); err != nil {
-	//	hclog.L().Error("failed to decode members.json", "error", err)
-	//	return 1
-	//}
+	data := b.Debug()
 	hclog.L().Debug("successfully read in agent information from bundle")
 
 	var result string
-	result, err = agentConfig(data)
-	if err != nil {
-		hclog.L().Error("failed to convert to user agent config", "error", err)
-		return 1
+	switch {
+	case c.full:
+		result, err = data.Agent.DebugConfigJSON()
+		if err != nil {
+			hclog.L().Error("failed to render DebugConfig", "error", err)
+			return 1
+		}
+	case c.query != "" || c.nonDefault:
+		result, err = c.settings(data)
+		if err != nil {
+			hclog.L().Error("failed to query DebugConfig", "query", c.query, "error", err)
+			return 1
+		}
+	default:
+		result, err = c.agentConfig(data)
+		if err != nil {
+			hclog.L().Error("failed to convert to user agent config", "error", err)
+			return 1
+		}
 	}
 	c.ui.Output(result)
 	return 0
 }
 
-func agentConfig(bundle read.Debug) (string, error) {
-	result, err := bundle.Agent.AgentConfigFull()
+// settings renders the DebugConfig settings matching -query, limited to those differing from
+// their default with -non-default.
+func (c *cmd) settings(data *read.Debug) (string, error) {
+	settings, err := data.Agent.QueryConfig(c.query)
+	if err != nil {
+		return "", err
+	}
+	if c.nonDefault {
+		if settings, err = read.NonDefaultSettings(settings, data.Agent.Config.Version); err != nil {
+			return "", err
+		}
+	}
+	if c.format == "json" {
+		if settings == nil {
+			settings = []read.ConfigSetting{}
+		}
+		out, err := json.MarshalIndent(settings, "", "  ")
+		return string(out), err
+	}
+	if len(settings) == 0 {
+		return fmt.Sprintf("no settings differ from the Consul %s defaults", data.Agent.Config.Version), nil
+	}
+	rows := []string{"Setting\x1fValue"}
+	if c.nonDefault {
+		rows[0] += "\x1fDefault"
+	}
+	for _, s := range settings {
+		row := fmt.Sprintf("%s\x1f%s", s.Path, s.Value)
+		if c.nonDefault {
+			row += fmt.Sprintf("\x1f%s", s.Default)
+		}
+		rows = append(rows, row)
+	}
+	return columnize.Format(rows, &columnize.Config{Delim: string([]byte{0x1f}), Glue: " "}), nil
+}
+
+// agentConfig renders the agent configuration reconstructed from the DebugConfig in -format.
+func (c *cmd) agentConfig(data *read.Debug) (string, error) {
+	file, err := data.Agent.ReconstructConfig()
 	if err != nil {
 		return "", err
 	}
-	return result, nil
+	if len(file.Redacted) > 0 {
+		hclog.L().Warn("secrets are redacted and left out of the agent configuration", "keys", strings.Join(file.Redacted, ","))
+	}
+	if c.format == "hcl" {
+		return file.HCL(), nil
+	}
+	return file.JSON()
 }
 
-const synopsis = `Returns HCL formatted agent configuration`
+const synopsis = `Returns the agent configuration reconstructed from agent.json`
 const help = `
 Usage: 
     consul-debug-read agent config [options]
+
+Without options, reconstructs the agent configuration file that produces the DebugConfig of
+agent.json, as JSON or with -format hcl as HCL. Settings holding Consul's default for the agent
+version are left out. Secrets, redacted by Consul or by consul-debug-read unless -no-redact is
+passed, are left out and listed instead. Service, check and config entry definitions are not
+reconstructed.
+
+-query explores the full DebugConfig of agent.json: a dotted path, optionally prefixed with $. as
+in JSONPath, whose segments may be globs (matched case-insensitively) and whose arrays are indexed
+with [N] or [*].
+
+-non-default only shows settings that differ from Consul's documented defaults for the agent
+version; settings without a documented default, such as NodeName or DataDir, are left out.
+
+Example:
+    $ consul-debug-read agent config -format hcl > agent.hcl
+    $ consul-debug-read agent config -query 'DNS*'
+    $ consul-debug-read agent config -query 'Limits.*' -format json
+    $ consul-debug-read agent config -query 'TLS.*.Verify*' -non-default
+    $ consul-debug-read agent config -non-default
+    $ consul-debug-read agent config -full
 `
@@ -0,0 +1,127 @@
+package lint
+
+import (
+	"consul-debug-read/internal/read/commands"
+	"consul-debug-read/internal/read/commands/config/get"
+	"consul-debug-read/internal/read/commands/flags"
+	"consul-debug-read/internal/read/lint"
+	"consul-debug-read/internal/read/loader"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/hashicorp/go-hclog"
+	"github.com/mitchellh/cli"
+)
+
+type cmd struct {
+	ui        cli.Ui
+	flags     *flag.FlagSet
+	pathFlags *flags.DebugReadFlags
+
+	severity string
+	format   string
+
+	verbose bool
+	silent  bool
+}
+
+func New(ui cli.Ui) (cli.Command, error) {
+	c := &cmd{
+		ui:        ui,
+		pathFlags: &flags.DebugReadFlags{},
+		flags:     flag.NewFlagSet("", flag.ContinueOnError),
+	}
+	c.flags.StringVar(&c.severity, "severity", lint.SeverityInfo, "Minimum severity of findings to report: info, warning or critical")
+	c.flags.StringVar(&c.format, "format", "table", "Output format: table or json")
+	c.flags.BoolVar(&c.silent, "silent", false, "Disables all normal log output")
+	c.flags.BoolVar(&c.verbose, "verbose", false, "Enable verbose debugging output")
+
+	flags.FlagMerge(c.flags, c.pathFlags.Flags())
+
+	return c, nil
+}
+
+func (c *cmd) Help() string { return commands.Usage(help, c.flags) }
+
+func (c *cmd) Synopsis() string { return synopsis }
+
+func (c *cmd) Run(args []string) int {
+	if err := c.flags.Parse(args); err != nil {
+		c.ui.Error(fmt.Sprintf("Failed to parse flags: %v", err))
+		return 1
+	}
+	if c.verbose && c.silent {
+		c.ui.Error(fmt.Sprintf("Cannot specify both -silent and -verbose"))
+		return 1
+	}
+	if !lint.ValidSeverity(c.severity) {
+		c.ui.Error(fmt.Sprintf("Invalid -severity %q: must be one of info, warning or critical", c.severity))
+		return 1
+	}
+	if c.format != "table" && c.format != "json" {
+		c.ui.Error(fmt.Sprintf("Invalid -format %q: must be one of table or json", c.format))
+		return 1
+	}
+
+	level := hclog.Info
+	if c.verbose {
+		level = hclog.Debug
+	} else if c.silent {
+		level = hclog.Off
+	}
+
+	commands.InitLogging(c.ui, level)
+
+	var ok bool
+	var path string
+	if path, ok = get.RenderPath(c.pathFlags); !ok {
+		hclog.L().Error("error rendering debug filepath", "filepath", path)
+		return 1
+	}
+
+	b, ok := commands.OpenBundle(c.pathFlags, path, loader.PartAgent)
+	if !ok {
+		return 1
+	}
+	data := b.Debug()
+	hclog.L().Debug("successfully read in agent information from bundle")
+
+	report, err := lint.Lint(&data.Agent)
+	if err != nil {
+		hclog.L().Error("failed to lint agent configuration", "error", err)
+		return 1
+	}
+
+	if c.format == "json" {
+		report.Found = report.Filter(c.severity)
+		if report.Found == nil {
+			report.Found = []lint.Finding{}
+		}
+		out, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			hclog.L().Error("failed to marshal lint report", "error", err)
+			return 1
+		}
+		c.ui.Output(string(out))
+		return 0
+	}
+	c.ui.Output(lint.Summary(report, c.severity))
+	return 0
+}
+
+const synopsis = `Checks the agent configuration against Consul best practices`
+const help = `
+Usage: 
+    consul-debug-read agent lint [options]
+
+Evaluates the DebugConfig of agent.json against Consul's configuration best practices: ACL
+default and down policies, DNS stale reads, raft snapshot and trailing log settings, the raft
+multiplier, gossip encryption, TLS verification, update checks, UI exposure and autopilot
+dead server cleanup. Each finding has a severity, a recommendation and a link to the Consul
+documentation. Raft and autopilot checks only apply to servers.
+
+Example:
+    $ consul-debug-read agent lint
+    $ consul-debug-read agent lint -severity warning
+    $ consul-debug-read agent lint -format json
+`
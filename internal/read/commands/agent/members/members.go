@@ -5,6 +5,7 @@ import (
 	"consul-debug-read/internal/read/commands"
 	"consul-debug-read/internal/read/commands/config/get"
 	"consul-debug-read/internal/read/commands/flags"
+	"consul-debug-read/internal/read/loader"
 	"flag"
 	"fmt"
 	"github.com/hashicorp/go-hclog"
@@ -60,20 +61,16 @@ func (c *cmd) Run(args []string) int {
 	var ok bool
 	var err error
 	var path string
-	if path, ok = get.RenderPathFromConfig(); !ok {
+	if path, ok = get.RenderPath(c.pathFlags); !ok {
 		hclog.L().Error("error rendering debug filepath", "filepath", path, "error", err)
 		return 1
 	}
 
-	var data read.Debug
-	if err = data.DecodeJSON(path, "agent"); err != nil {
-		hclog.L().Error("failed to decode agent.json", "error", err)
-		return 1
-	}
-	if err = data.DecodeJSON(path, "members"); err != nil {
-		hclog.L().Error("failed to decode members.json", "error", err)
+	b, ok := commands.OpenBundle(c.pathFlags, path, loader.PartAgent, loader.PartMembers)
+	if !ok {
 		return 1
 	}
+	data := b.Debug()
 	hclog.L().Debug("successfully read in agent cmd information from bundle")
 
 	result := agentMembers(data.Agent)
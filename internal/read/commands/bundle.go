@@ -0,0 +1,56 @@
+package commands
+
+import (
+	"consul-debug-read/internal/read"
+	"consul-debug-read/internal/read/commands/flags"
+	"consul-debug-read/internal/read/loader"
+	"consul-debug-read/pkg/redact"
+	"context"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/hashicorp/go-hclog"
+)
+
+// OpenBundle opens the bundle at path and concurrently decodes the given parts, logging progress
+// at debug level and any failure. An interrupt cancels decoding.
+func OpenBundle(pathFlags *flags.DebugReadFlags, path string, parts ...string) (*loader.Bundle, bool) {
+	b, err := loader.Open(path, BundleOptions(pathFlags.NoCache, pathFlags.Redactor())...)
+	if err != nil {
+		hclog.L().Error("failed to open bundle", "path", path, "error", err)
+		return nil, false
+	}
+	if len(parts) == 0 {
+		return b, true
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	hclog.L().Debug("reading in bundle", "path", b.Dir(), "parts", strings.Join(parts, ","))
+	if _, err = b.DecodeContext(ctx, parts...); err != nil {
+		hclog.L().Error("failed to read bundle", "error", err)
+		return nil, false
+	}
+	return b, true
+}
+
+// BundleOptions are the options commands open bundles with: progress logging, redaction with
+// redactor and, unless noCache, the analysis cache under read.DebugReadCacheDirPath.
+func BundleOptions(noCache bool, redactor *redact.Redactor) []loader.Option {
+	opts := []loader.Option{loader.WithProgress(logProgress), loader.WithRedactor(redactor)}
+	if !noCache {
+		opts = append(opts, loader.WithCache(read.DebugReadCacheDirPath))
+	}
+	return opts
+}
+
+func logProgress(p loader.Progress) {
+	switch {
+	case !p.Done:
+		hclog.L().Debug("reading in "+p.File, "bytes", p.Size)
+	case p.Err == nil && p.Cached:
+		hclog.L().Debug("read in "+p.File+" from cache", "bytes", p.Size, "elapsed", p.Elapsed)
+	case p.Err == nil:
+		hclog.L().Debug("read in "+p.File, "bytes", p.Size, "elapsed", p.Elapsed)
+	}
+}
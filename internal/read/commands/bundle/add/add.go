@@ -0,0 +1,183 @@
+package add
+
+import (
+	"consul-debug-read/internal/read"
+	"consul-debug-read/internal/read/commands"
+	"consul-debug-read/internal/read/commands/config/set"
+	"flag"
+	"fmt"
+	"github.com/hashicorp/go-hclog"
+	"github.com/mitchellh/cli"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type cmd struct {
+	ui    cli.Ui
+	flags *flag.FlagSet
+
+	name   string
+	ticket string
+	tags   string
+	use    bool
+	sel    string
+
+	verbose bool
+	silent  bool
+}
+
+func New(ui cli.Ui) (cli.Command, error) {
+	c := &cmd{
+		ui:    ui,
+		flags: flag.NewFlagSet("", flag.ContinueOnError),
+	}
+	c.flags.StringVar(&c.name, "name", "", "Name to register the bundle under (defaults to <ticket>-<node>-<capture time>)")
+	c.flags.StringVar(&c.ticket, "ticket", "", "Support ticket the bundle belongs to (defaults to the number prefixed to the archive name)")
+	c.flags.StringVar(&c.tags, "tags", "", "Comma separated tags to add to the bundle")
+	c.flags.BoolVar(&c.use, "use", false, "Also make the bundle the default bundle under analysis")
+	c.flags.StringVar(&c.sel, "select", "", read.SelectUsage)
+
+	c.flags.BoolVar(&c.silent, "silent", false, "Disables all normal log output")
+	c.flags.BoolVar(&c.verbose, "verbose", false, "Enable verbose debugging output")
+
+	return c, nil
+}
+
+func (c *cmd) Help() string { return commands.Usage(help, c.flags) }
+
+func (c *cmd) Synopsis() string { return synopsis }
+
+func (c *cmd) Run(args []string) int {
+	if err := c.flags.Parse(args); err != nil {
+		c.ui.Error(fmt.Sprintf("Failed to parse flags: %v", err))
+		return 1
+	}
+	if c.verbose && c.silent {
+		c.ui.Error(fmt.Sprintf("Cannot specify both -silent and -verbose"))
+		return 1
+	}
+	if c.flags.NArg() != 1 {
+		c.ui.Error("Expected a single bundle path\n\n" + c.Help())
+		return 1
+	}
+
+	level := hclog.Info
+	if c.verbose {
+		level = hclog.Debug
+	} else if c.silent {
+		level = hclog.Off
+	}
+
+	commands.InitLogging(c.ui, level)
+
+	selection, err := read.ParseBundleSelection(c.sel)
+	if err != nil {
+		c.ui.Error(err.Error())
+		return 1
+	}
+	path := c.flags.Arg(0)
+	extractedPath, archive, err := extract(path, selection)
+	if err != nil {
+		hclog.L().Error("failed to extract bundle", "path", path, "error", err)
+		return 1
+	}
+	if ok, err := set.ValidateDebugPath(extractedPath); !ok {
+		hclog.L().Error("bundle is invalid and does not contain all required debug bundle file extracts", "path", extractedPath, "error", err)
+		return 1
+	}
+
+	registry, err := read.LoadBundleRegistry()
+	if err != nil {
+		hclog.L().Error("failed to load bundle registry", "error", err)
+		return 1
+	}
+	if existing, ok := registry.FindPath(extractedPath); ok && c.name != "" && existing.Name != c.name {
+		hclog.L().Error("bundle path is already registered under another name", "path", extractedPath, "bundle", existing.Name)
+		return 1
+	}
+	b, err := registry.Register(extractedPath, archive, c.name, c.ticket)
+	if err != nil {
+		hclog.L().Error("failed to register bundle", "path", extractedPath, "error", err)
+		return 1
+	}
+	if c.tags != "" {
+		b.AddTags(splitTags(c.tags)...)
+	}
+	name, bundlePath := b.Name, b.Path
+	if c.use {
+		registry.Default = name
+	}
+	if err = registry.Save(); err != nil {
+		hclog.L().Error("failed to save bundle registry", "path", read.BundleRegistryPath, "error", err)
+		return 1
+	}
+	if c.use {
+		if ok, err := set.UpdateCurrentPathFrom(bundlePath, "bundle:"+name); !ok {
+			hclog.L().Error("failed update debug-read configuration file", "error", err)
+			return 1
+		}
+	}
+	c.ui.Output(fmt.Sprintf("registered bundle %s => %s", name, bundlePath))
+	return 0
+}
+
+// extract returns the extracted bundle directory for path, extracting archives as needed, along
+// with the archive it was extracted from when known. Directories of archives extract the archive
+// picked by selection.
+func extract(path string, selection read.BundleSelection) (string, string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", "", err
+	}
+	if !info.IsDir() {
+		if !strings.HasSuffix(path, ".tar.gz") {
+			return "", "", fmt.Errorf("%s is neither a bundle directory nor a .tar.gz archive", path)
+		}
+		extractedPath, err := read.ExtractBundle(path)
+		return extractedPath, path, err
+	}
+	if _, err = os.Stat(filepath.Join(path, "index.json")); err == nil {
+		return path, "", nil
+	}
+	archives, err := read.ListBundleArchives(path)
+	if err != nil {
+		return "", "", err
+	}
+	if len(archives) == 0 {
+		return path, "", nil
+	}
+	archive, err := selection.Select(path, archives)
+	if err != nil {
+		return "", "", err
+	}
+	archivePath := filepath.Join(path, archive.Name())
+	extractedPath, err := read.ExtractBundle(archivePath)
+	return extractedPath, archivePath, err
+}
+
+func splitTags(tags string) []string {
+	var split []string
+	for _, t := range strings.Split(tags, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			split = append(split, t)
+		}
+	}
+	return split
+}
+
+const synopsis = `Registers a debug bundle under a name`
+const help = `
+Usage:
+    consul-debug-read bundle add [options] <bundle>
+
+Registers an extracted bundle directory or a .tar.gz archive (extracted alongside the archive) in
+the bundle registry. From a directory holding several archives, -select picks the archive to extract.
+
+The node, datacenter, agent version and capture time are read from the bundle. The name defaults to
+<ticket>-<node>-<capture time>, where the ticket defaults to the number prefixed to the archive name.
+
+Example:
+    $ consul-debug-read bundle add bundles/124722consul-debug-2023-10-04T18-29-47Z.tar.gz
+    $ consul-debug-read bundle add -name=eu-outage -tags=eu-01,server -use bundles/consul-debug-eu-01-stag
+`
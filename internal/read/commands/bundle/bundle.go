@@ -0,0 +1,35 @@
+package bundle
+
+import (
+	"consul-debug-read/internal/read/commands"
+	"github.com/mitchellh/cli"
+)
+
+type Cmd struct{}
+
+func New() *Cmd {
+	return &Cmd{}
+}
+
+func (c *Cmd) Help() string {
+	return commands.Usage(help, nil)
+}
+
+func (c *Cmd) Synopsis() string { return synopsis }
+
+func (c *Cmd) Run(args []string) int {
+	return cli.RunResultHelp
+}
+
+const synopsis = `Manages the registry of named debug bundles`
+const help = `
+Usage: 
+    consul-debug-read bundle <subcommand> [options]
+
+  Registered bundles are kept in $HOME/.consul-debug-read/bundles.yaml and can be switched to
+  with 'bundle use', or read by any command for a single invocation with -bundle=<name>.
+  Bundles set with 'config set-path' are registered automatically.
+
+  Run consul-debug-read bundle <subcommand> with no arguments for help on that
+  subcommand.
+`
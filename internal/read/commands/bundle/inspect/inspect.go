@@ -0,0 +1,143 @@
+package inspect
+
+import (
+	"consul-debug-read/internal/read"
+	"consul-debug-read/internal/read/commands"
+	"consul-debug-read/internal/read/commands/config/get"
+	"consul-debug-read/internal/read/commands/flags"
+	"consul-debug-read/internal/read/inspect"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/hashicorp/go-hclog"
+	"github.com/mitchellh/cli"
+	"os"
+	"strings"
+)
+
+type cmd struct {
+	ui        cli.Ui
+	flags     *flag.FlagSet
+	pathFlags *flags.DebugReadFlags
+
+	format string
+
+	verbose bool
+	silent  bool
+}
+
+func New(ui cli.Ui) (cli.Command, error) {
+	c := &cmd{
+		ui:        ui,
+		flags:     flag.NewFlagSet("", flag.ContinueOnError),
+		pathFlags: &flags.DebugReadFlags{},
+	}
+	c.flags.StringVar(&c.format, "format", "table", "Output format of the report: table or json")
+
+	c.flags.BoolVar(&c.silent, "silent", false, "Disables all normal log output")
+	c.flags.BoolVar(&c.verbose, "verbose", false, "Enable verbose debugging output")
+
+	flags.FlagMerge(c.flags, c.pathFlags.Flags())
+	return c, nil
+}
+
+func (c *cmd) Help() string { return commands.Usage(help, c.flags) }
+
+func (c *cmd) Synopsis() string { return synopsis }
+
+func (c *cmd) Run(args []string) int {
+	if err := c.flags.Parse(args); err != nil {
+		c.ui.Error(fmt.Sprintf("Failed to parse flags: %v", err))
+		return 1
+	}
+	if c.verbose && c.silent {
+		c.ui.Error(fmt.Sprintf("Cannot specify both -silent and -verbose"))
+		return 1
+	}
+	if c.format != "table" && c.format != "json" {
+		c.ui.Error(fmt.Sprintf("Invalid -format %q: must be one of table or json", c.format))
+		return 1
+	}
+	if c.flags.NArg() > 1 {
+		c.ui.Error("Expected at most one bundle path or name\n\n" + c.Help())
+		return 1
+	}
+
+	level := hclog.Info
+	if c.verbose {
+		level = hclog.Debug
+	} else if c.silent {
+		level = hclog.Off
+	}
+
+	commands.InitLogging(c.ui, level)
+
+	var path string
+	if c.flags.NArg() == 1 {
+		var err error
+		if path, err = bundleDir(c.flags.Arg(0)); err != nil {
+			hclog.L().Error("failed to open bundle", "bundle", c.flags.Arg(0), "error", err)
+			return 1
+		}
+	} else {
+		var ok bool
+		if path, ok = get.RenderPath(c.pathFlags); !ok {
+			hclog.L().Error("error rendering debug filepath", "filepath", path)
+			return 1
+		}
+	}
+	hclog.L().Debug("inspecting bundle", "path", path)
+
+	report, err := inspect.Inspect(path)
+	if err != nil {
+		hclog.L().Error("failed to inspect bundle", "path", path, "error", err)
+		return 1
+	}
+	if c.format == "json" {
+		out, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			hclog.L().Error("failed to encode bundle report", "error", err)
+			return 1
+		}
+		c.ui.Output(string(out))
+		return 0
+	}
+	c.ui.Output(inspect.Summary(report))
+	return 0
+}
+
+// bundleDir returns the extracted directory of a bundle directory, archive or registered bundle
+// name, without validating its contents: reporting on incomplete bundles is the point of inspect.
+func bundleDir(arg string) (string, error) {
+	path, err := read.ResolveBundleArg(arg)
+	if err != nil {
+		return "", err
+	}
+	if info, err := os.Stat(path); err == nil && !info.IsDir() && strings.HasSuffix(path, ".tar.gz") {
+		return read.ExtractBundle(path)
+	}
+	return path, nil
+}
+
+const synopsis = `Reports the completeness and integrity of a debug bundle`
+const help = `
+Usage:
+    consul-debug-read bundle inspect [options] [<bundle>]
+
+Reports what a bundle holds before analysis starts, for a bundle directory, .tar.gz archive or
+registered bundle name, or the bundle under analysis when none is passed:
+
+    - which capture targets listed in index.json are present
+    - whether each JSON file decodes, with the byte offset of truncated or malformed content
+    - metric captures in metrics.json against those expected from the interval and duration
+    - missing interval captures and profile files
+    - how much of the capture window consul.log covers
+    - whether the bundle came from a server or a client, and which analyses it supports
+
+Bundles passed as an argument are not validated, so bundles other commands refuse to read can be
+inspected.
+
+Example:
+    $ consul-debug-read bundle inspect
+    $ consul-debug-read bundle inspect -format=json bundles/124722consul-debug-2023-10-04T18-29-47Z.tar.gz
+`
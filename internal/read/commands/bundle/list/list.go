@@ -0,0 +1,135 @@
+package list
+
+import (
+	"consul-debug-read/internal/read"
+	"consul-debug-read/internal/read/commands"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/hashicorp/go-hclog"
+	"github.com/mitchellh/cli"
+	"github.com/ryanuber/columnize"
+	"strings"
+	"time"
+)
+
+type cmd struct {
+	ui    cli.Ui
+	flags *flag.FlagSet
+
+	format string
+	tag    string
+	ticket string
+
+	verbose bool
+	silent  bool
+}
+
+func New(ui cli.Ui) (cli.Command, error) {
+	c := &cmd{
+		ui:    ui,
+		flags: flag.NewFlagSet("", flag.ContinueOnError),
+	}
+	c.flags.StringVar(&c.format, "format", "table", "Output format of the bundle list: table or json")
+	c.flags.StringVar(&c.tag, "tag", "", "Only list bundles with this tag")
+	c.flags.StringVar(&c.ticket, "ticket", "", "Only list bundles of this support ticket")
+
+	c.flags.BoolVar(&c.silent, "silent", false, "Disables all normal log output")
+	c.flags.BoolVar(&c.verbose, "verbose", false, "Enable verbose debugging output")
+
+	return c, nil
+}
+
+func (c *cmd) Help() string { return commands.Usage(help, c.flags) }
+
+func (c *cmd) Synopsis() string { return synopsis }
+
+func (c *cmd) Run(args []string) int {
+	if err := c.flags.Parse(args); err != nil {
+		c.ui.Error(fmt.Sprintf("Failed to parse flags: %v", err))
+		return 1
+	}
+	if c.verbose && c.silent {
+		c.ui.Error(fmt.Sprintf("Cannot specify both -silent and -verbose"))
+		return 1
+	}
+	if c.format != "table" && c.format != "json" {
+		c.ui.Error(fmt.Sprintf("Invalid -format %q: must be one of table or json", c.format))
+		return 1
+	}
+
+	level := hclog.Info
+	if c.verbose {
+		level = hclog.Debug
+	} else if c.silent {
+		level = hclog.Off
+	}
+
+	commands.InitLogging(c.ui, level)
+
+	registry, err := read.LoadBundleRegistry()
+	if err != nil {
+		hclog.L().Error("failed to load bundle registry", "error", err)
+		return 1
+	}
+	bundles := make([]read.RegisteredBundle, 0, len(registry.Bundles))
+	for _, b := range registry.Bundles {
+		if (c.tag == "" || b.HasTag(c.tag)) && (c.ticket == "" || b.Ticket == c.ticket) {
+			bundles = append(bundles, b)
+		}
+	}
+
+	if c.format == "json" {
+		out, err := json.MarshalIndent(bundles, "", "  ")
+		if err != nil {
+			hclog.L().Error("failed to encode bundles", "error", err)
+			return 1
+		}
+		c.ui.Output(string(out))
+		return 0
+	}
+
+	if len(bundles) == 0 {
+		c.ui.Output("no bundles registered, see 'consul-debug-read bundle add'")
+		return 0
+	}
+	stale := make(map[string]bool)
+	for _, b := range registry.Stale() {
+		stale[b.Name] = true
+	}
+	rows := []string{" \x1fName\x1fTicket\x1fDatacenter\x1fNode\x1fVersion\x1fCaptured\x1fTags\x1fPath"}
+	for _, b := range bundles {
+		marker := " "
+		if b.Name == registry.Default {
+			marker = "*"
+		}
+		path := b.Path
+		if stale[b.Name] {
+			path += " (missing)"
+		}
+		rows = append(rows, fmt.Sprintf("%s\x1f%s\x1f%s\x1f%s\x1f%s\x1f%s\x1f%s\x1f%s\x1f%s",
+			marker, b.Name, orDash(b.Ticket), b.Datacenter, b.Node, b.Version,
+			b.CaptureTime.Format(time.RFC3339), orDash(strings.Join(b.Tags, ",")), path))
+	}
+	c.ui.Output(columnize.Format(rows, &columnize.Config{Delim: string([]byte{0x1f}), Glue: " "}))
+	return 0
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+const synopsis = `Lists the registered debug bundles`
+const help = `
+Usage:
+    consul-debug-read bundle list [options]
+
+Lists the registered bundles. The default bundle under analysis is marked with '*', bundles whose
+extracted directory no longer exists are marked missing (see 'consul-debug-read bundle prune').
+
+Example:
+    $ consul-debug-read bundle list -ticket=124722
+`
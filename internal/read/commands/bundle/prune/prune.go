@@ -0,0 +1,146 @@
+package prune
+
+import (
+	"consul-debug-read/internal/read"
+	"consul-debug-read/internal/read/commands"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/hashicorp/go-hclog"
+	"github.com/mitchellh/cli"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+type cmd struct {
+	ui    cli.Ui
+	flags *flag.FlagSet
+
+	dryRun bool
+	cache  bool
+
+	verbose bool
+	silent  bool
+}
+
+func New(ui cli.Ui) (cli.Command, error) {
+	c := &cmd{
+		ui:    ui,
+		flags: flag.NewFlagSet("", flag.ContinueOnError),
+	}
+	c.flags.BoolVar(&c.dryRun, "dry-run", false, "List the bundles that would be pruned without removing them")
+	c.flags.BoolVar(&c.cache, "cache", false, "Also empty the analysis cache of every bundle")
+
+	c.flags.BoolVar(&c.silent, "silent", false, "Disables all normal log output")
+	c.flags.BoolVar(&c.verbose, "verbose", false, "Enable verbose debugging output")
+
+	return c, nil
+}
+
+func (c *cmd) Help() string { return commands.Usage(help, c.flags) }
+
+func (c *cmd) Synopsis() string { return synopsis }
+
+func (c *cmd) Run(args []string) int {
+	if err := c.flags.Parse(args); err != nil {
+		c.ui.Error(fmt.Sprintf("Failed to parse flags: %v", err))
+		return 1
+	}
+	if c.verbose && c.silent {
+		c.ui.Error(fmt.Sprintf("Cannot specify both -silent and -verbose"))
+		return 1
+	}
+
+	level := hclog.Info
+	if c.verbose {
+		level = hclog.Debug
+	} else if c.silent {
+		level = hclog.Off
+	}
+
+	commands.InitLogging(c.ui, level)
+
+	if c.cache {
+		if ok := c.pruneCache(); !ok {
+			return 1
+		}
+	}
+
+	registry, err := read.LoadBundleRegistry()
+	if err != nil {
+		hclog.L().Error("failed to load bundle registry", "error", err)
+		return 1
+	}
+	stale := registry.Stale()
+	if len(stale) == 0 {
+		c.ui.Output("no missing bundles to prune")
+		return 0
+	}
+	for _, b := range stale {
+		if c.dryRun {
+			c.ui.Output(fmt.Sprintf("would prune bundle %s (%s)", b.Name, b.Path))
+			continue
+		}
+		if _, err = registry.Remove(b.Name); err != nil {
+			hclog.L().Error("failed to prune bundle", "bundle", b.Name, "error", err)
+			return 1
+		}
+		c.ui.Output(fmt.Sprintf("pruned bundle %s (%s)", b.Name, b.Path))
+	}
+	if c.dryRun {
+		return 0
+	}
+	if err = registry.Save(); err != nil {
+		hclog.L().Error("failed to save bundle registry", "path", read.BundleRegistryPath, "error", err)
+		return 1
+	}
+	return 0
+}
+
+// pruneCache empties the analysis cache, which holds the parsed metrics.json and consul.log of
+// every bundle read so far.
+func (c *cmd) pruneCache() bool {
+	var size int64
+	err := filepath.WalkDir(read.DebugReadCacheDirPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if info, err := d.Info(); err == nil && !d.IsDir() {
+			size += info.Size()
+		}
+		return nil
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		c.ui.Output("analysis cache is empty")
+		return true
+	}
+	if err != nil {
+		hclog.L().Error("failed to read analysis cache", "path", read.DebugReadCacheDirPath, "error", err)
+		return false
+	}
+	if c.dryRun {
+		c.ui.Output(fmt.Sprintf("would empty analysis cache %s (%s)", read.DebugReadCacheDirPath, read.ConvertIntBytes(int(size))))
+		return true
+	}
+	if err = os.RemoveAll(read.DebugReadCacheDirPath); err != nil {
+		hclog.L().Error("failed to empty analysis cache", "path", read.DebugReadCacheDirPath, "error", err)
+		return false
+	}
+	c.ui.Output(fmt.Sprintf("emptied analysis cache %s (%s)", read.DebugReadCacheDirPath, read.ConvertIntBytes(int(size))))
+	return true
+}
+
+const synopsis = `Removes registered bundles whose files no longer exist`
+const help = `
+Usage:
+    consul-debug-read bundle prune [options]
+
+Unregisters every bundle whose extracted directory was deleted or moved. With -cache, also empties
+the analysis cache (~/.consul-debug-read/cache) of parsed metrics.json and consul.log files, which
+commands rebuild the next time they read a bundle.
+
+Example:
+    $ consul-debug-read bundle prune -dry-run
+    $ consul-debug-read bundle prune -cache
+`
@@ -0,0 +1,123 @@
+package remove
+
+import (
+	"consul-debug-read/internal/read"
+	"consul-debug-read/internal/read/commands"
+	"flag"
+	"fmt"
+	"github.com/hashicorp/go-hclog"
+	"github.com/mitchellh/cli"
+	"os"
+)
+
+type cmd struct {
+	ui    cli.Ui
+	flags *flag.FlagSet
+
+	delete bool
+
+	verbose bool
+	silent  bool
+}
+
+func New(ui cli.Ui) (cli.Command, error) {
+	c := &cmd{
+		ui:    ui,
+		flags: flag.NewFlagSet("", flag.ContinueOnError),
+	}
+	c.flags.BoolVar(&c.delete, "delete", false, "Also delete the extracted directory of bundles registered from a .tar.gz archive that still exists")
+
+	c.flags.BoolVar(&c.silent, "silent", false, "Disables all normal log output")
+	c.flags.BoolVar(&c.verbose, "verbose", false, "Enable verbose debugging output")
+
+	return c, nil
+}
+
+func (c *cmd) Help() string { return commands.Usage(help, c.flags) }
+
+func (c *cmd) Synopsis() string { return synopsis }
+
+func (c *cmd) Run(args []string) int {
+	if err := c.flags.Parse(args); err != nil {
+		c.ui.Error(fmt.Sprintf("Failed to parse flags: %v", err))
+		return 1
+	}
+	if c.verbose && c.silent {
+		c.ui.Error(fmt.Sprintf("Cannot specify both -silent and -verbose"))
+		return 1
+	}
+	if c.flags.NArg() == 0 {
+		c.ui.Error("Expected at least one bundle name\n\n" + c.Help())
+		return 1
+	}
+
+	level := hclog.Info
+	if c.verbose {
+		level = hclog.Debug
+	} else if c.silent {
+		level = hclog.Off
+	}
+
+	commands.InitLogging(c.ui, level)
+
+	registry, err := read.LoadBundleRegistry()
+	if err != nil {
+		hclog.L().Error("failed to load bundle registry", "error", err)
+		return 1
+	}
+	for _, name := range c.flags.Args() {
+		if _, err = registry.Lookup(name); err != nil {
+			hclog.L().Error("failed to remove bundle", "error", err)
+			return 1
+		}
+	}
+	var removed []read.RegisteredBundle
+	for _, name := range c.flags.Args() {
+		b, err := registry.Remove(name)
+		if err != nil {
+			hclog.L().Error("failed to remove bundle", "error", err)
+			return 1
+		}
+		removed = append(removed, b)
+	}
+	if err = registry.Save(); err != nil {
+		hclog.L().Error("failed to save bundle registry", "path", read.BundleRegistryPath, "error", err)
+		return 1
+	}
+
+	status := 0
+	for _, b := range removed {
+		c.ui.Output(fmt.Sprintf("removed bundle %s", b.Name))
+		if !c.delete {
+			continue
+		}
+		// Only extractions that can be recreated from their archive are deleted
+		if b.Archive == "" {
+			hclog.L().Warn("bundle was not registered from an archive, keeping its directory", "bundle", b.Name, "path", b.Path)
+			continue
+		}
+		if _, err = os.Stat(b.Archive); err != nil {
+			hclog.L().Warn("bundle archive no longer exists, keeping its extracted directory", "bundle", b.Name, "archive", b.Archive)
+			continue
+		}
+		if err = os.RemoveAll(b.Path); err != nil {
+			hclog.L().Error("failed to delete extracted bundle", "bundle", b.Name, "path", b.Path, "error", err)
+			status = 1
+			continue
+		}
+		c.ui.Output(fmt.Sprintf("deleted %s", b.Path))
+	}
+	return status
+}
+
+const synopsis = `Removes bundles from the registry`
+const help = `
+Usage:
+    consul-debug-read bundle remove [options] <name>...
+
+Unregisters bundles. Their files are kept unless -delete is passed, which deletes the extracted
+directory of bundles registered from a .tar.gz archive that still exists, so they can be added again.
+
+Example:
+    $ consul-debug-read bundle remove -delete 124722-server-1-20231004T182947Z
+`
@@ -0,0 +1,107 @@
+package tag
+
+import (
+	"consul-debug-read/internal/read"
+	"consul-debug-read/internal/read/commands"
+	"flag"
+	"fmt"
+	"github.com/hashicorp/go-hclog"
+	"github.com/mitchellh/cli"
+	"strings"
+)
+
+type cmd struct {
+	ui    cli.Ui
+	flags *flag.FlagSet
+
+	remove bool
+	ticket string
+
+	verbose bool
+	silent  bool
+}
+
+func New(ui cli.Ui) (cli.Command, error) {
+	c := &cmd{
+		ui:    ui,
+		flags: flag.NewFlagSet("", flag.ContinueOnError),
+	}
+	c.flags.BoolVar(&c.remove, "remove", false, "Remove the given tags instead of adding them")
+	c.flags.StringVar(&c.ticket, "ticket", "", "Also set the support ticket of the bundle")
+
+	c.flags.BoolVar(&c.silent, "silent", false, "Disables all normal log output")
+	c.flags.BoolVar(&c.verbose, "verbose", false, "Enable verbose debugging output")
+
+	return c, nil
+}
+
+func (c *cmd) Help() string { return commands.Usage(help, c.flags) }
+
+func (c *cmd) Synopsis() string { return synopsis }
+
+func (c *cmd) Run(args []string) int {
+	if err := c.flags.Parse(args); err != nil {
+		c.ui.Error(fmt.Sprintf("Failed to parse flags: %v", err))
+		return 1
+	}
+	if c.verbose && c.silent {
+		c.ui.Error(fmt.Sprintf("Cannot specify both -silent and -verbose"))
+		return 1
+	}
+	if c.flags.NArg() < 2 && !(c.flags.NArg() == 1 && c.ticket != "") {
+		c.ui.Error("Expected a bundle name followed by tags\n\n" + c.Help())
+		return 1
+	}
+
+	level := hclog.Info
+	if c.verbose {
+		level = hclog.Debug
+	} else if c.silent {
+		level = hclog.Off
+	}
+
+	commands.InitLogging(c.ui, level)
+
+	registry, err := read.LoadBundleRegistry()
+	if err != nil {
+		hclog.L().Error("failed to load bundle registry", "error", err)
+		return 1
+	}
+	b, err := registry.Lookup(c.flags.Arg(0))
+	if err != nil {
+		hclog.L().Error("failed to tag bundle", "error", err)
+		return 1
+	}
+	tags := c.flags.Args()[1:]
+	if c.remove {
+		b.RemoveTags(tags...)
+	} else {
+		b.AddTags(tags...)
+	}
+	if c.ticket != "" {
+		b.Ticket = c.ticket
+	}
+	name, current := b.Name, strings.Join(b.Tags, ",")
+	if err = registry.Save(); err != nil {
+		hclog.L().Error("failed to save bundle registry", "path", read.BundleRegistryPath, "error", err)
+		return 1
+	}
+	if current == "" {
+		current = "<none>"
+	}
+	c.ui.Output(fmt.Sprintf("bundle %s tags: %s", name, current))
+	return 0
+}
+
+const synopsis = `Adds or removes tags of a registered bundle`
+const help = `
+Usage:
+    consul-debug-read bundle tag [options] <name> <tag>...
+
+Tags a registered bundle, e.g. with the incident, environment or role it belongs to, so it can be
+found again with 'consul-debug-read bundle list -tag=<tag>'.
+
+Example:
+    $ consul-debug-read bundle tag 124722-server-1-20231004T182947Z outage leader
+    $ consul-debug-read bundle tag -remove 124722-server-1-20231004T182947Z leader
+`
@@ -0,0 +1,100 @@
+package use
+
+import (
+	"consul-debug-read/internal/read"
+	"consul-debug-read/internal/read/commands"
+	"consul-debug-read/internal/read/commands/config/set"
+	"flag"
+	"fmt"
+	"github.com/hashicorp/go-hclog"
+	"github.com/mitchellh/cli"
+	"os"
+)
+
+type cmd struct {
+	ui    cli.Ui
+	flags *flag.FlagSet
+
+	verbose bool
+	silent  bool
+}
+
+func New(ui cli.Ui) (cli.Command, error) {
+	c := &cmd{
+		ui:    ui,
+		flags: flag.NewFlagSet("", flag.ContinueOnError),
+	}
+	c.flags.BoolVar(&c.silent, "silent", false, "Disables all normal log output")
+	c.flags.BoolVar(&c.verbose, "verbose", false, "Enable verbose debugging output")
+
+	return c, nil
+}
+
+func (c *cmd) Help() string { return commands.Usage(help, c.flags) }
+
+func (c *cmd) Synopsis() string { return synopsis }
+
+func (c *cmd) Run(args []string) int {
+	if err := c.flags.Parse(args); err != nil {
+		c.ui.Error(fmt.Sprintf("Failed to parse flags: %v", err))
+		return 1
+	}
+	if c.verbose && c.silent {
+		c.ui.Error(fmt.Sprintf("Cannot specify both -silent and -verbose"))
+		return 1
+	}
+	if c.flags.NArg() != 1 {
+		c.ui.Error("Expected a single bundle name\n\n" + c.Help())
+		return 1
+	}
+
+	level := hclog.Info
+	if c.verbose {
+		level = hclog.Debug
+	} else if c.silent {
+		level = hclog.Off
+	}
+
+	commands.InitLogging(c.ui, level)
+
+	registry, err := read.LoadBundleRegistry()
+	if err != nil {
+		hclog.L().Error("failed to load bundle registry", "error", err)
+		return 1
+	}
+	b, err := registry.Lookup(c.flags.Arg(0))
+	if err != nil {
+		hclog.L().Error("failed to select bundle", "error", err)
+		return 1
+	}
+	if _, err = os.Stat(b.Path); err != nil {
+		hclog.L().Error("registered bundle path is no longer available, see 'consul-debug-read bundle prune'", "bundle", b.Name, "path", b.Path, "error", err)
+		return 1
+	}
+	if ok, err := set.UpdateCurrentPathFrom(b.Path, "bundle:"+b.Name); !ok {
+		hclog.L().Error("failed update debug-read configuration file", "error", err)
+		return 1
+	}
+	registry.Default = b.Name
+	if err = registry.Save(); err != nil {
+		hclog.L().Error("failed to save bundle registry", "path", read.BundleRegistryPath, "error", err)
+		return 1
+	}
+	if os.Getenv(read.DebugReadEnvVar) != "" {
+		hclog.L().Warn("CONSUL_DEBUG_PATH is set and takes precedence over the bundle in use, unset it to read this bundle", read.DebugReadEnvVar, os.Getenv(read.DebugReadEnvVar))
+	}
+	c.ui.Output(fmt.Sprintf("using bundle %s => %s", b.Name, b.Path))
+	return 0
+}
+
+const synopsis = `Switches the bundle under analysis to a registered bundle`
+const help = `
+Usage:
+    consul-debug-read bundle use <name>
+
+Points the consul-debug-read configuration at a registered bundle, without extracting it again.
+To read another bundle for a single command instead, pass -bundle=<name> to that command.
+
+Example:
+    $ consul-debug-read bundle use 124722-server-1-20231004T182947Z
+`
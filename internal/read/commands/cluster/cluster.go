@@ -0,0 +1,176 @@
+package cluster
+
+import (
+	"consul-debug-read/internal/read"
+	"consul-debug-read/internal/read/cluster"
+	"consul-debug-read/internal/read/commands"
+	"consul-debug-read/internal/read/commands/flags"
+	"consul-debug-read/internal/read/loader"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/hashicorp/go-hclog"
+	"github.com/mitchellh/cli"
+	"os"
+	"path/filepath"
+)
+
+type cmd struct {
+	ui          cli.Ui
+	flags       *flag.FlagSet
+	redactFlags *flags.RedactFlags
+
+	format string
+
+	verbose bool
+	silent  bool
+}
+
+func New(ui cli.Ui) (cli.Command, error) {
+	c := &cmd{
+		ui:          ui,
+		redactFlags: &flags.RedactFlags{},
+		flags:       flag.NewFlagSet("", flag.ContinueOnError),
+	}
+	c.flags.StringVar(&c.format, "format", "table", "Output format of the report: table or json")
+
+	c.flags.BoolVar(&c.silent, "silent", false, "Disables all normal log output")
+	c.flags.BoolVar(&c.verbose, "verbose", false, "Enable verbose debugging output")
+
+	flags.FlagMerge(c.flags, c.redactFlags.Flags())
+
+	return c, nil
+}
+
+func (c *cmd) Help() string { return commands.Usage(help, c.flags) }
+
+func (c *cmd) Synopsis() string { return synopsis }
+
+func (c *cmd) Run(args []string) int {
+	if err := c.flags.Parse(args); err != nil {
+		c.ui.Error(fmt.Sprintf("Failed to parse flags: %v", err))
+		return 1
+	}
+	if c.verbose && c.silent {
+		c.ui.Error(fmt.Sprintf("Cannot specify both -silent and -verbose"))
+		return 1
+	}
+	if c.format != "table" && c.format != "json" {
+		c.ui.Error(fmt.Sprintf("Invalid -format %q: must be one of table or json", c.format))
+		return 1
+	}
+	if c.flags.NArg() == 0 {
+		c.ui.Error("Expected bundle paths or a directory of bundles\n\n" + c.Help())
+		return 1
+	}
+
+	level := hclog.Info
+	if c.verbose {
+		level = hclog.Debug
+	} else if c.silent {
+		level = hclog.Off
+	}
+
+	commands.InitLogging(c.ui, level)
+
+	paths, err := bundlePaths(c.flags.Args())
+	if err != nil {
+		hclog.L().Error("failed to list bundles", "error", err)
+		return 1
+	}
+	var bundles []cluster.Bundle
+	for _, path := range paths {
+		hclog.L().Debug("loading bundle", "path", path)
+		b, err := loader.Load(path, commands.BundleOptions(false, c.redactFlags.Redactor())...)
+		if err != nil {
+			hclog.L().Error("failed to load bundle", "path", path, "error", err)
+			return 1
+		}
+		node := b.Agent.Config.NodeName
+		if node == "" {
+			node = filepath.Base(path)
+		}
+		bundles = append(bundles, cluster.Bundle{Node: node, Path: path, Debug: b})
+	}
+
+	report, err := cluster.Analyze(bundles)
+	if err != nil {
+		hclog.L().Error("failed to analyze bundles", "error", err)
+		return 1
+	}
+
+	if c.format == "json" {
+		out, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			hclog.L().Error("failed to encode cluster report", "error", err)
+			return 1
+		}
+		c.ui.Output(string(out))
+		return 0
+	}
+	for _, b := range bundles {
+		c.ui.Output(fmt.Sprintf("==> %s: %s", b.Node, b.Path))
+	}
+	c.ui.Output("")
+	c.ui.Output(cluster.Summary(report))
+	return 0
+}
+
+// bundlePaths expands the arguments into bundle paths: bundle archives and extracted bundle
+// directories are used as is, any other directory contributes the bundle archives it contains and
+// registered bundle names resolve to their extracted directory.
+func bundlePaths(args []string) ([]string, error) {
+	var paths []string
+	for _, arg := range args {
+		arg, err := read.ResolveBundleArg(arg)
+		if err != nil {
+			return nil, err
+		}
+		info, err := os.Stat(arg)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			paths = append(paths, arg)
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(arg, "index.json")); err == nil {
+			paths = append(paths, arg)
+			continue
+		}
+		archives, err := read.ListBundleArchives(arg)
+		if err != nil {
+			return nil, err
+		}
+		if len(archives) == 0 {
+			return nil, fmt.Errorf("%s is neither a bundle nor a directory of bundle archives", arg)
+		}
+		for _, a := range archives {
+			paths = append(paths, filepath.Join(arg, a.Name()))
+		}
+	}
+	return paths, nil
+}
+
+const synopsis = `Cross-checks the bundles of several agents of one cluster`
+const help = `
+Usage:
+    consul-debug-read cluster [options] <bundle|directory>...
+
+Loads one bundle per agent (extracted directories, .tar.gz archives, registered bundle names, or
+directories holding bundle archives) and cross-checks them:
+
+    - the latest raft configuration reported by each server
+    - the leader each server believes in (Stats.Consul.LeaderAddr)
+    - applied index lag behind the highest commit index captured across servers
+    - version skew between agents
+    - DebugConfig drift, ignoring per-node fields such as NodeName and bind addresses
+    - a merged view of the serf member status reported by every agent
+
+Servers trailing by 250 or more raft entries, and members whose status agents disagree on, are
+listed under Findings.
+
+Example:
+    $ consul-debug-read cluster ./bundles
+    $ consul-debug-read cluster -format=json server-1.tar.gz server-2.tar.gz server-3.tar.gz
+`
@@ -3,6 +3,7 @@ package get
 import (
 	"consul-debug-read/internal/read"
 	"consul-debug-read/internal/read/commands"
+	"consul-debug-read/internal/read/commands/config/set"
 	"consul-debug-read/internal/read/commands/flags"
 	"flag"
 	"fmt"
@@ -10,6 +11,7 @@ import (
 	"github.com/mitchellh/cli"
 	"gopkg.in/yaml.v2"
 	"os"
+	"path/filepath"
 )
 
 type Cmd struct {
@@ -60,13 +62,91 @@ func (c *Cmd) Run(args []string) int {
 
 	commands.InitLogging(c.ui, level)
 	hclog.L().Debug("rendering debug path setting from config.yaml")
-	if path, ok := RenderPathFromConfig(); ok {
+	if path, ok := RenderPath(c.pathFlags); ok {
 		c.ui.Output(path)
 	}
 	return 0
 }
 
-func RenderPathFromConfig() (string, bool) {
+// RenderPath returns the bundle path a command reads: the bundle passed with -bundle, -file or
+// -path for this invocation, otherwise the path rendered from CONSUL_DEBUG_PATH or config.yaml.
+// Directories of bundle archives are resolved with the -select strategy.
+func RenderPath(f *flags.DebugReadFlags) (string, bool) {
+	if f == nil {
+		return RenderPathFromConfig(read.BundleSelection{})
+	}
+	selection, err := read.ParseBundleSelection(f.Select.String())
+	if err != nil {
+		hclog.L().Error("failed to parse -select", "error", err)
+		return "", false
+	}
+	bundle, file, path := f.Bundle.String(), f.DebugFilePath.String(), f.DebugDirPath.String()
+	passed := 0
+	for _, v := range []string{bundle, file, path} {
+		if v != "" {
+			passed++
+		}
+	}
+	if passed > 1 {
+		hclog.L().Error("only one of -bundle, -file or -path may be passed", "bundle", bundle, "file", file, "path", path)
+		return "", false
+	}
+	switch {
+	case bundle != "":
+		return RenderRegisteredBundle(bundle)
+	case file != "":
+		return RenderInvocationPath(file, selection)
+	case path != "":
+		return RenderInvocationPath(path, selection)
+	}
+	return RenderPathFromConfig(selection)
+}
+
+// RenderInvocationPath resolves a -path or -file value without changing config.yaml: extracted
+// bundle directories are used as is, archives are extracted alongside themselves and directories of
+// archives extract the archive picked by selection.
+func RenderInvocationPath(path string, selection read.BundleSelection) (string, bool) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		hclog.L().Error("failed to obtain absolute path", "path", path, "error", err)
+		return "", false
+	}
+	extractedPath := path
+	if _, err = os.Stat(filepath.Join(path, "index.json")); err != nil {
+		if extractedPath, err = read.SelectAndExtractTarGzFilesInDir(path, selection); err != nil {
+			hclog.L().Error("failed to extract bundle from path", "path", path, "error", err)
+			return "", false
+		}
+	}
+	if ok, err := set.ValidateDebugPath(extractedPath); !ok {
+		hclog.L().Error("bundle is invalid and does not contain all required debug bundle file extracts", "path", extractedPath, "error", err)
+		return "", false
+	}
+	hclog.L().Debug("configuring path for this invocation", "path", extractedPath)
+	return extractedPath, true
+}
+
+// RenderRegisteredBundle returns the extracted path of the named registered bundle.
+func RenderRegisteredBundle(name string) (string, bool) {
+	registry, err := read.LoadBundleRegistry()
+	if err != nil {
+		hclog.L().Error("failed to load bundle registry", "error", err)
+		return "", false
+	}
+	b, err := registry.Lookup(name)
+	if err != nil {
+		hclog.L().Error("failed to resolve -bundle", "error", err)
+		return "", false
+	}
+	if _, err = os.Stat(b.Path); err != nil {
+		hclog.L().Error("registered bundle path is no longer available, see 'consul-debug-read bundle prune'", "bundle", b.Name, "path", b.Path, "error", err)
+		return "", false
+	}
+	hclog.L().Debug("configuring path from registered bundle", "bundle", b.Name, "path", b.Path)
+	return b.Path, true
+}
+
+func RenderPathFromConfig(selection read.BundleSelection) (string, bool) {
 	var path string
 	var config read.ReaderConfig
 
@@ -88,7 +168,7 @@ func RenderPathFromConfig() (string, bool) {
 	if path = os.Getenv(read.DebugReadEnvVar); path != "" {
 		var extractedPath string
 		hclog.L().Debug("configuring path from rendered CONSUL_DEBUG_PATH setting", read.DebugReadEnvVar, path)
-		if extractedPath, err = read.SelectAndExtractTarGzFilesInDir(path); err != nil {
+		if extractedPath, err = read.SelectAndExtractTarGzFilesInDir(path, selection); err != nil {
 			hclog.L().Error("failed to extract bundle from path", "path", path, "err", err)
 			return "", false
 		}
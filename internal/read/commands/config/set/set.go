@@ -72,6 +72,12 @@ func (c *cmd) Run(args []string) int {
 	var err error
 	var ok, usePath, useFile, useEnvVar bool
 
+	selection, err := read.ParseBundleSelection(c.pathFlags.Select.String())
+	if err != nil {
+		c.ui.Error(err.Error())
+		return 1
+	}
+
 	hclog.L().Debug("checking CONSUL_DEBUG_PATH env var (if set)", "env", read.DebugReadEnvVar)
 
 	if path, err = read.ExtractEnvironmentPath(); err != nil {
@@ -94,7 +100,7 @@ func (c *cmd) Run(args []string) int {
 
 	if useEnvVar {
 		hclog.L().Debug("attempting to set with CONSUL_DEBUG_PATH env variable", "path", path)
-		extractedPath, err = read.SelectAndExtractTarGzFilesInDir(path)
+		extractedPath, err = read.SelectAndExtractTarGzFilesInDir(path, selection)
 		if err != nil {
 			hclog.L().Error("failed to extract bundle from path", "path", path, "err", err)
 			c.ui.Error("failed to set consul-debug-read path")
@@ -116,7 +122,7 @@ func (c *cmd) Run(args []string) int {
 		c.ui.Output(fmt.Sprintf("\nconsul-debug-path set successfully using CONSUL_DEBUG_PATH env var => %s\n", extractedPath))
 	} else if usePath {
 		hclog.L().Debug("attempting to set with -path filepath", "path", c.path)
-		extractedPath, err = read.SelectAndExtractTarGzFilesInDir(c.path)
+		extractedPath, err = read.SelectAndExtractTarGzFilesInDir(c.path, selection)
 		if err != nil {
 			hclog.L().Error("failed to extract bundle from path", "path", c.path, "err", err)
 			c.ui.Error("failed to set consul-debug-read path")
@@ -137,7 +143,7 @@ func (c *cmd) Run(args []string) int {
 	} else if useFile {
 		hclog.L().Debug("attempting to set with -file filepath", "file", c.file)
 		if ok = strings.HasSuffix(c.file, ".tar.gz"); ok {
-			extractedPath, err = read.SelectAndExtractTarGzFilesInDir(c.file)
+			extractedPath, err = read.SelectAndExtractTarGzFilesInDir(c.file, selection)
 			if err != nil {
 				hclog.L().Error("failed to extract bundle from file", "file", c.file, "err", err)
 				c.ui.Error("failed to set consul-debug-read path using -file")
@@ -156,10 +162,44 @@ func (c *cmd) Run(args []string) int {
 		}
 		c.ui.Output(fmt.Sprintf("\nconsul-debug-path set successfully => %s\n", extractedPath))
 	}
+
+	// Remember the bundle so it can be switched back to with 'bundle use' without re-extracting
+	archive := ""
+	if useFile {
+		archive = c.file
+	}
+	registerBundle(extractedPath, archive)
 	return 0
 }
 
+// registerBundle adds the bundle set as the debug path to the bundle registry as its default.
+// Registration is best effort and never fails the path update.
+func registerBundle(extractedPath, archive string) {
+	registry, err := read.LoadBundleRegistry()
+	if err != nil {
+		hclog.L().Warn("failed to load bundle registry, bundle not registered", "error", err)
+		return
+	}
+	b, err := registry.Register(extractedPath, archive, "", "")
+	if err != nil {
+		hclog.L().Warn("failed to register bundle", "path", extractedPath, "error", err)
+		return
+	}
+	registry.Default = b.Name
+	if err = registry.Save(); err != nil {
+		hclog.L().Warn("failed to save bundle registry", "path", read.BundleRegistryPath, "error", err)
+		return
+	}
+	hclog.L().Debug("registered bundle", "bundle", registry.Default, "path", extractedPath)
+}
+
 func UpdateCurrentPath(updatePath string) (bool, error) {
+	return UpdateCurrentPathFrom(updatePath, "cli:-path|-file")
+}
+
+// UpdateCurrentPathFrom sets the configured debug path, recording renderedFrom as its source unless
+// the path is being set from the CONSUL_DEBUG_PATH environment variable.
+func UpdateCurrentPathFrom(updatePath, renderedFrom string) (bool, error) {
 	var config read.ReaderConfig
 
 	// Retrieve configuration file location and open file
@@ -183,7 +223,7 @@ func UpdateCurrentPath(updatePath string) (bool, error) {
 	} else {
 		config.DebugDirectoryPath = updatePath
 		config.DebugEnvVarSetting = "<UNSET>"
-		config.PathRenderedFrom = "cli:-path|-file"
+		config.PathRenderedFrom = renderedFrom
 	}
 
 	// Marshal the struct to bytes and write to file
@@ -231,6 +271,10 @@ Example (-path) for dir containing multiple .tar.gz bundles:
 	7: 124722consul-debug-us-east-stag.tar.gz
 	enter the number of the file to extract: 
 
+Example (-path) picking a bundle without prompting, e.g. from scripts or CI:
+	$ consul-debug-read config set-path -path bundles/ -select latest
+	$ consul-debug-read config set-path -path bundles/ -select name='*eu-01*'
+
 Example (-file) for extraction:
 	$ consul-debug-read config set-path -file bundles/124722consul-debug-2023-10-11T17-43-15Z.tar.gz
 `
@@ -0,0 +1,193 @@
+package diff
+
+import (
+	"consul-debug-read/internal/read"
+	"consul-debug-read/internal/read/commands"
+	"consul-debug-read/internal/read/commands/flags"
+	"consul-debug-read/internal/read/commands/metrics"
+	"consul-debug-read/internal/read/compare"
+	"consul-debug-read/internal/read/loader"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/hashicorp/go-hclog"
+	"github.com/mitchellh/cli"
+	"strings"
+)
+
+type cmd struct {
+	ui          cli.Ui
+	flags       *flag.FlagSet
+	redactFlags *flags.RedactFlags
+
+	format          string
+	regressionsOnly bool
+	sections        string
+
+	verbose bool
+	silent  bool
+}
+
+func New(ui cli.Ui) (cli.Command, error) {
+	c := &cmd{
+		ui:          ui,
+		redactFlags: &flags.RedactFlags{},
+		flags:       flag.NewFlagSet("", flag.ContinueOnError),
+	}
+	c.flags.StringVar(&c.format, "format", "table", "Output format of the differences: table or json")
+	c.flags.BoolVar(&c.regressionsOnly, "regressions-only", false, "Only report differences flagged as regressions")
+	c.flags.StringVar(&c.sections, "sections", "", "Comma separated sections to compare: version, config, raft, members, host, metrics (defaults to all)")
+
+	c.flags.BoolVar(&c.silent, "silent", false, "Disables all normal log output")
+	c.flags.BoolVar(&c.verbose, "verbose", false, "Enable verbose debugging output")
+
+	flags.FlagMerge(c.flags, c.redactFlags.Flags())
+
+	return c, nil
+}
+
+func (c *cmd) Help() string { return commands.Usage(help, c.flags) }
+
+func (c *cmd) Synopsis() string { return synopsis }
+
+func (c *cmd) Run(args []string) int {
+	if err := c.flags.Parse(args); err != nil {
+		c.ui.Error(fmt.Sprintf("Failed to parse flags: %v", err))
+		return 1
+	}
+	if c.verbose && c.silent {
+		c.ui.Error(fmt.Sprintf("Cannot specify both -silent and -verbose"))
+		return 1
+	}
+	if c.format != "table" && c.format != "json" {
+		c.ui.Error(fmt.Sprintf("Invalid -format %q: must be one of table or json", c.format))
+		return 1
+	}
+	for _, s := range strings.Split(c.sections, ",") {
+		if s = strings.TrimSpace(s); s != "" && !validSection(s) {
+			c.ui.Error(fmt.Sprintf("Invalid -sections value %q: must be one of %s", s, strings.Join(compare.Sections, ", ")))
+			return 1
+		}
+	}
+	if c.flags.NArg() != 2 {
+		c.ui.Error("Expected a baseline and an incident bundle path\n\n" + c.Help())
+		return 1
+	}
+
+	level := hclog.Info
+	if c.verbose {
+		level = hclog.Debug
+	} else if c.silent {
+		level = hclog.Off
+	}
+
+	commands.InitLogging(c.ui, level)
+
+	baselinePath, err := read.ResolveBundleArg(c.flags.Arg(0))
+	if err != nil {
+		hclog.L().Error("failed to resolve baseline bundle", "error", err)
+		return 1
+	}
+	incidentPath, err := read.ResolveBundleArg(c.flags.Arg(1))
+	if err != nil {
+		hclog.L().Error("failed to resolve incident bundle", "error", err)
+		return 1
+	}
+	hclog.L().Debug("loading baseline bundle", "path", baselinePath)
+	baseline, err := loader.Load(baselinePath, commands.BundleOptions(false, c.redactFlags.Redactor())...)
+	if err != nil {
+		hclog.L().Error("failed to load baseline bundle", "path", baselinePath, "error", err)
+		return 1
+	}
+	hclog.L().Debug("loading incident bundle", "path", incidentPath)
+	incident, err := loader.Load(incidentPath, commands.BundleOptions(false, c.redactFlags.Redactor())...)
+	if err != nil {
+		hclog.L().Error("failed to load incident bundle", "path", incidentPath, "error", err)
+		return 1
+	}
+
+	diffs, err := compare.Bundles(baseline, incident, metrics.KeyMetricGroups())
+	if err != nil {
+		hclog.L().Error("failed to compare bundles", "error", err)
+		return 1
+	}
+	diffs = c.filter(diffs)
+
+	if c.format == "json" {
+		if diffs == nil {
+			diffs = []compare.Difference{}
+		}
+		out, err := json.MarshalIndent(diffs, "", "  ")
+		if err != nil {
+			hclog.L().Error("failed to encode differences", "error", err)
+			return 1
+		}
+		c.ui.Output(string(out))
+		return 0
+	}
+
+	regressions := 0
+	for _, d := range diffs {
+		if d.Regression {
+			regressions++
+		}
+	}
+	c.ui.Output(fmt.Sprintf("==> baseline: %s\n==> incident: %s\n", baselinePath, incidentPath))
+	c.ui.Output(compare.Summary(diffs, "Baseline", "Incident"))
+	if regressions > 0 {
+		c.ui.Output(fmt.Sprintf("\n%d regression(s) flagged with '!'", regressions))
+	}
+	return 0
+}
+
+func validSection(section string) bool {
+	for _, s := range compare.Sections {
+		if s == section {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *cmd) filter(diffs []compare.Difference) []compare.Difference {
+	sections := make(map[string]bool)
+	for _, s := range strings.Split(c.sections, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			sections[s] = true
+		}
+	}
+	var filtered []compare.Difference
+	for _, d := range diffs {
+		if len(sections) > 0 && !sections[d.Section] {
+			continue
+		}
+		if c.regressionsOnly && !d.Regression {
+			continue
+		}
+		filtered = append(filtered, d)
+	}
+	return filtered
+}
+
+const synopsis = `Compares a baseline bundle with an incident bundle`
+const help = `
+Usage:
+    consul-debug-read diff [options] <baseline-bundle> <incident-bundle>
+
+Loads two bundles (extracted directories, .tar.gz archives or registered bundle names) and reports what differs between them:
+
+    version   agent and Go runtime versions
+    config    every differing DebugConfig setting
+    raft      leader, raft state and raft peer set changes
+    members   serf member status and build changes
+    host      CPU, memory and disk resources
+    metrics   mean and max of the key metrics, with percentage change
+
+Differences likely to explain an incident (a lost leader, a removed raft peer, members no longer
+alive, key metrics at least 20% worse, memory or disk usage up 10 or more points) are flagged
+as regressions with '!'.
+
+Example:
+    $ consul-debug-read diff consul-debug-baseline-2023-10-04T18-29-47Z consul-debug-2023-10-04T19-02-11Z
+    $ consul-debug-read diff -regressions-only -sections=raft,metrics baseline.tar.gz incident.tar.gz
+`
@@ -1,18 +1,69 @@
 package flags
 
 import (
+	"consul-debug-read/internal/read"
+	"consul-debug-read/pkg/redact"
 	"flag"
+	"fmt"
+	"regexp"
+	"strings"
 )
 
 type DebugReadFlags struct {
+	// DebugDirPath is the bundle directory, or directory of bundle archives, passed with -path.
+	DebugDirPath stringValue
+	// DebugFilePath is the bundle .tar.gz or directory passed with -file.
 	DebugFilePath stringValue
+	// Bundle names a registered bundle to read instead of the configured debug path.
+	Bundle stringValue
+	// Select picks the archive to read from a directory of bundle archives, see read.ParseBundleSelection.
+	Select stringValue
+	// NoCache skips the analysis cache, reading metrics.json and consul.log from the bundle.
+	NoCache bool
+	RedactFlags
 }
 
 func (f *DebugReadFlags) Flags() *flag.FlagSet {
 	fs := flag.NewFlagSet("", flag.ContinueOnError)
+	fs.Var(&f.DebugDirPath, "path", "Bundle directory, or directory of bundle archives, to read for this invocation only instead of the configured debug path")
+	fs.Var(&f.DebugFilePath, "file", "Bundle .tar.gz archive or directory to read for this invocation only instead of the configured debug path")
+	fs.Var(&f.Bundle, "bundle", "Name of a registered bundle to read for this invocation only, see 'consul-debug-read bundle list'")
+	fs.Var(&f.Select, "select", read.SelectUsage)
+	fs.BoolVar(&f.NoCache, "no-cache", false, "Read metrics.json and consul.log from the bundle instead of the analysis cache")
+	FlagMerge(fs, f.RedactFlags.Flags())
 	return fs
 }
 
+// RedactFlags select the secrets redacted from output, for commands reading bundles given as
+// arguments rather than through DebugReadFlags.
+type RedactFlags struct {
+	// NoRedact shows secrets as captured instead of redacting them.
+	NoRedact bool
+	// RedactPatterns are regular expressions of further secrets to redact, passed with -redact-pattern.
+	RedactPatterns patternsValue
+}
+
+func (f *RedactFlags) Flags() *flag.FlagSet {
+	fs := flag.NewFlagSet("", flag.ContinueOnError)
+	fs.BoolVar(&f.NoRedact, "no-redact", false, "Show tokens, keys and other secrets as captured instead of redacting them")
+	fs.Var(&f.RedactPatterns, "redact-pattern", "Regular expression of further secrets to redact, may be repeated. With a (?P<secret>...) group only the group is redacted")
+	return fs
+}
+
+// Redactor returns the redactor of the secrets to hide from output, nil with -no-redact.
+func (f *RedactFlags) Redactor() *redact.Redactor {
+	if f.NoRedact {
+		return nil
+	}
+	patterns := redact.DefaultPatterns()
+	for i, re := range f.RedactPatterns {
+		patterns = append(patterns, redact.Pattern{Name: fmt.Sprintf("redact-pattern-%d", i+1), Value: re})
+	}
+	return redact.New(patterns...)
+}
+
+// FlagMerge adds the flags of src to dst, skipping flags dst already defines so commands can
+// give a shared flag their own meaning.
 func FlagMerge(dst, src *flag.FlagSet) {
 	if dst == nil {
 		panic("dst cannot be nil")
@@ -21,10 +72,35 @@ func FlagMerge(dst, src *flag.FlagSet) {
 		return
 	}
 	src.VisitAll(func(f *flag.Flag) {
+		if dst.Lookup(f.Name) != nil {
+			return
+		}
 		dst.Var(f.Value, f.Name, f.Usage)
 	})
 }
 
+// patternsValue is a repeatable flag of regular expressions, compiled as they are set.
+type patternsValue []*regexp.Regexp
+
+// Set implements the flag.Value interface.
+func (p *patternsValue) Set(v string) error {
+	re, err := regexp.Compile(v)
+	if err != nil {
+		return err
+	}
+	*p = append(*p, re)
+	return nil
+}
+
+// String implements the flag.Value interface.
+func (p *patternsValue) String() string {
+	var patterns []string
+	for _, re := range *p {
+		patterns = append(patterns, re.String())
+	}
+	return strings.Join(patterns, ",")
+}
+
 // stringValue provides a flag value that's aware if it has been set.
 type stringValue struct {
 	v *string
@@ -4,12 +4,12 @@ import (
 	"consul-debug-read/internal/read/commands"
 	"consul-debug-read/internal/read/commands/config/get"
 	"consul-debug-read/internal/read/commands/flags"
+	"consul-debug-read/internal/read/loader"
 	"consul-debug-read/internal/read/log"
 	"flag"
 	"fmt"
 	"github.com/hashicorp/go-hclog"
 	"github.com/mitchellh/cli"
-	"time"
 )
 
 type cmd struct {
@@ -71,18 +71,22 @@ func (c *cmd) Run(args []string) int {
 	var ok bool
 	var err error
 	var path string
-	if path, ok = get.RenderPathFromConfig(); !ok {
+	if path, ok = get.RenderPath(c.pathFlags); !ok {
 		hclog.L().Error("error rendering debug filepath", "filepath", path, "error", err)
 		return 1
 	}
 
-	logFile := path + "/consul.log"
+	b, ok := commands.OpenBundle(c.pathFlags, path)
+	if !ok {
+		return 1
+	}
+	logFile := b.LogFile()
 	var out string
 
 	switch {
 	case c.source != "" && !c.sourceCount:
 		hclog.L().Debug("parsing info bundle log file [INFO] messages", "log-file", logFile)
-		entries, err = log.ParseLog(logFile, log.InfoLevel, c.source, time.Time{}, time.Time{})
+		entries, err = b.LogEntries(loader.LogFilter{Levels: []string{loader.LevelInfo}, Source: c.source})
 		if err != nil {
 			hclog.L().Error("error parsing log file", "file", logFile, "error", err)
 			return 1
@@ -90,7 +94,7 @@ func (c *cmd) Run(args []string) int {
 		out = log.FormatLog(entries)
 	case c.sourceCount:
 		hclog.L().Debug("parsing info bundle log file [INFO] messages", "log-file", logFile)
-		entries, err = log.ParseLog(logFile, log.InfoLevel, c.source, time.Time{}, time.Time{})
+		entries, err = b.LogEntries(loader.LogFilter{Levels: []string{loader.LevelInfo}, Source: c.source})
 		if err != nil {
 			hclog.L().Error("error parsing log file", "file", logFile, "error", err)
 			return 1
@@ -100,7 +104,7 @@ func (c *cmd) Run(args []string) int {
 		out = log.FormatCounts(counts, "source")
 	case c.messageCount:
 		hclog.L().Debug("parsing info bundle log file [INFO] messages", "log-file", logFile)
-		entries, err = log.ParseLog(logFile, log.InfoLevel, c.source, time.Time{}, time.Time{})
+		entries, err = b.LogEntries(loader.LogFilter{Levels: []string{loader.LevelInfo}, Source: c.source})
 		if err != nil {
 			hclog.L().Error("error parsing log file", "file", logFile, "error", err)
 			return 1
@@ -110,7 +114,7 @@ func (c *cmd) Run(args []string) int {
 		out = log.FormatCounts(counts, "message")
 	default:
 		hclog.L().Debug("parsing info bundle log file [INFO] messages", "log-file", logFile)
-		entries, err = log.ParseLog(logFile, log.InfoLevel, "", time.Time{}, time.Time{})
+		entries, err = b.LogEntries(loader.LogFilter{Levels: []string{loader.LevelInfo}})
 		if err != nil {
 			hclog.L().Error("error parsing log file", "file", logFile, "error", err)
 			return 1
@@ -64,17 +64,21 @@ func (c *cmd) Run(args []string) int {
 	var ok bool
 	var err error
 	var path string
-	if path, ok = get.RenderPathFromConfig(); !ok {
+	if path, ok = get.RenderPath(c.pathFlags); !ok {
 		hclog.L().Error("error rendering debug filepath", "filepath", path, "error", err)
 		return 1
 	}
 
-	logFile := path + "/consul.log"
+	b, ok := commands.OpenBundle(c.pathFlags, path)
+	if !ok {
+		return 1
+	}
+	logFile := b.LogFile()
 	var out string
 
 	switch {
 	case c.method != "":
-		entries, err = log.ParseRPCMethods(logFile, c.method)
+		entries, err = b.RPCCalls(c.method)
 		if err != nil {
 			hclog.L().Error("error parsing log file", "file", logFile, "error", err)
 			return 1
@@ -82,7 +86,7 @@ func (c *cmd) Run(args []string) int {
 		counts := log.AggregateRPCEntries(entries)
 		out = log.RPCCounts(counts)
 	default:
-		entries, err = log.ParseRPCMethods(logFile, "")
+		entries, err = b.RPCCalls("")
 		if err != nil {
 			hclog.L().Error("error parsing log file", "file", logFile, "error", err)
 			return 1
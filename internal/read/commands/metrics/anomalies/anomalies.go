@@ -0,0 +1,160 @@
+package anomalies
+
+import (
+	"consul-debug-read/internal/read"
+	"consul-debug-read/internal/read/commands"
+	"consul-debug-read/internal/read/commands/config/get"
+	"consul-debug-read/internal/read/commands/flags"
+	"consul-debug-read/internal/read/loader"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/hashicorp/go-hclog"
+	"github.com/mitchellh/cli"
+	"github.com/ryanuber/columnize"
+	"strconv"
+)
+
+type cmd struct {
+	ui        cli.Ui
+	flags     *flag.FlagSet
+	pathFlags *flags.DebugReadFlags
+
+	name   string
+	top    int
+	format string
+
+	verbose bool
+	silent  bool
+}
+
+func New(ui cli.Ui) (cli.Command, error) {
+	c := &cmd{
+		ui:        ui,
+		pathFlags: &flags.DebugReadFlags{},
+		flags:     flag.NewFlagSet("", flag.ContinueOnError),
+	}
+	c.flags.StringVar(&c.name, "name", "", "Only scan metrics matching this name or selector, e.g. 'consul.runtime.*' (defaults to every metric)")
+	c.flags.IntVar(&c.top, "top", 10, "Number of most severe anomalies to print (0 prints all)")
+	c.flags.StringVar(&c.format, "format", "table", "Output format of detected anomalies: table or json")
+
+	c.flags.BoolVar(&c.silent, "silent", false, "Disables all normal log output")
+	c.flags.BoolVar(&c.verbose, "verbose", false, "Enable verbose debugging output")
+
+	flags.FlagMerge(c.flags, c.pathFlags.Flags())
+
+	return c, nil
+}
+
+func (c *cmd) Help() string { return commands.Usage(help, c.flags) }
+
+func (c *cmd) Synopsis() string { return synopsis }
+
+func (c *cmd) Run(args []string) int {
+	if err := c.flags.Parse(args); err != nil {
+		c.ui.Error(fmt.Sprintf("Failed to parse flags: %v", err))
+		return 1
+	}
+	if c.verbose && c.silent {
+		c.ui.Error(fmt.Sprintf("Cannot specify both -silent and -verbose"))
+		return 1
+	}
+	if c.format != "table" && c.format != "json" {
+		c.ui.Error(fmt.Sprintf("Invalid -format %q: must be one of table or json", c.format))
+		return 1
+	}
+
+	level := hclog.Info
+	if c.verbose {
+		level = hclog.Debug
+	} else if c.silent {
+		level = hclog.Off
+	}
+
+	commands.InitLogging(c.ui, level)
+
+	var ok bool
+	var err error
+	var path string
+	if path, ok = get.RenderPath(c.pathFlags); !ok {
+		hclog.L().Error("error rendering debug filepath", "filepath", path, "error", err)
+		return 1
+	}
+
+	var selector *read.MetricSelector
+	if c.name != "" {
+		if selector, err = read.ParseMetricSelector(c.name); err != nil {
+			hclog.L().Error("invalid metric selector", "name", c.name, "error", err)
+			return 1
+		}
+	}
+
+	b, ok := commands.OpenBundle(c.pathFlags, path, loader.PartIndex, loader.PartMetrics)
+	if !ok {
+		return 1
+	}
+	data := b.Debug()
+
+	anomalies, err := data.Metrics.DetectAnomalies(selector, c.top)
+	if err != nil {
+		hclog.L().Error("failed to detect metric anomalies", "error", err)
+		return 1
+	}
+
+	if c.format == "json" {
+		if anomalies == nil {
+			anomalies = []read.Anomaly{}
+		}
+		out, err := json.MarshalIndent(anomalies, "", "  ")
+		if err != nil {
+			hclog.L().Error("failed to encode anomalies", "error", err)
+			return 1
+		}
+		c.ui.Output(string(out))
+		return 0
+	}
+	if len(anomalies) == 0 {
+		c.ui.Output("no anomalies detected across the capture window")
+		return 0
+	}
+
+	result := []string{"Severity\x1fKind\x1fMetric\x1fLabels\x1fWhen\x1fBefore\x1fAfter\x1f"}
+	for _, a := range anomalies {
+		labels := read.FormatLabels(a.Labels)
+		if labels == "" {
+			labels = "-"
+		}
+		when := a.Start.Format(read.MetricsTimestampLayout)
+		if !a.End.Equal(a.Start) {
+			when += " => " + a.End.Format(read.MetricsTimestampLayout)
+		}
+		result = append(result, fmt.Sprintf("%.0f%%\x1f%s\x1f%s\x1f%s\x1f%s\x1f%s\x1f%s\x1f",
+			a.Severity*100, a.Kind, a.Name, labels, when, formatValue(a.Before), formatValue(a.After)))
+	}
+	c.ui.Output(columnize.Format(result, &columnize.Config{Delim: string([]byte{0x1f}), Glue: " "}))
+	return 0
+}
+
+func formatValue(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+const synopsis = `Ranks the metrics that changed most during the capture window`
+const help = `
+Usage:
+    consul-debug-read metrics anomalies [options]
+
+Scans every captured metric series for what changed during the capture and prints the top-N series
+ranked by severity (the size of the change relative to the series baseline):
+
+    spike         a single capture far outside the series' usual range
+    level-shift   the series settles at a new level part-way through the capture
+    growth/drop   the series moves steadily in one direction across the capture
+                  (e.g. a growing consul.runtime.heap_objects)
+
+The When column is the capture at which the change occurred, or the span of a growth/drop trend.
+
+Example:
+    $ consul-debug-read metrics anomalies -top=5
+    $ consul-debug-read metrics anomalies -name='consul.raft.*' -format=json
+`
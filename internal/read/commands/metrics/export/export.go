@@ -0,0 +1,146 @@
+package export
+
+import (
+	"consul-debug-read/internal/read"
+	"consul-debug-read/internal/read/commands"
+	"consul-debug-read/internal/read/commands/config/get"
+	"consul-debug-read/internal/read/commands/flags"
+	"consul-debug-read/internal/read/loader"
+	"flag"
+	"fmt"
+	"github.com/hashicorp/go-hclog"
+	"github.com/mitchellh/cli"
+	"io"
+	"os"
+)
+
+type cmd struct {
+	ui        cli.Ui
+	flags     *flag.FlagSet
+	pathFlags *flags.DebugReadFlags
+
+	format string
+	output string
+
+	verbose bool
+	silent  bool
+}
+
+func New(ui cli.Ui) (cli.Command, error) {
+	c := &cmd{
+		ui:        ui,
+		pathFlags: &flags.DebugReadFlags{},
+		flags:     flag.NewFlagSet("", flag.ContinueOnError),
+	}
+	c.flags.StringVar(&c.format, "format", "openmetrics", "Export format of bundle metrics: openmetrics or influx-line")
+	c.flags.StringVar(&c.output, "output", "-", "File to write exported metrics to ('-' writes to stdout)")
+
+	c.flags.BoolVar(&c.silent, "silent", false, "Disables all normal log output")
+	c.flags.BoolVar(&c.verbose, "verbose", false, "Enable verbose debugging output")
+
+	flags.FlagMerge(c.flags, c.pathFlags.Flags())
+
+	return c, nil
+}
+
+func (c *cmd) Help() string { return commands.Usage(help, c.flags) }
+
+func (c *cmd) Synopsis() string { return synopsis }
+
+func (c *cmd) Run(args []string) int {
+	if err := c.flags.Parse(args); err != nil {
+		c.ui.Error(fmt.Sprintf("Failed to parse flags: %v", err))
+		return 1
+	}
+	if c.verbose && c.silent {
+		c.ui.Error(fmt.Sprintf("Cannot specify both -silent and -verbose"))
+		return 1
+	}
+	var write func(b *read.Debug, w io.Writer) error
+	switch c.format {
+	case "openmetrics":
+		write = (*read.Debug).WriteOpenMetrics
+	case "influx-line":
+		write = (*read.Debug).WriteInfluxLine
+	default:
+		c.ui.Error(fmt.Sprintf("Invalid -format %q: must be one of openmetrics or influx-line", c.format))
+		return 1
+	}
+
+	level := hclog.Info
+	if c.verbose {
+		level = hclog.Debug
+	} else if c.silent {
+		level = hclog.Off
+	}
+
+	commands.InitLogging(c.ui, level)
+
+	var ok bool
+	var err error
+	var path string
+	if path, ok = get.RenderPath(c.pathFlags); !ok {
+		hclog.L().Error("error rendering debug filepath", "filepath", path, "error", err)
+		return 1
+	}
+
+	parts := []string{loader.PartIndex, loader.PartMetrics}
+	if c.format == "influx-line" {
+		parts = append(parts, loader.PartAgent)
+	}
+	b, ok := commands.OpenBundle(c.pathFlags, path, parts...)
+	if !ok {
+		return 1
+	}
+	data := b.Debug()
+
+	var w io.Writer = os.Stdout
+	var f *os.File
+	if c.output != "-" && c.output != "" {
+		if f, err = os.Create(c.output); err != nil {
+			hclog.L().Error("failed to create export file", "output", c.output, "error", err)
+			return 1
+		}
+		w = f
+	}
+	if err = write(data, w); err != nil {
+		if f != nil {
+			_ = f.Close()
+		}
+		hclog.L().Error("failed to export metrics", "format", c.format, "error", err)
+		return 1
+	}
+	if f != nil {
+		// A failed close can leave the export incomplete.
+		if err = f.Close(); err != nil {
+			hclog.L().Error("failed to close export file", "output", c.output, "error", err)
+			return 1
+		}
+		hclog.L().Info("metrics exported successfully", "format", c.format, "output", c.output)
+	}
+	return 0
+}
+
+const synopsis = `Export bundle metrics for ingestion into external time series databases`
+const help = `
+Usage:
+    consul-debug-read metrics export [options]
+
+Exports every metrics.json capture of the bundle with its original timestamp.
+
+Formats:
+    openmetrics    OpenMetrics text exposition. Names and labels are sanitized to their Prometheus
+                   form, gauges/points are exported as gauges, counters as cumulative <name>_total
+                   counters and samples as <name>_count/<name>_sum summaries. Names colliding
+                   once sanitized, such as a-b and a.b, are exported as a_b and a_b_2.
+    influx-line    InfluxDB line protocol with nanosecond timestamps. The measurement is the metric
+                   name, tags are the metric labels plus the agent datacenter and node, and
+                   counters/samples carry a field per aggregate (count, rate, sum, min, max, mean, stddev).
+
+Examples:
+    $ consul-debug-read metrics export -format=openmetrics -output=metrics.om
+    $ promtool tsdb create-blocks-from openmetrics metrics.om ./data
+
+    $ consul-debug-read metrics export -format=influx-line -output=metrics.lp
+    $ influx write --bucket consul-debug-metrics --precision ns --file metrics.lp
+`
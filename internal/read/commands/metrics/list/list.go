@@ -0,0 +1,142 @@
+package list
+
+import (
+	"consul-debug-read/internal/read"
+	"consul-debug-read/internal/read/commands"
+	"consul-debug-read/internal/read/commands/config/get"
+	"consul-debug-read/internal/read/commands/flags"
+	"consul-debug-read/internal/read/loader"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/hashicorp/go-hclog"
+	"github.com/mitchellh/cli"
+	"github.com/ryanuber/columnize"
+	"strings"
+)
+
+type cmd struct {
+	ui        cli.Ui
+	flags     *flag.FlagSet
+	pathFlags *flags.DebugReadFlags
+
+	format       string
+	undocumented bool
+
+	verbose bool
+	silent  bool
+}
+
+func New(ui cli.Ui) (cli.Command, error) {
+	c := &cmd{
+		ui:        ui,
+		pathFlags: &flags.DebugReadFlags{},
+		flags:     flag.NewFlagSet("", flag.ContinueOnError),
+	}
+	c.flags.StringVar(&c.format, "format", "table", "Output format of the metric list: table or json")
+	c.flags.BoolVar(&c.undocumented, "undocumented", false, "Only list captured metrics missing from the telemetry catalog for the bundle's consul version")
+
+	c.flags.BoolVar(&c.silent, "silent", false, "Disables all normal log output")
+	c.flags.BoolVar(&c.verbose, "verbose", false, "Enable verbose debugging output")
+
+	flags.FlagMerge(c.flags, c.pathFlags.Flags())
+
+	return c, nil
+}
+
+func (c *cmd) Help() string { return commands.Usage(help, c.flags) }
+
+func (c *cmd) Synopsis() string { return synopsis }
+
+func (c *cmd) Run(args []string) int {
+	if err := c.flags.Parse(args); err != nil {
+		c.ui.Error(fmt.Sprintf("Failed to parse flags: %v", err))
+		return 1
+	}
+	if c.verbose && c.silent {
+		c.ui.Error(fmt.Sprintf("Cannot specify both -silent and -verbose"))
+		return 1
+	}
+	if c.format != "table" && c.format != "json" {
+		c.ui.Error(fmt.Sprintf("Invalid -format %q: must be one of table or json", c.format))
+		return 1
+	}
+
+	level := hclog.Info
+	if c.verbose {
+		level = hclog.Debug
+	} else if c.silent {
+		level = hclog.Off
+	}
+
+	commands.InitLogging(c.ui, level)
+
+	var ok bool
+	var err error
+	var path string
+	if path, ok = get.RenderPath(c.pathFlags); !ok {
+		hclog.L().Error("error rendering debug filepath", "filepath", path, "error", err)
+		return 1
+	}
+
+	b, ok := commands.OpenBundle(c.pathFlags, path, loader.PartIndex, loader.PartMetrics)
+	if !ok {
+		return 1
+	}
+	data := b.Debug()
+
+	_, telemetryInfo, err := read.GetTelemetryMetrics(data.Index.AgentVersion)
+	if err != nil {
+		hclog.L().Error("failed to load telemetry catalog", "error", err)
+		return 1
+	}
+
+	captured := data.Metrics.CapturedMetrics(telemetryInfo)
+	if c.undocumented {
+		var filtered []read.CapturedMetric
+		for _, m := range captured {
+			if !m.Documented {
+				filtered = append(filtered, m)
+			}
+		}
+		captured = filtered
+	}
+
+	if c.format == "json" {
+		if captured == nil {
+			captured = []read.CapturedMetric{}
+		}
+		out, err := json.MarshalIndent(captured, "", "  ")
+		if err != nil {
+			hclog.L().Error("failed to encode metric list", "error", err)
+			return 1
+		}
+		c.ui.Output(string(out))
+		return 0
+	}
+
+	result := []string{"Metric\x1fType\x1fLabels\x1fSamples\x1fDocumented\x1f"}
+	for _, m := range captured {
+		labels := "-"
+		if len(m.LabelKeys) > 0 {
+			labels = strings.Join(m.LabelKeys, ",")
+		}
+		result = append(result, fmt.Sprintf("%s\x1f%s\x1f%s\x1f%d\x1f%t\x1f", m.Name, m.Type, labels, m.Samples, m.Documented))
+	}
+	c.ui.Output(columnize.Format(result, &columnize.Config{Delim: string([]byte{0x1f}), Glue: " "}))
+	return 0
+}
+
+const synopsis = `Lists every metric name captured in the bundle`
+const help = `
+Usage:
+    consul-debug-read metrics list [options]
+
+Enumerates every metric name captured in the bundle's metrics.json with its type, label keys and
+number of captured samples, and whether it is documented in the telemetry catalog for the bundle's
+consul version.
+
+Example:
+    $ consul-debug-read metrics list
+    $ consul-debug-read metrics list -undocumented -format=json
+`
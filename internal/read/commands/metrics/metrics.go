@@ -5,6 +5,7 @@ import (
 	"consul-debug-read/internal/read/commands"
 	"consul-debug-read/internal/read/commands/config/get"
 	"consul-debug-read/internal/read/commands/flags"
+	"consul-debug-read/internal/read/loader"
 	"flag"
 	"fmt"
 	"github.com/hashicorp/go-hclog"
@@ -150,12 +151,22 @@ var (
 	}
 )
 
+// KeyMetricGroups returns a copy of the key metric names grouped by the health area they cover.
+func KeyMetricGroups() map[string][]string {
+	groups := make(map[string][]string, len(keyMetricNames))
+	for title, names := range keyMetricNames {
+		groups[title] = append([]string(nil), names...)
+	}
+	return groups
+}
+
 type cmd struct {
 	ui        cli.Ui
 	flags     *flag.FlagSet
 	pathFlags *flags.DebugReadFlags
 
-	name string
+	name    string
+	groupBy string
 
 	listAvailableTelemetry bool
 
@@ -194,9 +205,10 @@ func New(ui cli.Ui) (cli.Command, error) {
 		pathFlags: &flags.DebugReadFlags{},
 		flags:     flag.NewFlagSet("", flag.ContinueOnError),
 	}
-	c.flags.StringVar(&c.name, "name", "", "Retrieve specific metric timestamped values by name")
+	c.flags.StringVar(&c.name, "name", "", "Retrieve specific metric timestamped values by name. Supports label matchers, e.g. 'consul.rpc.request{type=write,leader=~\"true|false\"}'")
+	c.flags.StringVar(&c.groupBy, "group-by", "", "Comma separated label key(s) to aggregate -name values by (e.g., method, path, datacenter)")
 
-	c.flags.BoolVar(&c.listAvailableTelemetry, "list-available-telemetry", false, "List available metric names as retrieved from consul telemetry docs")
+	c.flags.BoolVar(&c.listAvailableTelemetry, "list-available-telemetry", false, "List the telemetry metric names documented for the bundle's consul version")
 
 	c.flags.BoolVar(&c.keyMetrics, "key-metrics", false, "Retrieve key metric values for Consul from debug bundle")
 	c.flags.BoolVar(&c.host, "host", false, "Retrieve Host specific metrics")
@@ -258,68 +270,58 @@ func (c *cmd) Run(args []string) int {
 	var ok bool
 	var err error
 	var path string
-	if path, ok = get.RenderPathFromConfig(); !ok {
+	if path, ok = get.RenderPath(c.pathFlags); !ok {
 		hclog.L().Error("error rendering debug filepath", "filepath", path, "error", err)
 		return 1
 	}
 
-	var data read.Debug
-	var result string
-
+	var parts []string
 	if c.name != "" {
-		hclog.L().Debug("reading in index.json", "filepath", path)
-		if err = data.DecodeJSON(path, "index"); err != nil {
-			hclog.L().Error("failed to decode index.json", "error", err)
-			return 1
-		}
-		hclog.L().Debug("reading in metrics.json", "filepath", path)
-		if err = data.DecodeJSON(path, "metrics"); err != nil {
-			hclog.L().Error("failed to decode metrics.json", "error", err)
-			return 1
-		}
-		hclog.L().Debug("successfully read in bundle contents")
+		parts = append(parts, loader.PartIndex, loader.PartMetrics)
 	}
 	if c.host {
-		hclog.L().Debug("reading in host.json", "filepath", path)
-		if err = data.DecodeJSON(path, "host"); err != nil {
-			hclog.L().Error("failed to decode host.json", "error", err)
-			return 1
-		}
-		hclog.L().Debug("successfully read in host.json bundle contents")
+		parts = append(parts, loader.PartHost)
 	}
 	if c.keyMetrics || c.memory || c.network || c.rateLimiting || c.serfHealth || c.autopilot || c.transactionTiming || c.leadershipChanges || c.bolt || c.dataplane || c.federationStatus || c.threadSaturation || c.serviceMetrics || c.telegraf {
-		hclog.L().Debug("reading in agent.json", "filepath", path)
-		if err = data.DecodeJSON(path, "agent"); err != nil {
-			hclog.L().Error("failed to decode agent.json", "error", err)
-			return 1
-		}
-		hclog.L().Debug("reading in host.json", "filepath", path)
-		if err = data.DecodeJSON(path, "host"); err != nil {
-			hclog.L().Error("failed to decode host.json", "error", err)
-			return 1
-		}
-		hclog.L().Debug("reading in index.json", "filepath", path)
-		if err = data.DecodeJSON(path, "index"); err != nil {
-			hclog.L().Error("failed to decode index.json", "error", err)
-			return 1
-		}
-		hclog.L().Debug("reading in metrics.json", "filepath", path)
-		if err = data.DecodeJSON(path, "metrics"); err != nil {
-			hclog.L().Error("failed to decode metrics.json", "error", err)
+		parts = append(parts, loader.PartAgent, loader.PartHost, loader.PartIndex, loader.PartMetrics)
+	}
+
+	data := &read.Debug{}
+	if len(parts) > 0 {
+		b, ok := commands.OpenBundle(c.pathFlags, path, parts...)
+		if !ok {
 			return 1
 		}
+		data = b.Debug()
 		hclog.L().Debug("successfully read in bundle contents")
 	}
+	var result string
 
 	switch {
 	case c.listAvailableTelemetry:
-		result, err = read.ListMetrics()
+		// The bundle's agent version pins the catalog; fall back to every documented metric without one.
+		if data.Index.AgentVersion == "" {
+			if b, err := loader.Open(path); err != nil {
+				hclog.L().Debug("unable to read bundle agent version, listing all documented metrics", "error", err)
+			} else {
+				data = b.Debug()
+			}
+		}
+		result, err = read.ListMetrics(data.Index.AgentVersion)
 		if err != nil {
 			hclog.L().Error("failed to retrieve agent telemetry available metrics", "error", err)
 			return 1
 		}
 	case c.host:
 		result = data.HostSummary()
+	case c.name != "" && c.groupBy != "":
+		var values string
+		values, err = data.GetMetricValuesGroupBy(c.name, strings.Split(c.groupBy, ","))
+		if err != nil {
+			hclog.L().Error("failed to group metric values", "name", c.name, "group-by", c.groupBy, "error", err)
+			return 1
+		}
+		c.ui.Output(values)
 	case c.name != "":
 		var values string
 		values, err = data.GetMetricValues(c.name, c.verify, c.sort, c.short)
@@ -563,6 +565,13 @@ Description:
 	
 	Sort metric capture by value (highest to lowest)
 		$ consul-debug-read metrics -name <name_of_metric> -sort
+
+	Filter captures by label (=, !=, =~ regex, !~ negated regex)
+		$ consul-debug-read metrics -name 'consul.rpc.request{type=write,leader=true}'
+
+	Aggregate captures by one or more label keys
+		$ consul-debug-read metrics -name 'consul.client.rpc' -group-by=method
+		$ consul-debug-read metrics -name 'consul.http.*' -group-by=method,path
 	
 	Skip hashidoc metric name validation:
 		$ consul-debug-read metrics -name <valid_name_but_not_in_docs> -verify=false`
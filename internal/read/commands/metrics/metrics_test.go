@@ -3,7 +3,6 @@ package metrics
 import (
 	"bytes"
 	"consul-debug-read/internal/read"
-	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -26,14 +25,11 @@ func BenchmarkDecodeMetrics(b *testing.B) {
 		// Create a new buffer with JSON data (you can use a file reader in your real scenario)
 		buffer := bytes.NewBuffer(jsonData)
 
-		// Create a JSON decoder
-		decoder := json.NewDecoder(buffer)
-
 		// Create a Debug instance to decode into
 		debug := &read.Debug{}
 
 		// Run the benchmarked function
-		err := debug.DecodeMetrics(decoder)
+		err := debug.DecodeMetrics(buffer)
 		if err != nil {
 			b.Fatalf("Error in DecodeMetrics: %v", err)
 		}
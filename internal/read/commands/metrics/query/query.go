@@ -0,0 +1,254 @@
+package query
+
+import (
+	"consul-debug-read/internal/read"
+	"consul-debug-read/internal/read/commands"
+	"consul-debug-read/internal/read/commands/config/get"
+	"consul-debug-read/internal/read/commands/flags"
+	"consul-debug-read/internal/read/loader"
+	"consul-debug-read/internal/read/query"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/hashicorp/go-hclog"
+	"github.com/mitchellh/cli"
+	"github.com/ryanuber/columnize"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type cmd struct {
+	ui        cli.Ui
+	flags     *flag.FlagSet
+	pathFlags *flags.DebugReadFlags
+
+	format  string
+	start   string
+	end     string
+	step    time.Duration
+	instant bool
+
+	verbose bool
+	silent  bool
+}
+
+func New(ui cli.Ui) (cli.Command, error) {
+	c := &cmd{
+		ui:        ui,
+		pathFlags: &flags.DebugReadFlags{},
+		flags:     flag.NewFlagSet("", flag.ContinueOnError),
+	}
+	c.flags.StringVar(&c.format, "format", "table", "Output format of query results: table or json")
+	c.flags.StringVar(&c.start, "start", "", "RFC3339 start time of the evaluation window (defaults to first metrics capture)")
+	c.flags.StringVar(&c.end, "end", "", "RFC3339 end time of the evaluation window, or evaluation time with -instant (defaults to last metrics capture)")
+	c.flags.DurationVar(&c.step, "step", 0, "Evaluation step of range queries (defaults to the bundle capture interval)")
+	c.flags.BoolVar(&c.instant, "instant", false, "Evaluate the expression once at -end instead of over the capture window")
+
+	c.flags.BoolVar(&c.silent, "silent", false, "Disables all normal log output")
+	c.flags.BoolVar(&c.verbose, "verbose", false, "Enable verbose debugging output")
+
+	flags.FlagMerge(c.flags, c.pathFlags.Flags())
+
+	return c, nil
+}
+
+func (c *cmd) Help() string { return commands.Usage(help, c.flags) }
+
+func (c *cmd) Synopsis() string { return synopsis }
+
+func (c *cmd) Run(args []string) int {
+	if err := c.flags.Parse(args); err != nil {
+		c.ui.Error(fmt.Sprintf("Failed to parse flags: %v", err))
+		return 1
+	}
+	if c.verbose && c.silent {
+		c.ui.Error(fmt.Sprintf("Cannot specify both -silent and -verbose"))
+		return 1
+	}
+	if c.format != "table" && c.format != "json" {
+		c.ui.Error(fmt.Sprintf("Invalid -format %q: must be one of table or json", c.format))
+		return 1
+	}
+	expr := strings.Join(c.flags.Args(), " ")
+	if expr == "" {
+		c.ui.Error("No query expression provided\n\n" + c.Help())
+		return 1
+	}
+
+	level := hclog.Info
+	if c.verbose {
+		level = hclog.Debug
+	} else if c.silent {
+		level = hclog.Off
+	}
+
+	commands.InitLogging(c.ui, level)
+
+	var ok bool
+	var err error
+	var path string
+	if path, ok = get.RenderPath(c.pathFlags); !ok {
+		hclog.L().Error("error rendering debug filepath", "filepath", path, "error", err)
+		return 1
+	}
+
+	b, ok := commands.OpenBundle(c.pathFlags, path, loader.PartIndex, loader.PartMetrics)
+	if !ok {
+		return 1
+	}
+	data := b.Debug()
+
+	engine := query.NewEngine(data.Metrics)
+	start, end := engine.Bounds()
+	if c.start != "" {
+		if start, err = time.Parse(time.RFC3339, c.start); err != nil {
+			hclog.L().Error("invalid -start time", "start", c.start, "error", err)
+			return 1
+		}
+	}
+	if c.end != "" {
+		if end, err = time.Parse(time.RFC3339, c.end); err != nil {
+			hclog.L().Error("invalid -end time", "end", c.end, "error", err)
+			return 1
+		}
+	}
+
+	if c.instant {
+		var result interface{}
+		if result, err = engine.Instant(expr, end); err != nil {
+			hclog.L().Error("failed to evaluate query", "query", expr, "error", err)
+			return 1
+		}
+		var out string
+		if out, err = formatInstant(result, c.format); err != nil {
+			hclog.L().Error("failed to format query result", "error", err)
+			return 1
+		}
+		c.ui.Output(out)
+		return 0
+	}
+
+	step := c.step
+	if step == 0 {
+		if step, err = time.ParseDuration(data.Index.Interval); err != nil || step <= 0 {
+			step = 30 * time.Second
+		}
+	}
+	hclog.L().Debug("evaluating range query", "query", expr, "start", start, "end", end, "step", step)
+	matrix, err := engine.Range(expr, start, end, step)
+	if err != nil {
+		hclog.L().Error("failed to evaluate query", "query", expr, "error", err)
+		return 1
+	}
+	out, err := formatMatrix(matrix, c.format)
+	if err != nil {
+		hclog.L().Error("failed to format query result", "error", err)
+		return 1
+	}
+	c.ui.Output(out)
+	return 0
+}
+
+// jsonSeries mirrors the Prometheus HTTP API representation of a series.
+type jsonSeries struct {
+	Metric query.Labels     `json:"metric"`
+	Values [][2]interface{} `json:"values,omitempty"`
+	Value  []interface{}    `json:"value,omitempty"`
+}
+
+func formatValue(v float64) string {
+	if math.IsNaN(v) {
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+func formatMatrix(matrix query.Matrix, format string) (string, error) {
+	if format == "json" {
+		out := make([]jsonSeries, 0, len(matrix))
+		for _, s := range matrix {
+			js := jsonSeries{Metric: s.Metric}
+			for _, p := range s.Points {
+				js.Values = append(js.Values, [2]interface{}{p.T.Unix(), formatValue(p.V)})
+			}
+			out = append(out, js)
+		}
+		b, err := json.MarshalIndent(out, "", "  ")
+		return string(b), err
+	}
+	if len(matrix) == 0 {
+		return "* query => no series returned", nil
+	}
+	var result []string
+	for _, s := range matrix {
+		name := query.FormatMetric(s.Metric)
+		result = append(result, fmt.Sprintf("\x1f%s\x1f", name))
+		result = append(result, fmt.Sprintf("\x1f%s\x1f", strings.Repeat("-", len(name))))
+		result = append(result, "Timestamp\x1fValue\x1f")
+		for _, p := range s.Points {
+			result = append(result, fmt.Sprintf("%s\x1f%s\x1f", p.T.Format(read.MetricsTimestampLayout), formatValue(p.V)))
+		}
+		result = append(result, "\x1f\x1f")
+	}
+	return columnize.Format(result, &columnize.Config{Delim: string([]byte{0x1f}), Glue: " "}), nil
+}
+
+func formatInstant(result interface{}, format string) (string, error) {
+	var vec query.Vector
+	switch r := result.(type) {
+	case query.Scalar:
+		if format == "json" {
+			return fmt.Sprintf("%q", formatValue(float64(r))), nil
+		}
+		return formatValue(float64(r)), nil
+	case query.Vector:
+		vec = r
+	default:
+		return "", fmt.Errorf("instant queries must return a scalar or instant vector")
+	}
+	if format == "json" {
+		out := make([]jsonSeries, 0, len(vec))
+		for _, s := range vec {
+			out = append(out, jsonSeries{Metric: s.Metric, Value: []interface{}{s.T.Unix(), formatValue(s.V)}})
+		}
+		b, err := json.MarshalIndent(out, "", "  ")
+		return string(b), err
+	}
+	result2 := []string{"Series\x1fTimestamp\x1fValue\x1f"}
+	for _, s := range vec {
+		result2 = append(result2, fmt.Sprintf("%s\x1f%s\x1f%s\x1f", query.FormatMetric(s.Metric), s.T.Format(read.MetricsTimestampLayout), formatValue(s.V)))
+	}
+	return columnize.Format(result2, &columnize.Config{Delim: string([]byte{0x1f}), Glue: " "}), nil
+}
+
+const synopsis = `Evaluate PromQL-style expressions over bundle metrics`
+const help = `
+Usage:
+    consul-debug-read metrics query [options] '<expression>'
+
+Evaluates a PromQL-lite expression against the metrics.json captures of the bundle, over the
+whole capture window (one point per capture interval) or at a single instant with -instant.
+
+Metric names may be written as captured (consul.raft.apply) or in Prometheus form
+(consul_raft_apply). Surround the '-' operator with whitespace, since '-' is valid within
+metric names (consul.mesh.active-root-ca.expiry).
+
+Supported:
+    selectors      consul.rpc.request{type="write",leader=~"true|false"}  consul.client.rpc[1m]
+    functions      rate, increase, delta, abs, scalar,
+                   avg_over_time, min_over_time, max_over_time, sum_over_time,
+                   count_over_time, last_over_time
+    aggregations   sum, avg, min, max, count  with 'by (labels)' or 'without (labels)'
+    operators      + - * / %   and comparison filters == != > < >= <=
+
+Counters in metrics.json are per-interval counts, so rate() and increase() sum the counts
+within the range window.
+
+Examples:
+    $ consul-debug-read metrics query 'sum by (method) (rate(consul.client.rpc[1m]))'
+    $ consul-debug-read metrics query 'max_over_time(consul.raft.commitTime[2m])'
+    $ consul-debug-read metrics query 'consul.raft.commitTime / consul.raft.apply'
+    $ consul-debug-read metrics query -instant -format=json 'consul_runtime_heap_objects > 100000'
+`
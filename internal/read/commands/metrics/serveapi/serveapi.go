@@ -0,0 +1,142 @@
+package serveapi
+
+import (
+	"consul-debug-read/internal/read/commands"
+	"consul-debug-read/internal/read/commands/config/get"
+	"consul-debug-read/internal/read/commands/flags"
+	"consul-debug-read/internal/read/loader"
+	"consul-debug-read/internal/read/query"
+	"consul-debug-read/internal/read/web"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/hashicorp/go-hclog"
+	"github.com/mitchellh/cli"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+)
+
+type cmd struct {
+	ui        cli.Ui
+	flags     *flag.FlagSet
+	pathFlags *flags.DebugReadFlags
+
+	addr string
+
+	verbose bool
+	silent  bool
+}
+
+func New(ui cli.Ui) (cli.Command, error) {
+	c := &cmd{
+		ui:        ui,
+		pathFlags: &flags.DebugReadFlags{},
+		flags:     flag.NewFlagSet("", flag.ContinueOnError),
+	}
+	c.flags.StringVar(&c.addr, "addr", "127.0.0.1:9090", "Address the Prometheus-compatible API listens on")
+
+	c.flags.BoolVar(&c.silent, "silent", false, "Disables all normal log output")
+	c.flags.BoolVar(&c.verbose, "verbose", false, "Enable verbose debugging output")
+
+	flags.FlagMerge(c.flags, c.pathFlags.Flags())
+
+	return c, nil
+}
+
+func (c *cmd) Help() string { return commands.Usage(help, c.flags) }
+
+func (c *cmd) Synopsis() string { return synopsis }
+
+func (c *cmd) Run(args []string) int {
+	if err := c.flags.Parse(args); err != nil {
+		c.ui.Error(fmt.Sprintf("Failed to parse flags: %v", err))
+		return 1
+	}
+	if c.verbose && c.silent {
+		c.ui.Error(fmt.Sprintf("Cannot specify both -silent and -verbose"))
+		return 1
+	}
+
+	level := hclog.Info
+	if c.verbose {
+		level = hclog.Debug
+	} else if c.silent {
+		level = hclog.Off
+	}
+
+	commands.InitLogging(c.ui, level)
+
+	var ok bool
+	var err error
+	var path string
+	if path, ok = get.RenderPath(c.pathFlags); !ok {
+		hclog.L().Error("error rendering debug filepath", "filepath", path, "error", err)
+		return 1
+	}
+
+	b, ok := commands.OpenBundle(c.pathFlags, path, loader.PartIndex, loader.PartMetrics)
+	if !ok {
+		return 1
+	}
+	data := b.Debug()
+
+	engine := query.NewEngine(data.Metrics)
+	api := web.NewPrometheusAPI(engine).Handler()
+	server := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			hclog.L().Debug("api request", "method", r.Method, "url", r.URL.String())
+			api.ServeHTTP(w, r)
+		}),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	listener, err := net.Listen("tcp", c.addr)
+	if err != nil {
+		hclog.L().Error("failed to start api server", "addr", c.addr, "error", err)
+		return 1
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	start, end := engine.Bounds()
+	c.ui.Output(fmt.Sprintf("==> serving Prometheus API for bundle %s at http://%s (press Ctrl-C to stop)", path, listener.Addr()))
+	c.ui.Output(fmt.Sprintf("    captures: %s => %s", start.UTC().Format(time.RFC3339), end.UTC().Format(time.RFC3339)))
+	if err = server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		hclog.L().Error("api server failed", "error", err)
+		return 1
+	}
+	return 0
+}
+
+const synopsis = `Serve bundle metrics over a Prometheus-compatible HTTP API`
+const help = `
+Usage:
+    consul-debug-read metrics serve-api [options]
+
+Exposes the bundle's metrics.json captures through a read-only subset of the Prometheus HTTP API
+so a Grafana Prometheus data source (and the stock Consul dashboards) can chart offline captures:
+
+    /api/v1/query          instant queries (defaults to the last capture time)
+    /api/v1/query_range    range queries
+    /api/v1/series         series matching match[] selectors
+    /api/v1/labels         label names
+    /api/v1/label/<name>/values
+
+Queries are evaluated by the same PromQL-lite engine as 'metrics query'. Metric and label names are
+exposed in Prometheus form (consul.raft.apply => consul_raft_apply). Set the Grafana dashboard time
+range to the capture window printed at startup.
+
+Example:
+    $ consul-debug-read metrics serve-api -addr=127.0.0.1:9090
+    # Grafana: Add data source => Prometheus => URL http://127.0.0.1:9090
+`
@@ -0,0 +1,128 @@
+package serve
+
+import (
+	"consul-debug-read/internal/read/commands"
+	"consul-debug-read/internal/read/commands/config/get"
+	"consul-debug-read/internal/read/commands/flags"
+	"consul-debug-read/internal/read/commands/metrics"
+	"consul-debug-read/internal/read/loader"
+	"consul-debug-read/internal/read/web"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/hashicorp/go-hclog"
+	"github.com/mitchellh/cli"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+)
+
+type cmd struct {
+	ui        cli.Ui
+	flags     *flag.FlagSet
+	pathFlags *flags.DebugReadFlags
+
+	addr string
+
+	verbose bool
+	silent  bool
+}
+
+func New(ui cli.Ui) (cli.Command, error) {
+	c := &cmd{
+		ui:        ui,
+		pathFlags: &flags.DebugReadFlags{},
+		flags:     flag.NewFlagSet("", flag.ContinueOnError),
+	}
+	c.flags.StringVar(&c.addr, "addr", "127.0.0.1:8088", "Address the dashboard server listens on")
+
+	c.flags.BoolVar(&c.silent, "silent", false, "Disables all normal log output")
+	c.flags.BoolVar(&c.verbose, "verbose", false, "Enable verbose debugging output")
+
+	flags.FlagMerge(c.flags, c.pathFlags.Flags())
+
+	return c, nil
+}
+
+func (c *cmd) Help() string { return commands.Usage(help, c.flags) }
+
+func (c *cmd) Synopsis() string { return synopsis }
+
+func (c *cmd) Run(args []string) int {
+	if err := c.flags.Parse(args); err != nil {
+		c.ui.Error(fmt.Sprintf("Failed to parse flags: %v", err))
+		return 1
+	}
+	if c.verbose && c.silent {
+		c.ui.Error(fmt.Sprintf("Cannot specify both -silent and -verbose"))
+		return 1
+	}
+
+	level := hclog.Info
+	if c.verbose {
+		level = hclog.Debug
+	} else if c.silent {
+		level = hclog.Off
+	}
+
+	commands.InitLogging(c.ui, level)
+
+	var ok bool
+	var err error
+	var path string
+	if path, ok = get.RenderPath(c.pathFlags); !ok {
+		hclog.L().Error("error rendering debug filepath", "filepath", path, "error", err)
+		return 1
+	}
+
+	b, ok := commands.OpenBundle(c.pathFlags, path, loader.PartAgent, loader.PartMembers, loader.PartIndex, loader.PartMetrics)
+	if !ok {
+		return 1
+	}
+
+	server := &http.Server{
+		Handler:           web.NewServer(b.Debug(), b.LogFile(), metrics.KeyMetricGroups()).WithRedactor(b.Redactor()).Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	listener, err := net.Listen("tcp", c.addr)
+	if err != nil {
+		hclog.L().Error("failed to start dashboard server", "addr", c.addr, "error", err)
+		return 1
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	c.ui.Output(fmt.Sprintf("==> serving bundle %s at http://%s (press Ctrl-C to stop)", path, listener.Addr()))
+	if err = server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		hclog.L().Error("dashboard server failed", "error", err)
+		return 1
+	}
+	return 0
+}
+
+const synopsis = `Serve a local web dashboard over the debug bundle`
+const help = `
+Usage:
+    consul-debug-read serve [options]
+
+Starts a local HTTP server with a self-contained dashboard over the bundle: time-series charts
+for any metric (label matchers and '*' wildcards supported), the key metric groups, member and
+raft tables, and consul.log search. No external services (InfluxDB/Grafana) are required.
+
+The dashboard data is also available as JSON:
+    /api/overview    /api/metrics    /api/series?query=<selector>    /api/groups
+    /api/members     /api/raft       /api/logs?q=<text>&level=<levels>&limit=<n>
+
+Example:
+    $ consul-debug-read serve -addr=127.0.0.1:8088
+`
@@ -5,6 +5,7 @@ import (
 	"consul-debug-read/internal/read/commands"
 	"consul-debug-read/internal/read/commands/config/get"
 	"consul-debug-read/internal/read/commands/flags"
+	"consul-debug-read/internal/read/loader"
 	"flag"
 	"fmt"
 	"github.com/hashicorp/go-hclog"
@@ -63,38 +64,17 @@ func (c *cmd) Run(args []string) int {
 	var ok bool
 	var err error
 	var path string
-	if path, ok = get.RenderPathFromConfig(); !ok {
+	if path, ok = get.RenderPath(c.pathFlags); !ok {
 		hclog.L().Error("error rendering debug filepath", "filepath", path, "error", err)
 		return 1
 	}
 
-	var data read.Debug
 	var result string
-	hclog.L().Debug("reading in agent.json")
-	if err = data.DecodeJSON(path, "agent"); err != nil {
-		hclog.L().Error("failed to decode agent.json", "error", err)
-		return 1
-	}
-	hclog.L().Debug("reading members.json")
-	if err = data.DecodeJSON(path, "members"); err != nil {
-		hclog.L().Error("failed to decode members.json", "error", err)
-		return 1
-	}
-	hclog.L().Debug("reading in host.json")
-	if err = data.DecodeJSON(path, "host"); err != nil {
-		hclog.L().Error("failed to decode host.json", "error", err)
-		return 1
-	}
-	hclog.L().Debug("reading in index.json")
-	if err = data.DecodeJSON(path, "index"); err != nil {
-		hclog.L().Error("failed to decode index.json", "error", err)
-		return 1
-	}
-	hclog.L().Debug("reading in metrics.json")
-	if err := data.DecodeJSON(path, "metrics"); err != nil {
-		hclog.L().Error("failed to decode metrics.json", "error", err)
+	b, ok := commands.OpenBundle(c.pathFlags, path, loader.PartAgent, loader.PartMembers, loader.PartHost, loader.PartIndex, loader.PartMetrics)
+	if !ok {
 		return 1
 	}
+	data := b.Debug()
 	hclog.L().Debug("successfully read in bundle contents")
 
 	files, err := getLogFiles(path)
@@ -0,0 +1,31 @@
+package telemetry
+
+import (
+	"consul-debug-read/internal/read/commands"
+	"github.com/mitchellh/cli"
+)
+
+type Cmd struct{}
+
+func New() *Cmd {
+	return &Cmd{}
+}
+
+func (c *Cmd) Help() string {
+	return commands.Usage(help, nil)
+}
+
+func (c *Cmd) Synopsis() string { return synopsis }
+
+func (c *Cmd) Run(args []string) int {
+	return cli.RunResultHelp
+}
+
+const synopsis = `Manages the consul telemetry metric catalog`
+const help = `
+Usage: 
+    consul-debug-read telemetry <subcommand> [options]
+
+  Run consul-debug-read telemetry <subcommand> with no arguments for help on that
+  subcommand.
+`
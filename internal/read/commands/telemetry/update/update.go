@@ -0,0 +1,168 @@
+package update
+
+import (
+	"consul-debug-read/internal/read"
+	"consul-debug-read/internal/read/commands"
+	"consul-debug-read/internal/read/commands/flags"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/hashicorp/go-hclog"
+	"github.com/mitchellh/cli"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type cmd struct {
+	ui        cli.Ui
+	flags     *flag.FlagSet
+	pathFlags *flags.DebugReadFlags
+
+	docs    string
+	version string
+	output  string
+	force   bool
+
+	verbose bool
+	silent  bool
+}
+
+func New(ui cli.Ui) (cli.Command, error) {
+	c := &cmd{
+		ui:        ui,
+		pathFlags: &flags.DebugReadFlags{},
+		flags:     flag.NewFlagSet("", flag.ContinueOnError),
+	}
+	c.flags.StringVar(&c.docs, "docs", "", "Path to a local consul repository checkout, or directly to its telemetry.mdx")
+	c.flags.StringVar(&c.version, "version", "", "Consul version the docs describe (defaults to the checkout's version/VERSION)")
+	c.flags.StringVar(&c.output, "output", read.TelemetryCatalogPath, "File to write the refreshed telemetry catalog to")
+	c.flags.BoolVar(&c.force, "force", false, "Merge docs older than the catalog's docs version, marking newer metrics as removed")
+
+	c.flags.BoolVar(&c.silent, "silent", false, "Disables all normal log output")
+	c.flags.BoolVar(&c.verbose, "verbose", false, "Enable verbose debugging output")
+
+	flags.FlagMerge(c.flags, c.pathFlags.Flags())
+
+	return c, nil
+}
+
+func (c *cmd) Help() string { return commands.Usage(help, c.flags) }
+
+func (c *cmd) Synopsis() string { return synopsis }
+
+func (c *cmd) Run(args []string) int {
+	if err := c.flags.Parse(args); err != nil {
+		c.ui.Error(fmt.Sprintf("Failed to parse flags: %v", err))
+		return 1
+	}
+	if c.verbose && c.silent {
+		c.ui.Error(fmt.Sprintf("Cannot specify both -silent and -verbose"))
+		return 1
+	}
+	if c.docs == "" {
+		c.ui.Error("Missing required -docs flag")
+		return 1
+	}
+
+	level := hclog.Info
+	if c.verbose {
+		level = hclog.Debug
+	} else if c.silent {
+		level = hclog.Off
+	}
+
+	commands.InitLogging(c.ui, level)
+
+	docsFile, version, err := resolveDocs(c.docs, c.version)
+	if err != nil {
+		hclog.L().Error("failed to locate consul telemetry docs", "docs", c.docs, "error", err)
+		return 1
+	}
+
+	f, err := os.Open(docsFile)
+	if err != nil {
+		hclog.L().Error("failed to open consul telemetry docs", "file", docsFile, "error", err)
+		return 1
+	}
+	defer f.Close()
+	hclog.L().Debug("parsing consul telemetry docs", "file", docsFile, "version", version)
+	documented, err := read.ParseTelemetryDocs(f)
+	if err != nil {
+		hclog.L().Error("failed to parse consul telemetry docs", "file", docsFile, "error", err)
+		return 1
+	}
+
+	catalog, err := read.LoadTelemetryCatalog()
+	if err != nil {
+		hclog.L().Error("failed to load current telemetry catalog", "error", err)
+		return 1
+	}
+	before := len(catalog.ForVersion(version))
+	if err = catalog.Merge(documented, version, c.force); err != nil {
+		hclog.L().Error("failed to merge consul telemetry docs, pass -force to merge older docs", "version", version, "error", err)
+		return 1
+	}
+
+	data, err := json.MarshalIndent(catalog, "", "  ")
+	if err != nil {
+		hclog.L().Error("failed to encode telemetry catalog", "error", err)
+		return 1
+	}
+	if err = os.MkdirAll(filepath.Dir(c.output), 0755); err != nil {
+		hclog.L().Error("failed to create telemetry catalog directory", "output", c.output, "error", err)
+		return 1
+	}
+	if err = os.WriteFile(c.output, append(data, '\n'), 0644); err != nil {
+		hclog.L().Error("failed to write telemetry catalog", "output", c.output, "error", err)
+		return 1
+	}
+	c.ui.Output(fmt.Sprintf("telemetry catalog updated to consul %s: %d documented metrics (previously %d) => %s",
+		version, len(documented), before, c.output))
+	return 0
+}
+
+// resolveDocs returns the telemetry.mdx file for the -docs path and the Consul version it
+// documents, read from the checkout's version/VERSION file when not given explicitly.
+func resolveDocs(docs, version string) (string, string, error) {
+	info, err := os.Stat(docs)
+	if err != nil {
+		return "", "", err
+	}
+	docsFile, root := docs, ""
+	if info.IsDir() {
+		root = docs
+		docsFile = filepath.Join(docs, filepath.FromSlash(read.TelemetryDocsPath))
+	}
+	if version != "" {
+		return docsFile, version, nil
+	}
+	if root == "" {
+		return "", "", fmt.Errorf("-version is required when -docs points directly at a file")
+	}
+	raw, err := os.ReadFile(filepath.Join(root, "version", "VERSION"))
+	if err != nil {
+		return "", "", fmt.Errorf("unable to determine docs version, set -version: %v", err)
+	}
+	return docsFile, strings.TrimSpace(string(raw)), nil
+}
+
+const synopsis = `Refreshes the telemetry catalog from a local consul docs checkout`
+const help = `
+Usage:
+    consul-debug-read telemetry update -docs <path> [options]
+
+Rebuilds the telemetry metric catalog used by 'metrics -name' and 'metrics -list-available-telemetry'
+from the telemetry docs of a local consul repository checkout (website/content/docs/agent/telemetry.mdx).
+No network access is required.
+
+Metrics new to the docs are recorded as introduced in -version, and metrics no longer documented are
+recorded as removed in it, so bundles from older agents keep resolving the metrics they emit. The
+refreshed catalog is written to ~/.consul-debug-read/telemetry.json and takes precedence over the
+catalog built into consul-debug-read. Docs older than the catalog's docs version are refused, as
+they would mark newer metrics as removed, unless -force is passed.
+
+Example:
+    $ git -C ~/src/consul checkout v1.17.0
+    $ consul-debug-read telemetry update -docs ~/src/consul
+`
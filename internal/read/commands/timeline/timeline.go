@@ -0,0 +1,190 @@
+package timeline
+
+import (
+	"consul-debug-read/internal/read"
+	"consul-debug-read/internal/read/commands"
+	"consul-debug-read/internal/read/commands/config/get"
+	"consul-debug-read/internal/read/commands/flags"
+	"consul-debug-read/internal/read/loader"
+	"consul-debug-read/internal/read/timeline"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/hashicorp/go-hclog"
+	"github.com/mitchellh/cli"
+	"github.com/ryanuber/columnize"
+	"time"
+)
+
+type cmd struct {
+	ui        cli.Ui
+	flags     *flag.FlagSet
+	pathFlags *flags.DebugReadFlags
+
+	since  string
+	until  string
+	top    int
+	format string
+
+	verbose bool
+	silent  bool
+}
+
+func New(ui cli.Ui) (cli.Command, error) {
+	c := &cmd{
+		ui:        ui,
+		pathFlags: &flags.DebugReadFlags{},
+		flags:     flag.NewFlagSet("", flag.ContinueOnError),
+	}
+	c.flags.StringVar(&c.since, "since", "", "Start of the timeline window: RFC3339 or a time of day (15:04:05) on the capture date (defaults to capture start)")
+	c.flags.StringVar(&c.until, "until", "", "End of the timeline window: RFC3339 or a time of day (15:04:05) on the capture date (defaults to capture end)")
+	c.flags.IntVar(&c.top, "top", 20, "Number of most severe metric anomalies to include (0 includes all)")
+	c.flags.StringVar(&c.format, "format", "table", "Output format of the timeline: table or json")
+
+	c.flags.BoolVar(&c.silent, "silent", false, "Disables all normal log output")
+	c.flags.BoolVar(&c.verbose, "verbose", false, "Enable verbose debugging output")
+
+	flags.FlagMerge(c.flags, c.pathFlags.Flags())
+
+	return c, nil
+}
+
+func (c *cmd) Help() string { return commands.Usage(help, c.flags) }
+
+func (c *cmd) Synopsis() string { return synopsis }
+
+func (c *cmd) Run(args []string) int {
+	if err := c.flags.Parse(args); err != nil {
+		c.ui.Error(fmt.Sprintf("Failed to parse flags: %v", err))
+		return 1
+	}
+	if c.verbose && c.silent {
+		c.ui.Error(fmt.Sprintf("Cannot specify both -silent and -verbose"))
+		return 1
+	}
+	if c.format != "table" && c.format != "json" {
+		c.ui.Error(fmt.Sprintf("Invalid -format %q: must be one of table or json", c.format))
+		return 1
+	}
+
+	level := hclog.Info
+	if c.verbose {
+		level = hclog.Debug
+	} else if c.silent {
+		level = hclog.Off
+	}
+
+	commands.InitLogging(c.ui, level)
+
+	var ok bool
+	var err error
+	var path string
+	if path, ok = get.RenderPath(c.pathFlags); !ok {
+		hclog.L().Error("error rendering debug filepath", "filepath", path, "error", err)
+		return 1
+	}
+
+	b, ok := commands.OpenBundle(c.pathFlags, path, loader.PartIndex, loader.PartMetrics)
+	if !ok {
+		return 1
+	}
+	data := b.Debug()
+
+	// Times of day are resolved against the first capture, in the bundle's time zone.
+	var reference time.Time
+	if len(data.Metrics.Metrics) > 0 {
+		reference, _ = read.ParseMetricTimestamp(data.Metrics.Metrics[0].Timestamp)
+	}
+	opts := timeline.Options{LogFile: b.LogFile(), Redactor: b.Redactor(), TopAnomalies: c.top}
+	if opts.Since, err = parseWindowTime(c.since, reference); err != nil {
+		hclog.L().Error("invalid -since", "since", c.since, "error", err)
+		return 1
+	}
+	if opts.Until, err = parseWindowTime(c.until, reference); err != nil {
+		hclog.L().Error("invalid -until", "until", c.until, "error", err)
+		return 1
+	}
+	if !opts.Since.IsZero() && !opts.Until.IsZero() && opts.Until.Before(opts.Since) {
+		hclog.L().Error("-until must not be before -since", "since", opts.Since, "until", opts.Until)
+		return 1
+	}
+
+	hclog.L().Debug("reading in profile captures", "filepath", path)
+	if opts.Profiles, err = b.Profiles(); err != nil {
+		hclog.L().Warn("failed to read profile captures, skipping profile events", "error", err)
+	}
+
+	events, err := timeline.Build(data.Metrics, opts)
+	if err != nil {
+		hclog.L().Error("failed to build timeline", "error", err)
+		return 1
+	}
+
+	if c.format == "json" {
+		if events == nil {
+			events = []timeline.Event{}
+		}
+		out, err := json.MarshalIndent(events, "", "  ")
+		if err != nil {
+			hclog.L().Error("failed to encode timeline", "error", err)
+			return 1
+		}
+		c.ui.Output(string(out))
+		return 0
+	}
+	if len(events) == 0 {
+		c.ui.Output("no events found in the timeline window")
+		return 0
+	}
+
+	// Render log and profile times, parsed with bare offsets, in the bundle's named time zone.
+	location := time.Local
+	if !reference.IsZero() {
+		location = reference.Location()
+	}
+	result := []string{"Time\x1fSource\x1fKind\x1fEvent"}
+	for _, e := range events {
+		result = append(result, fmt.Sprintf("%s\x1f%s\x1f%s\x1f%s", e.Time.In(location).Format(read.MetricsTimestampLayout), e.Source, e.Kind, e.Summary))
+	}
+	c.ui.Output(columnize.Format(result, &columnize.Config{Delim: string([]byte{0x1f}), Glue: " "}))
+	return 0
+}
+
+// parseWindowTime parses an RFC3339 timestamp, or a 15:04:05 time of day on the reference date.
+func parseWindowTime(value string, reference time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	clock, err := time.Parse(time.TimeOnly, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("expected RFC3339 or 15:04:05, got %q", value)
+	}
+	if reference.IsZero() {
+		return time.Time{}, fmt.Errorf("bundle has no metrics captures to resolve time of day %q against", value)
+	}
+	return time.Date(reference.Year(), reference.Month(), reference.Day(),
+		clock.Hour(), clock.Minute(), clock.Second(), 0, reference.Location()), nil
+}
+
+const synopsis = `Merges logs, metric anomalies and profile captures into one timeline`
+const help = `
+Usage:
+    consul-debug-read timeline [options]
+
+Builds a single chronologically ordered view of what happened during the capture by merging:
+
+    log       raft and gossip log events, plus every WARN and ERROR entry
+    metric    the most severe metric anomalies (spikes, level shifts, growth and drops)
+    profile   per-interval profile captures, with the goroutine count from goroutine.prof
+
+Use -since and -until to focus on an incident window. Times of day are read on the capture date in
+the bundle's time zone. Metric anomalies are detected from the captures inside the window only, so
+-top ranks the window's anomalies against a baseline taken from the same window.
+
+Example:
+    $ consul-debug-read timeline -since=15:12:30 -until=15:14:00
+    $ consul-debug-read timeline -since=2024-02-07T15:12:30-05:00 -format=json
+`
@@ -2,12 +2,15 @@ package read
 
 import (
 	"archive/tar"
+	"bufio"
+	"bytes"
 	"compress/gzip"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"github.com/ryanuber/columnize"
+	"github.com/hashicorp/go-hclog"
 	"io"
-	"log"
 	"os"
 	"path/filepath"
 	"reflect"
@@ -26,6 +29,7 @@ const (
 	PercentRegex                = "percentage"
 	BundleRegex                 = `.*consul-debug.*|.*ConsulDebug.*` // consul debug command | hcdiag
 	TimeStampRegex              = `^(\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}\.\d{3}(Z|[-+]\d{2}:?\d{2}|[-+]\d{4}|[-+]\d{2})?)`
+	MetricsTimestampLayout      = "2006-01-02 15:04:05 -0700 MST"
 )
 
 var (
@@ -33,6 +37,7 @@ var (
 	CurrentDir, _           = os.Getwd()
 	DebugReadConfigDirPath  = fmt.Sprintf("%s/%s", UserHomeDir, DefaultCmdConfigFileDirName)
 	DebugReadConfigFullPath = fmt.Sprintf("%s/%s", DebugReadConfigDirPath, DefaultCmdConfigFileName)
+	DebugReadCacheDirPath   = fmt.Sprintf("%s/cache", DebugReadConfigDirPath)
 	bundleRegex             = regexp.MustCompile(BundleRegex)
 	timeReg                 = regexp.MustCompile(TimeUnitsRegex)
 	bytesReg                = regexp.MustCompile(BytesRegex)
@@ -55,7 +60,7 @@ func parseDuration(durationStr string) (time.Duration, error) {
 
 func ToRFC3339(ts string) (string, error) {
 	// Parse the timestamp string into a time.Time value
-	timestamp, err := time.Parse("2006-01-02 15:04:05 -0700 MST", ts)
+	timestamp, err := time.Parse(MetricsTimestampLayout, ts)
 	if err != nil {
 		return "", fmt.Errorf("error parsing timestamp: %v\n", err)
 	}
@@ -134,6 +139,10 @@ func extractTarGz(srcFile, destDir string) (string, error) {
 		if err = destFile.Close(); err != nil {
 			return "", cleanup(err)
 		}
+		// Keep the capture time of files, index.json's dates the bundle (see RegisteredBundle)
+		if err = os.Chtimes(destFilePath, header.ModTime, header.ModTime); err != nil {
+			return "", err
+		}
 		i++
 	}
 
@@ -148,24 +157,17 @@ func extractTarGz(srcFile, destDir string) (string, error) {
 	return extractRootDir, nil
 }
 
-func SelectAndExtractTarGzFilesInDir(sourceDir string) (string, error) {
-	var selectedFile os.DirEntry
-	var sourceFilePath, extractRoot string
-	// var extractedDebugPath string
+// SelectAndExtractTarGzFilesInDir extracts the bundle archive sourceDir, or when sourceDir is a
+// directory of bundle archives, the archive picked by selection. A directory without archives is
+// returned as is, for the caller to validate as an extracted bundle.
+func SelectAndExtractTarGzFilesInDir(sourceDir string, selection BundleSelection) (string, error) {
+	var sourceFilePath string
 	// If debug path is not a bundle directly, parse for bundles and extract
 	if !strings.HasSuffix(sourceDir, ".tar.gz") {
-		var bundles []os.DirEntry
-
-		files, err := os.ReadDir(sourceDir)
-		if err != nil {
-			return "", fmt.Errorf("failed to read debug-path directory %s\n%v\n", sourceDir, err)
-		}
 		// Filter files for .tar.gz bundles
-		for _, file := range files {
-			name := file.Name()
-			if !file.IsDir() && strings.HasSuffix(name, ".tar.gz") && bundleRegex.FindStringSubmatch(name) != nil {
-				bundles = append(bundles, file)
-			}
+		bundles, err := ListBundleArchives(sourceDir)
+		if err != nil {
+			return "", err
 		}
 
 		// If there are no .tar.gz files (i.e., len(bundles) <= 0),
@@ -176,34 +178,10 @@ func SelectAndExtractTarGzFilesInDir(sourceDir string) (string, error) {
 			return sourceDir, nil
 		}
 
-		// Build extraction tool title
-		title := "Consul Debug Bundle Extraction Tool"
-		ul := fmt.Sprintf(strings.Repeat("-", len(title)))
-		menu := []string{fmt.Sprintf("\x1f%s\x1f", title)}
-		menu = append(menu, fmt.Sprintf("\x1f%s\x1f", ul))
-
-		// Print columnized output for user to select
-		menu = append(menu, fmt.Sprintf("Option\x1fBundle Name\x1fSize\x1f"))
-		// fmt.Println("Select a bundle to extract:")
-		conv := ByteConverter{}
-		for i, bundle := range bundles {
-			info, _ := bundle.Info()
-			bundleSize := conv.ConvertToReadableBytes(info.Size())
-			menu = append(menu, fmt.Sprintf("%d\x1f%s\x1f%s\x1f", i+1, bundle.Name(), bundleSize))
-		}
-		output := columnize.Format(menu, &columnize.Config{Delim: string([]byte{0x1f}), Glue: " "})
-		fmt.Printf("\n%s\n\n", output)
-		fmt.Print("Enter the file option number to extract: ")
-		var selected int
-		if _, err = fmt.Scanf("%d", &selected); err != nil {
+		selectedFile, err := selection.Select(sourceDir, bundles)
+		if err != nil {
 			return "", err
 		}
-
-		if selected < 1 || selected > len(bundles) {
-			return "", fmt.Errorf("invalid selection: %v", err)
-		}
-
-		selectedFile = bundles[selected-1]
 		sourceFilePath = filepath.Join(sourceDir, selectedFile.Name())
 	} else {
 		sourceFilePath = sourceDir
@@ -253,31 +231,6 @@ func ConvertSecondsReadable(seconds int) string {
 	return formatted
 }
 
-func StructToHCL(data interface{}, indent string) string {
-	hcl := ""
-	t := reflect.TypeOf(data)
-	v := reflect.ValueOf(data)
-
-	for i := 0; i < t.NumField(); i++ {
-		field := t.Field(i)
-		value := v.Field(i)
-
-		if value.Kind() == reflect.Struct {
-			hcl += fmt.Sprintf("%s%s {\n", indent, field.Name)
-			hcl += StructToHCL(value.Interface(), indent+"  ")
-			hcl += fmt.Sprintf("%s}\n", indent)
-		} else {
-			jsonTagName := field.Tag.Get("json")
-			if jsonTagName == "" {
-				jsonTagName = field.Name
-			}
-			hcl += fmt.Sprintf("%s%s = %v\n", indent, jsonTagName, value.Interface())
-		}
-	}
-
-	return hcl
-}
-
 func WriteFileWithPerms(outputFile, payload string, mode os.FileMode) error {
 	// os.WriteFile truncates existing files and overwrites them, but only if they are writable.
 	// If the file exists it will already likely be read-only. Remove it first.
@@ -318,12 +271,29 @@ func (b *Debug) numberOfCaptures() (int, error) {
 }
 
 func (b *Debug) DecodeAgent(agentDecoder *json.Decoder) error {
+	var raw json.RawMessage
+	if err := agentDecoder.Decode(&raw); err != nil {
+		return err
+	}
 	var agentConfig Agent
-	err := agentDecoder.Decode(&agentConfig)
-	if err != nil {
-		log.Fatalf("error decoding agent: %v", err)
+	if err := json.Unmarshal(raw, &agentConfig); err != nil {
+		// Report the offset within agent.json rather than within raw.
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			typeErr.Offset += agentDecoder.InputOffset() - int64(len(raw))
+		}
+		return err
+	}
+	// Keep the DebugConfig as captured, as the typed DebugConfig only holds the settings analysed.
+	var sections struct {
+		DebugConfig json.RawMessage
+	}
+	if err := json.Unmarshal(raw, &sections); err != nil {
 		return err
 	}
+	agentConfig.RawDebugConfig = sections.DebugConfig
+	// members.json is decoded separately and may already have been
+	agentConfig.Members = b.Agent.Members
 	b.Agent = agentConfig
 	return nil
 }
@@ -336,7 +306,6 @@ func (b *Debug) DecodeHost(hostDecoder *json.Decoder) error {
 			break
 		}
 		if err != nil {
-			log.Fatalf("error decoding host: %v", err)
 			return err
 		}
 		b.Host = hostObject
@@ -348,7 +317,6 @@ func (a *Agent) DecodeMembers(memberDecoder *json.Decoder) error {
 	var membersList []Member
 	err := memberDecoder.Decode(&membersList)
 	if err != nil {
-		log.Fatalf("error decoding members: %v", err)
 		return err
 	}
 	a.Members = membersList
@@ -359,83 +327,284 @@ func (b *Debug) DecodeMetricsIndex(indexDecoder *json.Decoder) error {
 	var index Index
 	err := indexDecoder.Decode(&index)
 	if err != nil {
-		log.Fatalf("error decoding metrics: %v", err)
 		return err
 	}
 	b.Index = index
 	return nil
 }
 
-func (b *Debug) DecodeMetrics(metricsDecoder *json.Decoder) error {
-	var err error
-	captures, err := b.numberOfCaptures()
+// DecodeMetrics decodes the stream of capture objects of metrics.json. Damaged captures, such as
+// the last capture of a bundle killed mid-capture, are skipped up to the start of the next capture
+// and recorded in Metrics.Damaged along with their byte offset, so analysis proceeds with every
+// complete capture.
+func (b *Debug) DecodeMetrics(r io.Reader) error {
+	captures, damaged, err := DecodeMetricCaptures(r)
 	if err != nil {
 		return err
 	}
-	b.Metrics.Metrics = make([]Metric, captures)
-	for i := 0; i < captures; i++ {
-		var metric Metric
-		err = metricsDecoder.Decode(&metric)
+	b.Metrics.Metrics, b.Metrics.Damaged = captures, damaged
+	b.Metrics.WarnDamaged()
+	b.BuildMetricsIndex()
+	return nil
+}
+
+// WarnDamaged logs a warning listing the damaged captures skipped from metrics.json, if any.
+func (m *Metrics) WarnDamaged() {
+	if len(m.Damaged) == 0 {
+		return
+	}
+	offsets := make([]string, 0, len(m.Damaged))
+	for _, d := range m.Damaged {
+		offsets = append(offsets, strconv.FormatInt(d.Offset, 10))
+	}
+	hclog.L().Warn("metrics.json is damaged, skipped unreadable captures",
+		"recovered", len(m.Metrics), "damaged", len(m.Damaged), "offsets", strings.Join(offsets, ","),
+		"error", m.Damaged[0].Err)
+}
+
+// captureStartReg matches the start of a metrics.json capture object.
+var captureStartReg = regexp.MustCompile(`\{\s*"Timestamp"\s*:`)
+
+// DamagedCapture is a stretch of metrics.json that did not decode to a capture.
+type DamagedCapture struct {
+	// Offset is the byte offset of the damaged capture in metrics.json.
+	Offset int64
+	// Length is the number of bytes skipped to reach the next capture or the end of the file.
+	Length int64
+	// Truncated reports whether the capture was cut short by the end of the file.
+	Truncated bool
+	Err       error
+}
+
+// DecodeMetricCaptures decodes every complete capture object of r. Captures that fail to decode,
+// or decode without a Timestamp, are skipped up to the next capture object.
+func DecodeMetricCaptures(r io.Reader) ([]Metric, []DamagedCapture, error) {
+	var captures []Metric
+	damaged, err := ScanMetricCaptures(r, func(m Metric) { captures = append(captures, m) })
+	return captures, damaged, err
+}
+
+// ScanMetricCaptures is DecodeMetricCaptures, streaming each complete capture to fn instead of
+// collecting them. Only failures to read r are returned as an error.
+func ScanMetricCaptures(r io.Reader, fn func(Metric)) ([]DamagedCapture, error) {
+	var damaged []DamagedCapture
+	s := &captureStream{r: bufio.NewReader(r)}
+	for {
+		// A decoder stops at its first syntax error, so each run of intact captures has its own.
+		dec := json.NewDecoder(s)
+		var err error
+		for err == nil {
+			var raw json.RawMessage
+			if err = dec.Decode(&raw); err == io.EOF {
+				return damaged, nil
+			}
+			var metric Metric
+			if err == nil {
+				if err = json.Unmarshal(raw, &metric); err == nil && metric.Timestamp == "" {
+					err = errors.New("capture has no Timestamp")
+				}
+				if err != nil {
+					// Resync from the start of the capture rather than after it.
+					s.unread(bytes.NewReader(raw), dec.Buffered())
+					break
+				}
+				fn(metric)
+				continue
+			}
+			var syntaxErr *json.SyntaxError
+			if !errors.As(err, &syntaxErr) && !errors.Is(err, io.ErrUnexpectedEOF) {
+				return damaged, err
+			}
+			s.unread(dec.Buffered())
+		}
+		if serr := s.skipSpace(); serr != nil {
+			return damaged, serr
+		}
+		offset := s.offset
+		if serr := s.skipToCapture(); serr != nil {
+			return damaged, serr
+		}
+		damaged = append(damaged, DamagedCapture{
+			Offset:    offset,
+			Length:    s.offset - offset,
+			Truncated: errors.Is(err, io.ErrUnexpectedEOF),
+			Err:       err,
+		})
+	}
+}
+
+// captureStream reads metrics.json, tracking the offset read up to, and takes back bytes read
+// ahead of a damaged capture so it can be skipped.
+type captureStream struct {
+	r       io.Reader
+	pending []byte
+	offset  int64
+}
+
+func (s *captureStream) Read(p []byte) (int, error) {
+	var n int
+	var err error
+	if len(s.pending) > 0 {
+		n = copy(p, s.pending)
+		s.pending = s.pending[n:]
+	} else {
+		n, err = s.r.Read(p)
+	}
+	s.offset += int64(n)
+	return n, err
+}
+
+// unread puts back the bytes read from readers, in order, ahead of those not read yet.
+func (s *captureStream) unread(readers ...io.Reader) {
+	var back bytes.Buffer
+	for _, r := range readers {
+		_, _ = back.ReadFrom(r)
+	}
+	if back.Len() == 0 {
+		return
+	}
+	s.offset -= int64(back.Len())
+	s.pending = append(back.Bytes(), s.pending...)
+}
+
+// unreadBytes puts back b ahead of the bytes not read yet.
+func (s *captureStream) unreadBytes(b []byte) {
+	s.unread(bytes.NewReader(b))
+}
+
+// skipSpace discards whitespace up to the next byte of the stream.
+func (s *captureStream) skipSpace() error {
+	buf := make([]byte, 4096)
+	for {
+		n, err := s.Read(buf)
+		for i := 0; i < n; i++ {
+			if !isJSONSpace(buf[i]) {
+				s.unreadBytes(buf[i:n])
+				return nil
+			}
+		}
 		if err == io.EOF {
-			break
+			return nil
 		}
 		if err != nil {
-			return fmt.Errorf("error decoding | file: metrics.json %v", err)
+			return err
 		}
-		// Assign the Metrics to the Debug struct
-		b.Metrics.Metrics[i] = metric
 	}
-	b.BuildMetricsIndex()
-	return nil
 }
 
-func (b *Debug) DecodeJSON(debugPath, dataType string) error {
-	configs := map[string]string{
-		"agent":   "agent.json",
-		"members": "members.json",
-		"metrics": "metrics.json",
-		"host":    "host.json",
-		"index":   "index.json",
+// captureStartPeek bounds how far after a { a capture's "Timestamp" field is looked for, so that
+// a capture start split across reads is still found.
+const captureStartPeek = 256
+
+// skipToCapture discards the first byte of the stream and the bytes after it up to the start of
+// the next capture object, or the end of the stream.
+func (s *captureStream) skipToCapture() error {
+	buf := make([]byte, 32*1024)
+	var carry []byte
+	first := true
+	for {
+		n, err := s.Read(buf)
+		window := append(carry, buf[:n]...)
+		from := 0
+		if first && len(window) > 0 {
+			from, first = 1, false
+		}
+		if loc := captureStartReg.FindIndex(window[from:]); loc != nil {
+			s.unreadBytes(window[from+loc[0]:])
+			return nil
+		}
+		// Keep a trailing { whose "Timestamp" may be in the next read.
+		carry = nil
+		if i := bytes.LastIndexByte(window[from:], '{'); i >= 0 && len(window)-(from+i) < captureStartPeek {
+			carry = append([]byte(nil), window[from+i:]...)
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
 	}
+}
 
-	fileName, found := configs[dataType]
+func isJSONSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+// bundleFileNames maps the data types of DecodeJSON to their bundle files.
+var bundleFileNames = map[string]string{
+	"agent":   "agent.json",
+	"members": "members.json",
+	"metrics": "metrics.json",
+	"host":    "host.json",
+	"index":   "index.json",
+}
+
+// NewContextReader returns a reader of r that fails with ctx.Err() once ctx is done.
+func NewContextReader(ctx context.Context, r io.Reader) io.Reader {
+	return contextReader{ctx: ctx, r: r}
+}
+
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (c contextReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.r.Read(p)
+}
+
+func (b *Debug) DecodeJSON(debugPath, dataType string) error {
+	fileName, found := bundleFileNames[dataType]
 	if !found {
 		return fmt.Errorf("unknown data type: %s", dataType)
 	}
 
-	return b.decodeFile(debugPath, fileName, dataType)
+	return b.decodeFile(context.Background(), debugPath, fileName, dataType)
 }
 
-func (b *Debug) decodeFile(debugPath, fileName, dataType string) error {
-	filePath := fmt.Sprintf("%s/%s", debugPath, fileName)
+// DecodeJSONContext is DecodeJSON, abandoning the decode with ctx.Err() once ctx is done.
+func (b *Debug) DecodeJSONContext(ctx context.Context, debugPath, dataType string) error {
+	fileName, found := bundleFileNames[dataType]
+	if !found {
+		return fmt.Errorf("unknown data type: %s", dataType)
+	}
+	return b.decodeFile(ctx, debugPath, fileName, dataType)
+}
 
-	// Read the entire file into memory
-	fileData, err := os.Open(filePath)
+// decodeFile decodes a bundle file, returning any failure as a *DecodeError.
+func (b *Debug) decodeFile(ctx context.Context, debugPath, fileName, dataType string) error {
+	file, err := os.Open(filepath.Join(debugPath, fileName))
 	if err != nil {
-		if os.IsNotExist(err) {
-			return fmt.Errorf("file not found: %s. Ensure debug-path is set to a valid path\n", filePath)
-		} else {
-			return fmt.Errorf("error reading file: %s - %v\n", filePath, err)
-		}
+		return newDecodeError(debugPath, fileName, err)
 	}
+	defer file.Close()
+	fileData := NewContextReader(ctx, file)
 	// Create a JSON decoder for the file data
 	decoder := json.NewDecoder(fileData)
 
 	// Decode JSON based on the data type
 	switch dataType {
 	case "agent":
-		return b.DecodeAgent(decoder)
+		err = b.DecodeAgent(decoder)
 	case "members":
-		return b.Agent.DecodeMembers(decoder)
+		err = b.Agent.DecodeMembers(decoder)
 	case "metrics":
-		return b.DecodeMetrics(decoder)
+		err = b.DecodeMetrics(fileData)
 	case "host":
-		return b.DecodeHost(decoder)
+		err = b.DecodeHost(decoder)
 	case "index":
-		return b.DecodeMetricsIndex(decoder)
+		err = b.DecodeMetricsIndex(decoder)
 	default:
 		return fmt.Errorf("unknown data type: %s", dataType)
 	}
+	if err != nil {
+		return newDecodeError(debugPath, fileName, err)
+	}
+	return nil
 }
 
 type ByValue []string
@@ -483,11 +652,11 @@ func CalculateGCRate(value, prev map[string]interface{}) (string, error) {
 	// Calculate the non-negative difference in GC pause times
 	diff := nonNegativeDifference(currentValue, previousValue)
 
-	timeCurrent, err := time.Parse("2006-01-02 15:04:05 -0700 MST", fmt.Sprintf("%s", value["timestamp"]))
+	timeCurrent, err := time.Parse(MetricsTimestampLayout, fmt.Sprintf("%s", value["timestamp"]))
 	if err != nil {
 		return "", err
 	}
-	timePrevious, err := time.Parse("2006-01-02 15:04:05 -0700 MST", fmt.Sprintf("%s", prev["timestamp"]))
+	timePrevious, err := time.Parse(MetricsTimestampLayout, fmt.Sprintf("%s", prev["timestamp"]))
 	if err != nil {
 		return "", err
 	}
@@ -708,8 +877,7 @@ func (h HoursConverter) Convert(timeValue interface{}) (string, error) {
 
 func validateName(name string, info string) bool {
 	// This metric name is dynamic and can be anything that the customer uses for service names
-	reg := regexp.MustCompile(`^consul\.proxy\..+$`)
-	if reg.MatchString(name) {
+	if meshProxyMetricReg.MatchString(name) {
 		fmt.Printf("built-in mesh proxy prefix used: %s\n", name)
 		return true
 	}
@@ -0,0 +1,57 @@
+package read
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestDecodeMetricCaptures(t *testing.T) {
+	capture := func(second string) string {
+		return `{"Timestamp":"2024-02-07 12:40:` + second + ` -0500 EST","Gauges":[{"Name":"consul.runtime.num_goroutines","Value":120}]}` + "\n"
+	}
+	corrupt := `{"Timestamp":"2024-02-07 12:40:10 -0500 EST","Gauges":[{"Name":"consul.runtime.num_goroutines","Value":x}]}` + "\n"
+	truncated := `{"Timestamp":"2024-02-07 12:40:40 -0500 EST","Gau`
+	data := capture("00") + corrupt + capture("20") + "{}\n" + capture("30") + truncated
+
+	// One byte at a time, damaged captures are resynced across reads.
+	for _, r := range []io.Reader{strings.NewReader(data), iotest.OneByteReader(strings.NewReader(data))} {
+		captures, damaged, err := DecodeMetricCaptures(r)
+		if err != nil {
+			t.Fatalf("DecodeMetricCaptures: %v", err)
+		}
+		var seconds []string
+		for _, c := range captures {
+			seconds = append(seconds, c.Timestamp[17:19])
+		}
+		if strings.Join(seconds, ",") != "00,20,30" {
+			t.Errorf("expected captures 00,20,30 to be recovered, got %v", seconds)
+		}
+		if len(damaged) != 3 {
+			t.Fatalf("expected 3 damaged captures, got %+v", damaged)
+		}
+		if damaged[0].Offset != int64(len(capture("00"))) || damaged[0].Truncated {
+			t.Errorf("expected the corrupt capture at offset %d, got %+v", len(capture("00")), damaged[0])
+		}
+		if !strings.Contains(damaged[1].Err.Error(), "no Timestamp") {
+			t.Errorf("expected the empty capture to be reported, got %v", damaged[1].Err)
+		}
+		last := damaged[2]
+		if !last.Truncated || last.Offset != int64(strings.LastIndex(data, "{")) || last.Length != int64(len(truncated)) {
+			t.Errorf("expected the last capture to be truncated, got %+v", last)
+		}
+	}
+	if _, _, err := DecodeMetricCaptures(iotest.TimeoutReader(strings.NewReader(data))); !errors.Is(err, iotest.ErrTimeout) {
+		t.Errorf("expected a read failure to be returned, got %v", err)
+	}
+
+	var b Debug
+	if err := b.DecodeMetrics(strings.NewReader(truncated)); err != nil {
+		t.Fatalf("DecodeMetrics: %v", err)
+	}
+	if len(b.Metrics.Metrics) != 0 || !strings.Contains(b.Summary(), "Capture Time Start: n/a") {
+		t.Errorf("expected a summary without captures, got:\n%s", b.Summary())
+	}
+}
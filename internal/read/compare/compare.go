@@ -0,0 +1,447 @@
+package compare
+
+import (
+	"consul-debug-read/internal/read"
+	"encoding/json"
+	"fmt"
+	"github.com/ryanuber/columnize"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Sections of a bundle comparison, in report order.
+const (
+	SectionVersion = "version"
+	SectionConfig  = "config"
+	SectionRaft    = "raft"
+	SectionMembers = "members"
+	SectionHost    = "host"
+	SectionMetrics = "metrics"
+)
+
+// Sections lists every comparison section in report order.
+var Sections = []string{SectionVersion, SectionConfig, SectionRaft, SectionMembers, SectionHost, SectionMetrics}
+
+const (
+	// metricRegressionRatio is the change in the worse direction at which a key metric regressed.
+	metricRegressionRatio = 0.2
+	// hostRegressionPoints is the increase in memory or disk used percent at which the host regressed.
+	hostRegressionPoints = 10.0
+)
+
+// lowerIsWorse lists key metrics whose decrease, rather than increase, is a regression.
+var lowerIsWorse = map[string]bool{
+	"consul.autopilot.healthy":             true,
+	"consul.autopilot.failure_tolerance":   true,
+	"consul.mesh.active-root-ca.expiry":    true,
+	"consul.mesh.active-signing-ca.expiry": true,
+	"consul.agent.tls.cert.expiry":         true,
+	"consul.server.isLeader":               true,
+}
+
+// Difference is one field that differs between a baseline and an incident bundle.
+type Difference struct {
+	Section  string `json:"section"`
+	Field    string `json:"field"`
+	Baseline string `json:"baseline"`
+	Incident string `json:"incident"`
+	// Change is the percentage change of numeric values, when meaningful.
+	Change     string `json:"change,omitempty"`
+	Regression bool   `json:"regression"`
+}
+
+// Bundles compares a baseline bundle with an incident bundle. Key metrics are compared by name
+// across all groups of keyMetrics.
+func Bundles(baseline, incident *read.Debug, keyMetrics map[string][]string) ([]Difference, error) {
+	var diffs []Difference
+	diffs = append(diffs, versionDiffs(baseline, incident)...)
+
+	configDiffs, err := configDiffs(baseline, incident)
+	if err != nil {
+		return nil, err
+	}
+	diffs = append(diffs, configDiffs...)
+
+	raftDiffs, err := raftDiffs(baseline, incident)
+	if err != nil {
+		return nil, err
+	}
+	diffs = append(diffs, raftDiffs...)
+	diffs = append(diffs, memberDiffs(baseline, incident)...)
+	diffs = append(diffs, hostDiffs(baseline, incident)...)
+	diffs = append(diffs, metricDiffs(baseline, incident, keyMetrics)...)
+	return diffs, nil
+}
+
+func versionDiffs(baseline, incident *read.Debug) []Difference {
+	var diffs []Difference
+	add := func(field, b, i string) {
+		if b != i {
+			diffs = append(diffs, Difference{Section: SectionVersion, Field: field, Baseline: b, Incident: i})
+		}
+	}
+	add("Config.Version", baseline.Agent.Config.Version, incident.Agent.Config.Version)
+	add("Config.Revision", baseline.Agent.Config.Revision, incident.Agent.Config.Revision)
+	add("Index.AgentVersion", baseline.Index.AgentVersion, incident.Index.AgentVersion)
+	add("Stats.Runtime.Version", baseline.Agent.Stats.Runtime.Version, incident.Agent.Stats.Runtime.Version)
+	return diffs
+}
+
+func configDiffs(baseline, incident *read.Debug) ([]Difference, error) {
+	b, err := FlattenConfig(baseline.Agent.DebugConfig)
+	if err != nil {
+		return nil, err
+	}
+	i, err := FlattenConfig(incident.Agent.DebugConfig)
+	if err != nil {
+		return nil, err
+	}
+	var diffs []Difference
+	for _, key := range unionKeys(b, i) {
+		if b[key] != i[key] {
+			diffs = append(diffs, Difference{Section: SectionConfig, Field: key, Baseline: orNone(b[key]), Incident: orNone(i[key])})
+		}
+	}
+	return diffs, nil
+}
+
+func raftDiffs(baseline, incident *read.Debug) ([]Difference, error) {
+	var diffs []Difference
+	if baseline.Agent.Stats.Consul.LeaderAddr != incident.Agent.Stats.Consul.LeaderAddr {
+		diffs = append(diffs, Difference{
+			Section:    SectionRaft,
+			Field:      "leader",
+			Baseline:   orNone(baseline.Agent.Stats.Consul.LeaderAddr),
+			Incident:   orNone(incident.Agent.Stats.Consul.LeaderAddr),
+			Regression: incident.Agent.Stats.Consul.LeaderAddr == "",
+		})
+	}
+	if baseline.Agent.Stats.Raft.State != incident.Agent.Stats.Raft.State {
+		diffs = append(diffs, Difference{Section: SectionRaft, Field: "state", Baseline: baseline.Agent.Stats.Raft.State, Incident: incident.Agent.Stats.Raft.State})
+	}
+	if !baseline.Agent.Config.Server || !incident.Agent.Config.Server {
+		return diffs, nil
+	}
+
+	peers := func(b *read.Debug) (map[string]read.RaftPeer, error) {
+		list, err := b.RaftPeers()
+		if err != nil {
+			return nil, err
+		}
+		m := make(map[string]read.RaftPeer, len(list))
+		for _, p := range list {
+			m[peerName(p)] = p
+		}
+		return m, nil
+	}
+	b, err := peers(baseline)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read baseline raft configuration: %v", err)
+	}
+	i, err := peers(incident)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read incident raft configuration: %v", err)
+	}
+	for _, name := range unionPeerNames(b, i) {
+		bp, inBase := b[name]
+		ip, inIncident := i[name]
+		switch {
+		case !inIncident:
+			diffs = append(diffs, Difference{Section: SectionRaft, Field: "peer " + name, Baseline: describePeer(bp), Incident: "<removed>", Regression: true})
+		case !inBase:
+			diffs = append(diffs, Difference{Section: SectionRaft, Field: "peer " + name, Baseline: "<none>", Incident: describePeer(ip)})
+		case bp.Voter != ip.Voter || bp.Address != ip.Address:
+			diffs = append(diffs, Difference{Section: SectionRaft, Field: "peer " + name, Baseline: describePeer(bp), Incident: describePeer(ip), Regression: bp.Voter && !ip.Voter})
+		}
+	}
+	if b, i := baseline.Agent.Stats.Raft.NumPeers, incident.Agent.Stats.Raft.NumPeers; b != i {
+		diffs = append(diffs, Difference{Section: SectionRaft, Field: "num_peers", Baseline: b, Incident: i, Change: percentChange(b, i), Regression: parseFloat(i) < parseFloat(b)})
+	}
+	return diffs, nil
+}
+
+func memberDiffs(baseline, incident *read.Debug) []Difference {
+	members := func(b *read.Debug) map[string]read.MemberSummary {
+		m := make(map[string]read.MemberSummary)
+		for _, s := range b.Agent.MemberSummaries() {
+			m[s.Node] = s
+		}
+		return m
+	}
+	b, i := members(baseline), members(incident)
+	names := make(map[string]struct{})
+	for n := range b {
+		names[n] = struct{}{}
+	}
+	for n := range i {
+		names[n] = struct{}{}
+	}
+	var diffs []Difference
+	for _, name := range sortedKeys(names) {
+		bm, inBase := b[name]
+		im, inIncident := i[name]
+		switch {
+		case !inIncident:
+			diffs = append(diffs, Difference{Section: SectionMembers, Field: name, Baseline: bm.Status, Incident: "<missing>", Regression: bm.Status == "Alive"})
+		case !inBase:
+			diffs = append(diffs, Difference{Section: SectionMembers, Field: name, Baseline: "<missing>", Incident: im.Status})
+		case bm.Status != im.Status:
+			diffs = append(diffs, Difference{Section: SectionMembers, Field: name, Baseline: bm.Status, Incident: im.Status, Regression: bm.Status == "Alive"})
+		case bm.Build != im.Build:
+			diffs = append(diffs, Difference{Section: SectionMembers, Field: name + " build", Baseline: bm.Build, Incident: im.Build})
+		}
+	}
+	return diffs
+}
+
+func hostDiffs(baseline, incident *read.Debug) []Difference {
+	var diffs []Difference
+	b, i := baseline.Host, incident.Host
+	if len(b.CPU) != len(i.CPU) {
+		diffs = append(diffs, Difference{Section: SectionHost, Field: "cpu cores",
+			Baseline: strconv.Itoa(len(b.CPU)), Incident: strconv.Itoa(len(i.CPU)), Regression: len(i.CPU) < len(b.CPU)})
+	}
+	conv := read.ByteConverter{}
+	if b.Memory.Total != i.Memory.Total {
+		diffs = append(diffs, Difference{Section: SectionHost, Field: "memory total",
+			Baseline: conv.ConvertToReadableBytes(b.Memory.Total), Incident: conv.ConvertToReadableBytes(i.Memory.Total),
+			Change: percentChangeFloat(b.Memory.Total, i.Memory.Total), Regression: i.Memory.Total < b.Memory.Total})
+	}
+	usage := func(field string, before, after float64) {
+		if math.Abs(after-before) < 0.01 {
+			return
+		}
+		diffs = append(diffs, Difference{Section: SectionHost, Field: field,
+			Baseline: fmt.Sprintf("%.2f%%", before), Incident: fmt.Sprintf("%.2f%%", after),
+			Change: fmt.Sprintf("%+.2f pts", after-before), Regression: after-before >= hostRegressionPoints})
+	}
+	usage("memory used", b.Memory.UsedPercent, i.Memory.UsedPercent)
+	usage("disk used", b.Disk.UsedPercent, i.Disk.UsedPercent)
+	if b.Host.Procs != i.Host.Procs {
+		diffs = append(diffs, Difference{Section: SectionHost, Field: "processes",
+			Baseline: strconv.Itoa(b.Host.Procs), Incident: strconv.Itoa(i.Host.Procs),
+			Change: percentChangeFloat(float64(b.Host.Procs), float64(i.Host.Procs))})
+	}
+	return diffs
+}
+
+// metricDiffs compares the mean and max of every key metric captured by either bundle.
+func metricDiffs(baseline, incident *read.Debug, keyMetrics map[string][]string) []Difference {
+	names := make(map[string]struct{})
+	for _, group := range keyMetrics {
+		for _, n := range group {
+			names[n] = struct{}{}
+		}
+	}
+	var diffs []Difference
+	for _, name := range sortedKeys(names) {
+		bs, bok := MetricStats(baseline.Metrics, name)
+		is, iok := MetricStats(incident.Metrics, name)
+		if !bok && !iok {
+			continue
+		}
+		for _, stat := range []struct {
+			label string
+			b, i  float64
+		}{{"mean", bs.Mean, is.Mean}, {"max", bs.Max, is.Max}} {
+			d := Difference{Section: SectionMetrics, Field: fmt.Sprintf("%s (%s)", name, stat.label), Baseline: "<none>", Incident: "<none>"}
+			if bok {
+				d.Baseline = formatFloat(stat.b)
+			}
+			if iok {
+				d.Incident = formatFloat(stat.i)
+			}
+			if bok && iok {
+				if stat.b == stat.i {
+					continue
+				}
+				d.Change = percentChangeFloat(stat.b, stat.i)
+				d.Regression = regressed(name, stat.b, stat.i)
+			}
+			diffs = append(diffs, d)
+		}
+	}
+	return diffs
+}
+
+// Stats summarizes every captured value of a metric across all of its series.
+type Stats struct {
+	Mean    float64
+	Max     float64
+	Last    float64
+	Samples int
+}
+
+// MetricStats returns the mean, max and last captured value of the named metric.
+func MetricStats(metrics read.Metrics, name string) (Stats, bool) {
+	series, err := metrics.SelectFunc(func(n string) bool { return n == name }, &read.MetricSelector{})
+	if err != nil || len(series) == 0 {
+		return Stats{}, false
+	}
+	var s Stats
+	var sum float64
+	s.Max = math.Inf(-1)
+	for _, ser := range series {
+		for _, sample := range ser.Samples {
+			sum += sample.Value
+			s.Samples++
+			if sample.Value > s.Max {
+				s.Max = sample.Value
+			}
+		}
+		if n := len(ser.Samples); n > 0 {
+			s.Last = ser.Samples[n-1].Value
+		}
+	}
+	if s.Samples == 0 {
+		return Stats{}, false
+	}
+	s.Mean = sum / float64(s.Samples)
+	return s, true
+}
+
+func regressed(name string, before, after float64) bool {
+	change := (after - before) / math.Max(math.Abs(before), 1e-9)
+	if lowerIsWorse[name] {
+		return change <= -metricRegressionRatio
+	}
+	return change >= metricRegressionRatio
+}
+
+// FlattenConfig flattens a configuration struct into dotted field paths and their JSON values.
+func FlattenConfig(config interface{}) (map[string]string, error) {
+	raw, err := json.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+	var tree interface{}
+	if err = json.Unmarshal(raw, &tree); err != nil {
+		return nil, err
+	}
+	flat := make(map[string]string)
+	read.FlattenJSON("", tree, flat)
+	return flat, nil
+}
+
+func peerName(p read.RaftPeer) string {
+	if p.Node != "" {
+		return p.Node
+	}
+	return p.ID
+}
+
+func describePeer(p read.RaftPeer) string {
+	voter := "voter"
+	if !p.Voter {
+		voter = "non-voter"
+	}
+	return fmt.Sprintf("%s %s", p.Address, voter)
+}
+
+func unionPeerNames(a, b map[string]read.RaftPeer) []string {
+	names := make(map[string]struct{})
+	for n := range a {
+		names[n] = struct{}{}
+	}
+	for n := range b {
+		names[n] = struct{}{}
+	}
+	return sortedKeys(names)
+}
+
+func unionKeys(a, b map[string]string) []string {
+	keys := make(map[string]struct{}, len(a))
+	for k := range a {
+		keys[k] = struct{}{}
+	}
+	for k := range b {
+		keys[k] = struct{}{}
+	}
+	return sortedKeys(keys)
+}
+
+func sortedKeys(m map[string]struct{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func percentChange(before, after string) string {
+	b, berr := strconv.ParseFloat(before, 64)
+	a, aerr := strconv.ParseFloat(after, 64)
+	if berr != nil || aerr != nil {
+		return ""
+	}
+	return percentChangeFloat(b, a)
+}
+
+func percentChangeFloat(before, after float64) string {
+	if before == 0 {
+		if after == 0 {
+			return "0%"
+		}
+		return "new"
+	}
+	return fmt.Sprintf("%+.1f%%", (after-before)/math.Abs(before)*100)
+}
+
+func parseFloat(s string) float64 {
+	f, _ := strconv.ParseFloat(s, 64)
+	return f
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "<none>"
+	}
+	return s
+}
+
+// Summary renders differences grouped by section, marking regressions with '!'.
+func Summary(diffs []Difference, baselineName, incidentName string) string {
+	if len(diffs) == 0 {
+		return "no differences found between bundles"
+	}
+	var out []string
+	section := ""
+	var rows []string
+	flush := func() {
+		if len(rows) > 0 {
+			title := strings.ToUpper(section[:1]) + section[1:] + ":"
+			out = append(out, fmt.Sprintf("%s\n%s\n%s", title, strings.Repeat("-", len(title)),
+				formatRows(rows)))
+		}
+		rows = nil
+	}
+	for _, d := range diffs {
+		if d.Section != section {
+			flush()
+			section = d.Section
+			rows = []string{fmt.Sprintf(" \x1fField\x1f%s\x1f%s\x1fChange", baselineName, incidentName)}
+		}
+		marker := " "
+		if d.Regression {
+			marker = "!"
+		}
+		change := d.Change
+		if change == "" {
+			change = "-"
+		}
+		rows = append(rows, fmt.Sprintf("%s\x1f%s\x1f%s\x1f%s\x1f%s", marker, d.Field, d.Baseline, d.Incident, change))
+	}
+	flush()
+	return strings.Join(out, "\n\n")
+}
+
+func formatRows(rows []string) string {
+	return columnize.Format(rows, &columnize.Config{Delim: string([]byte{0x1f}), Glue: " "})
+}
@@ -0,0 +1,59 @@
+package compare
+
+import (
+	"testing"
+)
+
+func TestFlattenConfig(t *testing.T) {
+	config := struct {
+		Datacenter string
+		Ports      map[string]int
+		RetryJoin  []string
+		Empty      []string
+		Token      *string
+	}{
+		Datacenter: "dc1",
+		Ports:      map[string]int{"DNS": 8600},
+		RetryJoin:  []string{"10.0.0.1", "10.0.0.2"},
+		Empty:      []string{},
+	}
+	flat, err := FlattenConfig(config)
+	if err != nil {
+		t.Fatalf("FlattenConfig: %v", err)
+	}
+	want := map[string]string{
+		"Datacenter":   `"dc1"`,
+		"Ports.DNS":    "8600",
+		"RetryJoin[0]": `"10.0.0.1"`,
+		"RetryJoin[1]": `"10.0.0.2"`,
+		"Empty":        "[]",
+		"Token":        "null",
+	}
+	if len(flat) != len(want) {
+		t.Fatalf("expected %d fields, got %d: %v", len(want), len(flat), flat)
+	}
+	for k, v := range want {
+		if flat[k] != v {
+			t.Errorf("%s: expected %s, got %s", k, v, flat[k])
+		}
+	}
+}
+
+func TestRegressed(t *testing.T) {
+	cases := []struct {
+		name          string
+		before, after float64
+		want          bool
+	}{
+		{"consul.raft.commitTime", 10, 11, false},
+		{"consul.raft.commitTime", 10, 15, true},
+		{"consul.raft.commitTime", 10, 2, false},
+		{"consul.autopilot.healthy", 1, 0, true},
+		{"consul.autopilot.healthy", 0, 1, false},
+	}
+	for _, c := range cases {
+		if got := regressed(c.name, c.before, c.after); got != c.want {
+			t.Errorf("regressed(%s, %v, %v) = %v, want %v", c.name, c.before, c.after, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package read
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// DecodeError is returned when a bundle file cannot be read or decoded. It wraps the underlying
+// error, so callers can still match os.ErrNotExist, *json.SyntaxError or *json.UnmarshalTypeError.
+type DecodeError struct {
+	// Path is the extracted bundle directory.
+	Path string
+	// File is the bundle file that failed, e.g. agent.json.
+	File string
+	// Offset is the byte offset in File at which decoding failed, or -1 when unknown.
+	Offset int64
+	// Field is the dotted path of the JSON field holding a value of the wrong type, if any.
+	Field string
+	Err   error
+}
+
+func newDecodeError(path, file string, err error) *DecodeError {
+	e := &DecodeError{Path: path, File: file, Offset: -1, Err: err}
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		e.Offset = syntaxErr.Offset
+	case errors.As(err, &typeErr):
+		e.Offset, e.Field = typeErr.Offset, typeErr.Field
+	}
+	return e
+}
+
+func (e *DecodeError) Error() string {
+	msg := fmt.Sprintf("failed to decode %s of bundle %s", e.File, e.Path)
+	if e.Offset >= 0 {
+		msg += fmt.Sprintf(" at byte %d", e.Offset)
+	}
+	if e.Field != "" {
+		msg += fmt.Sprintf(" (field %s)", e.Field)
+	}
+	return msg + ": " + e.Err.Error()
+}
+
+func (e *DecodeError) Unwrap() error { return e.Err }
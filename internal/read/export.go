@@ -0,0 +1,253 @@
+package read
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/hashicorp/go-hclog"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	invalidMetricNameChars = regexp.MustCompile(`[^a-zA-Z0-9_:]`)
+	invalidLabelNameChars  = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+	labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+)
+
+// SanitizeMetricName converts a Consul metric name into a valid Prometheus metric name.
+func SanitizeMetricName(name string) string {
+	name = invalidMetricNameChars.ReplaceAllString(name, "_")
+	if name != "" && name[0] >= '0' && name[0] <= '9' {
+		name = "_" + name
+	}
+	return name
+}
+
+// SanitizeLabelName converts a Consul label key into a valid Prometheus label name.
+func SanitizeLabelName(name string) string {
+	name = invalidLabelNameChars.ReplaceAllString(name, "_")
+	if name != "" && name[0] >= '0' && name[0] <= '9' {
+		name = "_" + name
+	}
+	return name
+}
+
+// openMetricsFamily is every exported series of a single metric name.
+type openMetricsFamily struct {
+	name       string
+	original   string
+	metricType string
+	series     map[string]*openMetricsSeries
+}
+
+type openMetricsSeries struct {
+	labels string
+	lines  []string
+
+	// running totals, metrics.json counters and samples only cover a single interval
+	count float64
+	sum   float64
+}
+
+// WriteOpenMetrics writes every metrics capture of the bundle in the OpenMetrics text format,
+// suitable for backfilling with `promtool tsdb create-blocks-from openmetrics`.
+//
+// Gauges and points are exported as gauges. Counters are accumulated across captures into a
+// monotonic <name>_total counter, and samples into a <name>_count/<name>_sum summary. Metrics
+// whose sanitized names collide, such as a-b and a.b, are exported as a_b and a_b_2.
+func (b *Debug) WriteOpenMetrics(w io.Writer) error {
+	families := make(map[string]*openMetricsFamily)
+	byMetric := make(map[string]*openMetricsFamily)
+
+	family := func(name, metricType string) *openMetricsFamily {
+		key := metricType + " " + name
+		if f, ok := byMetric[key]; ok {
+			return f
+		}
+		sanitized := SanitizeMetricName(name)
+		if metricType == "counter" {
+			sanitized = strings.TrimSuffix(sanitized, "_total")
+		}
+		// Keep metrics whose names only differ in sanitized characters apart.
+		unique := sanitized
+		for i := 2; families[unique] != nil; i++ {
+			unique = fmt.Sprintf("%s_%d", sanitized, i)
+		}
+		if unique != sanitized {
+			hclog.L().Warn("metric name collides with another once sanitized, exported under a suffixed name",
+				"metric", name, "type", metricType, "conflicts_with", families[sanitized].original, "name", unique)
+		}
+		f := &openMetricsFamily{name: unique, original: name, metricType: metricType, series: make(map[string]*openMetricsSeries)}
+		families[unique], byMetric[key] = f, f
+		return f
+	}
+	series := func(f *openMetricsFamily, labels map[string]string) *openMetricsSeries {
+		key := formatOpenMetricsLabels(labels)
+		s, ok := f.series[key]
+		if !ok {
+			s = &openMetricsSeries{labels: key}
+			f.series[key] = s
+		}
+		return s
+	}
+
+	for _, capture := range b.Metrics.Metrics {
+		parsed, err := ParseMetricTimestamp(capture.Timestamp)
+		if err != nil {
+			return fmt.Errorf("invalid metrics capture timestamp %q: %v", capture.Timestamp, err)
+		}
+		ts := strconv.FormatInt(parsed.Unix(), 10)
+
+		for _, g := range capture.Gauges {
+			f := family(g.Name, "gauge")
+			s := series(f, g.Labels)
+			s.lines = append(s.lines, fmt.Sprintf("%s%s %s %s", f.name, s.labels, formatExportValue(g.Value), ts))
+		}
+		for _, p := range capture.Points {
+			f := family(p.Name, "gauge")
+			s := series(f, p.Labels)
+			s.lines = append(s.lines, fmt.Sprintf("%s%s %s %s", f.name, s.labels, formatExportValue(p.Points), ts))
+		}
+		for _, c := range capture.Counters {
+			f := family(c.Name, "counter")
+			s := series(f, c.Labels)
+			s.sum += c.Sum
+			s.lines = append(s.lines, fmt.Sprintf("%s_total%s %s %s", f.name, s.labels, formatExportValue(s.sum), ts))
+		}
+		for _, sample := range capture.Samples {
+			f := family(sample.Name, "summary")
+			s := series(f, sample.Labels)
+			s.count += float64(sample.Count)
+			s.sum += sample.Sum
+			s.lines = append(s.lines,
+				fmt.Sprintf("%s_count%s %s %s", f.name, s.labels, formatExportValue(s.count), ts),
+				fmt.Sprintf("%s_sum%s %s %s", f.name, s.labels, formatExportValue(s.sum), ts))
+		}
+	}
+
+	names := make([]string, 0, len(families))
+	for name := range families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	bw := bufio.NewWriter(w)
+	for _, name := range names {
+		f := families[name]
+		fmt.Fprintf(bw, "# TYPE %s %s\n", f.name, f.metricType)
+		fmt.Fprintf(bw, "# HELP %s Consul metric %s\n", f.name, f.original)
+		keys := make([]string, 0, len(f.series))
+		for key := range f.series {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			for _, line := range f.series[key].lines {
+				fmt.Fprintln(bw, line)
+			}
+		}
+	}
+	fmt.Fprintln(bw, "# EOF")
+	return bw.Flush()
+}
+
+// formatOpenMetricsLabels renders a label set as {k="v",...} with sanitized names and escaped values.
+func formatOpenMetricsLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, fmt.Sprintf(`%s="%s"`, SanitizeLabelName(k), labelValueEscaper.Replace(labels[k])))
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+func formatExportValue(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	influxMeasurementEscaper = strings.NewReplacer(`,`, `\,`, ` `, `\ `)
+	influxTagEscaper         = strings.NewReplacer(`,`, `\,`, `=`, `\=`, ` `, `\ `)
+)
+
+// WriteInfluxLine writes every metrics capture of the bundle in InfluxDB line protocol with
+// nanosecond timestamps, suitable for `influx write --precision ns`.
+//
+// The measurement is the metric name and the tags are the metric labels plus the datacenter and
+// node of the agent (when agent.json has been decoded). Gauges and points have a single value
+// field, counters and samples carry a field per aggregate (count, rate, sum, min, max, mean, stddev).
+func (b *Debug) WriteInfluxLine(w io.Writer) error {
+	agentTags := map[string]string{
+		"datacenter": b.Agent.Config.Datacenter,
+		"node":       b.Agent.Config.NodeName,
+	}
+	aggregateFields := func(count int, rate, sum, min, max, mean, stddev float64) string {
+		return fmt.Sprintf("count=%di,rate=%s,sum=%s,min=%s,max=%s,mean=%s,stddev=%s", count,
+			formatExportValue(rate), formatExportValue(sum), formatExportValue(min),
+			formatExportValue(max), formatExportValue(mean), formatExportValue(stddev))
+	}
+
+	bw := bufio.NewWriter(w)
+	for _, capture := range b.Metrics.Metrics {
+		parsed, err := ParseMetricTimestamp(capture.Timestamp)
+		if err != nil {
+			return fmt.Errorf("invalid metrics capture timestamp %q: %v", capture.Timestamp, err)
+		}
+		ts := parsed.UnixNano()
+
+		for _, g := range capture.Gauges {
+			fmt.Fprintf(bw, "%s value=%s %d\n", influxSeriesKey(g.Name, g.Labels, agentTags), formatExportValue(g.Value), ts)
+		}
+		for _, p := range capture.Points {
+			fmt.Fprintf(bw, "%s value=%s %d\n", influxSeriesKey(p.Name, p.Labels, agentTags), formatExportValue(p.Points), ts)
+		}
+		for _, c := range capture.Counters {
+			fmt.Fprintf(bw, "%s %s %d\n", influxSeriesKey(c.Name, c.Labels, agentTags),
+				aggregateFields(c.Count, c.Rate, c.Sum, c.Min, c.Max, c.Mean, c.Stddev), ts)
+		}
+		for _, s := range capture.Samples {
+			fmt.Fprintf(bw, "%s %s %d\n", influxSeriesKey(s.Name, s.Labels, agentTags),
+				aggregateFields(s.Count, s.Rate, s.Sum, s.Min, s.Max, s.Mean, s.Stddev), ts)
+		}
+	}
+	return bw.Flush()
+}
+
+// influxSeriesKey renders measurement,tag=value,... with tags sorted by key. Metric labels take
+// precedence over agent tags of the same name, and empty tag values are omitted.
+func influxSeriesKey(name string, labels, agentTags map[string]string) string {
+	tags := make(map[string]string, len(labels)+len(agentTags))
+	for k, v := range agentTags {
+		tags[k] = v
+	}
+	for k, v := range labels {
+		tags[k] = v
+	}
+	keys := make([]string, 0, len(tags))
+	for k, v := range tags {
+		if v != "" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	var sb strings.Builder
+	sb.WriteString(influxMeasurementEscaper.Replace(name))
+	for _, k := range keys {
+		sb.WriteString(",")
+		sb.WriteString(influxTagEscaper.Replace(k))
+		sb.WriteString("=")
+		sb.WriteString(influxTagEscaper.Replace(tags[k]))
+	}
+	return sb.String()
+}
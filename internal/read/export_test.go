@@ -0,0 +1,79 @@
+package read
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWriteOpenMetrics(t *testing.T) {
+	b := &Debug{Metrics: Metrics{Metrics: []Metric{
+		{
+			Timestamp: "2024-02-07 12:40:00 -0500 EST",
+			Gauges:    []Gauge{{Name: "consul.mesh.active-root-ca.expiry", Value: 3.1e+08, Labels: map[string]string{}}},
+			Counters:  []Counters{{Name: "consul.client.rpc", Count: 2, Sum: 2, Labels: map[string]string{"method": `Catalog."Register"`}}},
+			Samples:   []Samples{{Name: "consul.raft.commitTime", Count: 4, Sum: 10}},
+		},
+		{
+			Timestamp: "2024-02-07 12:40:10 -0500 EST",
+			Counters:  []Counters{{Name: "consul.client.rpc", Count: 3, Sum: 3, Labels: map[string]string{"method": `Catalog."Register"`}}},
+			Samples:   []Samples{{Name: "consul.raft.commitTime", Count: 1, Sum: 5}},
+		},
+		{
+			// Colliding with the metrics above once sanitized, with the same and another type.
+			Timestamp: "2024-02-07 12:40:20 -0500 EST",
+			Gauges:    []Gauge{{Name: "consul.mesh.active_root_ca.expiry", Value: 1, Labels: map[string]string{}}},
+			Counters:  []Counters{{Name: "consul.raft.commitTime", Count: 1, Sum: 1, Labels: map[string]string{}}},
+		},
+	}}}
+
+	var buf bytes.Buffer
+	if err := b.WriteOpenMetrics(&buf); err != nil {
+		t.Fatal(err)
+	}
+	want := `# TYPE consul_client_rpc counter
+# HELP consul_client_rpc Consul metric consul.client.rpc
+consul_client_rpc_total{method="Catalog.\"Register\""} 2 1707327600
+consul_client_rpc_total{method="Catalog.\"Register\""} 5 1707327610
+# TYPE consul_mesh_active_root_ca_expiry gauge
+# HELP consul_mesh_active_root_ca_expiry Consul metric consul.mesh.active-root-ca.expiry
+consul_mesh_active_root_ca_expiry 3.1e+08 1707327600
+# TYPE consul_mesh_active_root_ca_expiry_2 gauge
+# HELP consul_mesh_active_root_ca_expiry_2 Consul metric consul.mesh.active_root_ca.expiry
+consul_mesh_active_root_ca_expiry_2 1 1707327620
+# TYPE consul_raft_commitTime summary
+# HELP consul_raft_commitTime Consul metric consul.raft.commitTime
+consul_raft_commitTime_count 4 1707327600
+consul_raft_commitTime_sum 10 1707327600
+consul_raft_commitTime_count 5 1707327610
+consul_raft_commitTime_sum 15 1707327610
+# TYPE consul_raft_commitTime_2 counter
+# HELP consul_raft_commitTime_2 Consul metric consul.raft.commitTime
+consul_raft_commitTime_2_total 1 1707327620
+# EOF
+`
+	if got := buf.String(); got != want {
+		t.Errorf("unexpected output:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestWriteInfluxLine(t *testing.T) {
+	b := &Debug{
+		Agent: Agent{Config: Config{Datacenter: "dc1", NodeName: "server 1"}},
+		Metrics: Metrics{Metrics: []Metric{{
+			Timestamp: "2024-02-07 12:40:00 -0500 EST",
+			Gauges:    []Gauge{{Name: "consul.runtime.heap_objects", Value: 100}},
+			Counters:  []Counters{{Name: "consul.client.rpc", Count: 2, Rate: 0.2, Sum: 2, Min: 1, Max: 1, Mean: 1, Labels: map[string]string{"method": "Catalog.Register", "node": "client,1"}}},
+		}}},
+	}
+
+	var buf bytes.Buffer
+	if err := b.WriteInfluxLine(&buf); err != nil {
+		t.Fatal(err)
+	}
+	want := `consul.runtime.heap_objects,datacenter=dc1,node=server\ 1 value=100 1707327600000000000
+consul.client.rpc,datacenter=dc1,method=Catalog.Register,node=client\,1 count=2i,rate=0.2,sum=2,min=1,max=1,mean=1,stddev=0 1707327600000000000
+`
+	if got := buf.String(); got != want {
+		t.Errorf("unexpected output:\n%s\nwant:\n%s", got, want)
+	}
+}
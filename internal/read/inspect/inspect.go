@@ -0,0 +1,576 @@
+package inspect
+
+import (
+	"consul-debug-read/internal/read"
+	"consul-debug-read/internal/read/log"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ryanuber/columnize"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// File check statuses.
+const (
+	StatusOK        = "ok"
+	StatusMissing   = "missing"
+	StatusEmpty     = "empty"
+	StatusTruncated = "truncated"
+	StatusMalformed = "malformed"
+)
+
+// captureFiles are the files consul debug writes to every interval capture directory.
+var captureFiles = []string{"goroutine.prof", "heap.prof"}
+
+// targetFiles maps the capture targets of index.json to the bundle files they produce; pprof
+// produces the interval capture directories instead.
+var targetFiles = map[string][]string{
+	"agent":   {"agent.json"},
+	"host":    {"host.json"},
+	"members": {"members.json"},
+	"metrics": {"metrics.json"},
+	"logs":    {"consul.log"},
+	"pprof":   nil,
+}
+
+// Target is a capture target and whether the bundle holds its output.
+type Target struct {
+	Name      string   `json:"name"`
+	Requested bool     `json:"requested"`
+	Present   bool     `json:"present"`
+	Files     []string `json:"files,omitempty"`
+}
+
+// FileCheck is the result of decoding one of the bundle's JSON files.
+type FileCheck struct {
+	File   string `json:"file"`
+	Status string `json:"status"`
+	// Objects is the number of top-level JSON values decoded; for metrics.json, the captures
+	// recovered around damaged ones.
+	Objects int `json:"objects"`
+	// Offset is the byte offset at which decoding failed, or of the first damaged capture.
+	Offset int64  `json:"offset,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Window is the capture window of the bundle.
+type Window struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	// Source is what the start was derived from: metrics, profiles or index.json.
+	Source string `json:"source"`
+}
+
+// Gap is a stretch between two interval captures missing one or more captures.
+type Gap struct {
+	After   time.Time `json:"after"`
+	Before  time.Time `json:"before"`
+	Missing int       `json:"missing"`
+}
+
+// MetricCoverage compares the metric captures in metrics.json with the captures expected from
+// the bundle interval and duration.
+type MetricCoverage struct {
+	Expected int       `json:"expected"`
+	Actual   int       `json:"actual"`
+	First    time.Time `json:"first,omitempty"`
+	Last     time.Time `json:"last,omitempty"`
+}
+
+// ProfileCoverage compares the interval capture directories with those expected.
+type ProfileCoverage struct {
+	Expected int   `json:"expected"`
+	Actual   int   `json:"actual"`
+	Gaps     []Gap `json:"gaps,omitempty"`
+	// MissingFiles lists, per capture directory, the profile files it lacks.
+	MissingFiles map[string][]string `json:"missing_files,omitempty"`
+}
+
+// LogCoverage is the time span covered by consul.log.
+type LogCoverage struct {
+	Entries int       `json:"entries"`
+	First   time.Time `json:"first,omitempty"`
+	Last    time.Time `json:"last,omitempty"`
+	// StartGap and EndGap are how much of the capture window before the first and after the last
+	// entry the log does not cover.
+	StartGap time.Duration `json:"start_gap"`
+	EndGap   time.Duration `json:"end_gap"`
+}
+
+// Analysis is a family of commands and whether the bundle supports it.
+type Analysis struct {
+	Name      string `json:"name"`
+	Available bool   `json:"available"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+// Report describes what a bundle holds and what analysis it supports.
+type Report struct {
+	Path       string          `json:"path"`
+	Node       string          `json:"node"`
+	Datacenter string          `json:"datacenter"`
+	Version    string          `json:"version"`
+	Mode       string          `json:"mode"`
+	Interval   string          `json:"interval"`
+	Duration   string          `json:"duration"`
+	Window     Window          `json:"window"`
+	Targets    []Target        `json:"targets"`
+	Files      []FileCheck     `json:"files"`
+	Metrics    MetricCoverage  `json:"metrics"`
+	Profiles   ProfileCoverage `json:"profiles"`
+	Logs       LogCoverage     `json:"logs"`
+	Analyses   []Analysis      `json:"analyses"`
+	Findings   []string        `json:"findings"`
+}
+
+// Inspect reports on the completeness and integrity of the extracted bundle at dir. Only
+// index.json is required: everything else is reported on rather than failed on, so that bundles
+// which would not load can still be inspected.
+func Inspect(dir string) (*Report, error) {
+	r := &Report{Path: dir, Mode: "unknown"}
+
+	var index read.Index
+	check, err := checkJSON(dir, "index.json", func(raw json.RawMessage) error { return json.Unmarshal(raw, &index) })
+	if err != nil {
+		return nil, err
+	}
+	if check.Status != StatusOK {
+		return nil, fmt.Errorf("%s/index.json is %s: %s", dir, check.Status, check.Error)
+	}
+	r.Files = append(r.Files, check)
+	r.Version, r.Interval, r.Duration = index.AgentVersion, index.Interval, index.Duration
+	interval, err := time.ParseDuration(index.Interval)
+	if err != nil || interval <= 0 {
+		return nil, fmt.Errorf("invalid bundle interval %q in index.json", index.Interval)
+	}
+	duration, err := time.ParseDuration(index.Duration)
+	if err != nil {
+		return nil, fmt.Errorf("invalid bundle duration %q in index.json", index.Duration)
+	}
+	expected := int(duration / interval)
+
+	var agent read.Agent
+	check, err = checkJSON(dir, "agent.json", func(raw json.RawMessage) error { return json.Unmarshal(raw, &agent) })
+	if err != nil {
+		return nil, err
+	}
+	r.Files = append(r.Files, check)
+	if check.Objects > 0 {
+		r.Node, r.Datacenter = agent.Config.NodeName, agent.Config.Datacenter
+		r.Mode = "client"
+		if agent.Config.Server {
+			r.Mode = "server"
+		}
+	}
+
+	for _, name := range []string{"members.json", "host.json"} {
+		if check, err = checkJSON(dir, name, nil); err != nil {
+			return nil, err
+		}
+		r.Files = append(r.Files, check)
+	}
+
+	r.Metrics.Expected = expected
+	check, err = checkMetrics(dir, &r.Metrics)
+	if err != nil {
+		return nil, err
+	}
+	r.Files = append(r.Files, check)
+
+	profiles, err := read.ReadProfileCaptures(dir)
+	if err != nil {
+		return nil, err
+	}
+	r.Profiles = profileCoverage(profiles, interval, expected)
+
+	r.Window = captureWindow(dir, r.Metrics, profiles, duration)
+
+	logFile := filepath.Join(dir, "consul.log")
+	if _, err := os.Stat(logFile); err == nil {
+		if r.Logs, err = logCoverage(logFile, r.Window); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %v", logFile, err)
+		}
+	}
+
+	r.Targets = targets(dir, index.Targets, r.Files, profiles)
+	r.Analyses = analyses(r)
+	r.Findings = findings(r, interval)
+	return r, nil
+}
+
+// checkJSON decodes the stream of JSON values in dir/name, passing each to decode when set. The
+// first error stops decoding and is recorded in the check along with its byte offset.
+func checkJSON(dir, name string, decode func(json.RawMessage) error) (FileCheck, error) {
+	check := FileCheck{File: name, Status: StatusOK}
+	f, err := os.Open(filepath.Join(dir, name))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			check.Status = StatusMissing
+			return check, nil
+		}
+		return check, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return check, err
+	}
+
+	dec := json.NewDecoder(f)
+	for {
+		offset := dec.InputOffset()
+		var raw json.RawMessage
+		err = dec.Decode(&raw)
+		if err == io.EOF {
+			break
+		}
+		var syntaxErr *json.SyntaxError
+		switch {
+		case errors.Is(err, io.ErrUnexpectedEOF):
+			check.Status, check.Offset = StatusTruncated, info.Size()
+		case errors.As(err, &syntaxErr):
+			check.Status, check.Offset = StatusMalformed, syntaxErr.Offset
+		case err != nil:
+			check.Status, check.Offset = StatusMalformed, dec.InputOffset()
+		case decode != nil:
+			if err = decode(raw); err != nil {
+				check.Status, check.Offset = StatusMalformed, offset
+			}
+		}
+		if err != nil {
+			check.Error = err.Error()
+			break
+		}
+		check.Objects++
+	}
+	if check.Status == StatusOK && check.Objects == 0 {
+		check.Status = StatusEmpty
+	}
+	return check, nil
+}
+
+// checkMetrics decodes metrics.json the way analysis commands do, recovering every complete
+// capture; the check reports the first damaged capture and how many were skipped.
+func checkMetrics(dir string, coverage *MetricCoverage) (FileCheck, error) {
+	check := FileCheck{File: "metrics.json", Status: StatusOK}
+	f, err := os.Open(filepath.Join(dir, check.File))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			check.Status = StatusMissing
+			return check, nil
+		}
+		return check, err
+	}
+	defer f.Close()
+	damaged, err := read.ScanMetricCaptures(f, func(m read.Metric) {
+		check.Objects++
+		ts, err := time.Parse(read.MetricsTimestampLayout, m.Timestamp)
+		if err != nil {
+			return
+		}
+		if coverage.First.IsZero() || ts.Before(coverage.First) {
+			coverage.First = ts
+		}
+		if ts.After(coverage.Last) {
+			coverage.Last = ts
+		}
+	})
+	if err != nil {
+		return check, err
+	}
+	coverage.Actual = check.Objects
+	switch {
+	case len(damaged) > 0:
+		check.Status, check.Offset = StatusMalformed, damaged[0].Offset
+		if len(damaged) == 1 && damaged[0].Truncated {
+			check.Status = StatusTruncated
+		}
+		check.Error = damaged[0].Err.Error()
+		if len(damaged) > 1 {
+			check.Error = fmt.Sprintf("%d damaged captures skipped, first: %s", len(damaged), check.Error)
+		}
+	case check.Objects == 0:
+		check.Status = StatusEmpty
+	}
+	return check, nil
+}
+
+func profileCoverage(profiles []read.ProfileCapture, interval time.Duration, expected int) ProfileCoverage {
+	c := ProfileCoverage{Expected: expected, Actual: len(profiles)}
+	for i := 1; i < len(profiles); i++ {
+		elapsed := profiles[i].Timestamp.Sub(profiles[i-1].Timestamp)
+		if elapsed*2 > interval*3 {
+			missing := int((elapsed+interval/2)/interval) - 1
+			c.Gaps = append(c.Gaps, Gap{After: profiles[i-1].Timestamp, Before: profiles[i].Timestamp, Missing: missing})
+		}
+	}
+	for _, p := range profiles {
+		for _, want := range captureFiles {
+			if !contains(p.Files, want) {
+				if c.MissingFiles == nil {
+					c.MissingFiles = make(map[string][]string)
+				}
+				name := filepath.Base(p.Dir)
+				c.MissingFiles[name] = append(c.MissingFiles[name], want)
+			}
+		}
+	}
+	return c
+}
+
+// captureWindow derives the capture window from the first metric capture, falling back to the
+// first interval capture and then to the modification time of index.json, which consul debug
+// writes when the capture starts.
+func captureWindow(dir string, metrics MetricCoverage, profiles []read.ProfileCapture, duration time.Duration) Window {
+	var w Window
+	switch {
+	case !metrics.First.IsZero():
+		w.Start, w.Source = metrics.First, "metrics"
+	case len(profiles) > 0:
+		w.Start, w.Source = profiles[0].Timestamp, "profiles"
+	default:
+		if info, err := os.Stat(filepath.Join(dir, "index.json")); err == nil {
+			w.Start, w.Source = info.ModTime(), "index.json"
+		}
+	}
+	w.End = w.Start.Add(duration)
+	return w
+}
+
+// logCoverage scans consul.log for the number of entries and the first and last timestamps,
+// without holding the entries in memory.
+func logCoverage(logFile string, w Window) (LogCoverage, error) {
+	var c LogCoverage
+	f, err := os.Open(logFile)
+	if err != nil {
+		return c, err
+	}
+	defer f.Close()
+	levels := strings.Join([]string{log.TraceLevel, log.DebugLevel, log.InfoLevel, log.WarnLevel, log.ErrorLevel}, "|")
+	scanner := log.NewScanner(f, levels, "", time.Time{}, time.Time{})
+	for scanner.Scan() {
+		ts := scanner.Entry().Timestamp
+		if c.Entries == 0 || ts.Before(c.First) {
+			c.First = ts
+		}
+		if c.Entries == 0 || ts.After(c.Last) {
+			c.Last = ts
+		}
+		c.Entries++
+	}
+	if err = scanner.Err(); err != nil {
+		return c, err
+	}
+	if c.Entries == 0 {
+		return c, nil
+	}
+	if c.First.After(w.Start) {
+		c.StartGap = c.First.Sub(w.Start)
+	}
+	if c.Last.Before(w.End) {
+		c.EndGap = w.End.Sub(c.Last)
+	}
+	return c, nil
+}
+
+func targets(dir string, requested []string, files []FileCheck, profiles []read.ProfileCapture) []Target {
+	names := make(map[string]bool)
+	for name := range targetFiles {
+		names[name] = false
+	}
+	for _, name := range requested {
+		names[name] = true
+	}
+	status := make(map[string]string, len(files))
+	for _, f := range files {
+		status[f.File] = f.Status
+	}
+
+	var result []Target
+	for _, name := range sortedKeys(names) {
+		t := Target{Name: name, Requested: names[name]}
+		switch name {
+		case "pprof":
+			t.Present = len(profiles) > 0
+			if t.Present {
+				t.Files = []string{fmt.Sprintf("%d interval capture(s)", len(profiles))}
+			}
+		case "logs":
+			if _, err := os.Stat(filepath.Join(dir, "consul.log")); err == nil {
+				t.Present, t.Files = true, []string{"consul.log"}
+			}
+		default:
+			for _, file := range targetFiles[name] {
+				if s, ok := status[file]; ok && s != StatusMissing {
+					t.Present = true
+					t.Files = append(t.Files, file)
+				}
+			}
+		}
+		result = append(result, t)
+	}
+	return result
+}
+
+func analyses(r *Report) []Analysis {
+	status := make(map[string]string, len(r.Files))
+	for _, f := range r.Files {
+		status[f.File] = f.Status
+	}
+	usable := func(file string) (bool, string) {
+		if s := status[file]; s != StatusOK {
+			return false, fmt.Sprintf("%s is %s", file, s)
+		}
+		return true, ""
+	}
+
+	var result []Analysis
+	add := func(name string, ok bool, reason string) {
+		if ok {
+			reason = ""
+		}
+		result = append(result, Analysis{Name: name, Available: ok, Reason: reason})
+	}
+	ok, reason := usable("agent.json")
+	add("agent config", ok, reason)
+	add("agent lint", ok, reason)
+	membersOK, membersReason := usable("members.json")
+	add("agent members", membersOK, membersReason)
+	if ok && r.Mode != "server" {
+		ok, reason = false, "bundle was captured on a client agent"
+	}
+	add("agent raft-configuration", ok, reason)
+	add("metrics", r.Metrics.Actual > 0, "metrics.json holds no metric captures")
+	add("log", r.Logs.Entries > 0, "consul.log is missing or holds no timestamped entries")
+	add("profiles", r.Profiles.Actual > 0, "no interval capture directories (pprof target)")
+	ok, reason = usable("host.json")
+	add("host", ok, reason)
+	add("timeline", r.Metrics.Actual > 0 || r.Logs.Entries > 0 || r.Profiles.Actual > 0, "no metrics, logs or profiles to correlate")
+	return result
+}
+
+func findings(r *Report, interval time.Duration) []string {
+	var f []string
+	for _, t := range r.Targets {
+		switch {
+		case t.Requested && !t.Present:
+			f = append(f, fmt.Sprintf("target %s was requested but its output is missing", t.Name))
+		case !t.Requested && t.Present:
+			f = append(f, fmt.Sprintf("target %s is not listed in index.json but its output is present", t.Name))
+		}
+	}
+	for _, c := range r.Files {
+		if c.Status == StatusTruncated || c.Status == StatusMalformed {
+			f = append(f, fmt.Sprintf("%s is %s at byte %d, %d object(s) decoded: %s", c.File, c.Status, c.Offset, c.Objects, c.Error))
+		}
+	}
+	if r.Metrics.Actual > 0 && r.Metrics.Actual < r.Metrics.Expected {
+		f = append(f, fmt.Sprintf("metrics.json holds %d of %d expected captures (%s every %s)", r.Metrics.Actual, r.Metrics.Expected, r.Duration, r.Interval))
+	}
+	if r.Profiles.Actual > 0 && r.Profiles.Actual < r.Profiles.Expected {
+		f = append(f, fmt.Sprintf("%d of %d expected interval captures are present", r.Profiles.Actual, r.Profiles.Expected))
+	}
+	for _, g := range r.Profiles.Gaps {
+		f = append(f, fmt.Sprintf("%d interval capture(s) missing between %s and %s", g.Missing, g.After.Format(time.TimeOnly), g.Before.Format(time.TimeOnly)))
+	}
+	for _, dir := range sortedKeys(r.Profiles.MissingFiles) {
+		f = append(f, fmt.Sprintf("interval capture %s lacks %s", dir, strings.Join(r.Profiles.MissingFiles[dir], ", ")))
+	}
+	if r.Logs.Entries > 0 {
+		if r.Logs.StartGap > interval {
+			f = append(f, fmt.Sprintf("consul.log starts %s after the capture started", r.Logs.StartGap))
+		}
+		if r.Logs.EndGap > interval {
+			f = append(f, fmt.Sprintf("consul.log ends %s before the capture ended", r.Logs.EndGap))
+		}
+	}
+	return f
+}
+
+// Summary renders the report as text.
+func Summary(r *Report) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Bundle:   %s\n", r.Path)
+	fmt.Fprintf(&b, "Node:     %s (%s, datacenter %s, consul %s)\n", r.Node, r.Mode, r.Datacenter, r.Version)
+	fmt.Fprintf(&b, "Capture:  %s every %s, %s to %s (from %s)\n", r.Duration, r.Interval,
+		r.Window.Start.Format(time.RFC3339), r.Window.End.Format(time.RFC3339), r.Window.Source)
+
+	b.WriteString("\nTargets:\n")
+	rows := []string{"Target\x1fRequested\x1fPresent\x1fOutput"}
+	for _, t := range r.Targets {
+		rows = append(rows, fmt.Sprintf("%s\x1f%t\x1f%t\x1f%s", t.Name, t.Requested, t.Present, strings.Join(t.Files, ", ")))
+	}
+	b.WriteString(formatRows(rows))
+
+	b.WriteString("\n\nFiles:\n")
+	rows = []string{"File\x1fStatus\x1fObjects\x1fOffset"}
+	for _, c := range r.Files {
+		offset := "-"
+		if c.Status == StatusTruncated || c.Status == StatusMalformed {
+			offset = fmt.Sprint(c.Offset)
+		}
+		rows = append(rows, fmt.Sprintf("%s\x1f%s\x1f%d\x1f%s", c.File, c.Status, c.Objects, offset))
+	}
+	b.WriteString(formatRows(rows))
+
+	b.WriteString("\n\nCoverage:\n")
+	rows = []string{"Signal\x1fExpected\x1fActual\x1fSpan"}
+	rows = append(rows, fmt.Sprintf("metrics\x1f%d\x1f%d\x1f%s", r.Metrics.Expected, r.Metrics.Actual, span(r.Metrics.First, r.Metrics.Last)))
+	profileSpan := "-"
+	if len(r.Profiles.Gaps) > 0 {
+		profileSpan = fmt.Sprintf("%d gap(s)", len(r.Profiles.Gaps))
+	}
+	rows = append(rows, fmt.Sprintf("profiles\x1f%d\x1f%d\x1f%s", r.Profiles.Expected, r.Profiles.Actual, profileSpan))
+	rows = append(rows, fmt.Sprintf("log entries\x1f-\x1f%d\x1f%s", r.Logs.Entries, span(r.Logs.First, r.Logs.Last)))
+	b.WriteString(formatRows(rows))
+
+	b.WriteString("\n\nAnalyses:\n")
+	rows = []string{"Analysis\x1fAvailable\x1fReason"}
+	for _, a := range r.Analyses {
+		rows = append(rows, fmt.Sprintf("%s\x1f%t\x1f%s", a.Name, a.Available, a.Reason))
+	}
+	b.WriteString(formatRows(rows))
+
+	b.WriteString("\n\nFindings:\n")
+	if len(r.Findings) == 0 {
+		b.WriteString("  none: the bundle is complete\n")
+	}
+	for _, f := range r.Findings {
+		fmt.Fprintf(&b, "  - %s\n", f)
+	}
+	return b.String()
+}
+
+func span(first, last time.Time) string {
+	if first.IsZero() {
+		return "-"
+	}
+	return fmt.Sprintf("%s - %s", first.Format(time.RFC3339), last.Format(time.RFC3339))
+}
+
+func formatRows(rows []string) string {
+	return columnize.Format(rows, &columnize.Config{Delim: "\x1f", Glue: "  ", Prefix: "  "})
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,73 @@
+package inspect
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInspect(t *testing.T) {
+	dir := t.TempDir()
+	metric := `{"Timestamp":"2024-02-07 12:40:%02d -0500 EST","Gauges":[],"Points":[],"Counters":[],"Samples":[]}` + "\n"
+	files := map[string]string{
+		"index.json":   `{"Version":2,"AgentVersion":"1.17.2","Interval":"10s","Duration":"40s","Targets":["agent","metrics","logs","pprof","host"]}`,
+		"agent.json":   `{"Config":{"Datacenter":"dc1","NodeName":"client-1","Server":false}}`,
+		"members.json": `[{"Name":"client-1"}]`,
+		"metrics.json": strings.Replace(metric, "%02d", "00", 1) + strings.Replace(metric, "%02d", "10", 1) + `{"Timestamp":"2024-02-07 12:40:20 -0500 EST","Gau`,
+		"consul.log":   "2024-02-07T12:40:15.000-0500 [INFO]  agent: Synced node info\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, capture := range []string{"2024-02-07T12-40-00-0500", "2024-02-07T12-40-30-0500"} {
+		if err := os.MkdirAll(filepath.Join(dir, capture), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, capture, "goroutine.prof"), []byte("goroutine profile: total 10\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r, err := Inspect(dir)
+	if err != nil {
+		t.Fatalf("Inspect: %v", err)
+	}
+	if r.Mode != "client" || r.Node != "client-1" {
+		t.Errorf("expected client bundle of client-1, got %s of %s", r.Mode, r.Node)
+	}
+	if r.Metrics.Expected != 4 || r.Metrics.Actual != 2 {
+		t.Errorf("expected 2 of 4 metric captures, got %+v", r.Metrics)
+	}
+	var metrics FileCheck
+	for _, f := range r.Files {
+		if f.File == "metrics.json" {
+			metrics = f
+		}
+	}
+	if metrics.Status != StatusTruncated || metrics.Offset != int64(strings.LastIndex(files["metrics.json"], "{")) {
+		t.Errorf("expected metrics.json truncated at its last capture, got %+v", metrics)
+	}
+	if len(r.Profiles.Gaps) != 1 || r.Profiles.Gaps[0].Missing != 2 || len(r.Profiles.MissingFiles) != 2 {
+		t.Errorf("expected one gap of 2 captures and heap.prof missing twice, got %+v", r.Profiles)
+	}
+	for _, a := range r.Analyses {
+		if a.Name == "agent raft-configuration" && a.Available {
+			t.Error("expected raft configuration to be unavailable for a client bundle")
+		}
+	}
+	findings := strings.Join(r.Findings, "\n")
+	for _, want := range []string{
+		"target host was requested but its output is missing",
+		"target members is not listed in index.json",
+		"metrics.json is truncated",
+		"consul.log starts 15s after the capture started",
+		"consul.log ends 25s before the capture ended",
+	} {
+		if !strings.Contains(findings, want) {
+			t.Errorf("expected finding %q in:\n%s", want, findings)
+		}
+	}
+}
@@ -0,0 +1,452 @@
+package lint
+
+import (
+	"consul-debug-read/internal/read"
+	"encoding/json"
+	"fmt"
+	"github.com/ryanuber/columnize"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Finding severities, from most to least severe.
+const (
+	SeverityCritical = "critical"
+	SeverityWarning  = "warning"
+	SeverityInfo     = "info"
+)
+
+var severityRank = map[string]int{SeverityCritical: 0, SeverityWarning: 1, SeverityInfo: 2}
+
+// ValidSeverity reports whether severity is one of the finding severities.
+func ValidSeverity(severity string) bool {
+	_, ok := severityRank[severity]
+	return ok
+}
+
+// AtLeast reports whether severity is as severe as min.
+func AtLeast(severity, min string) bool {
+	return severityRank[severity] <= severityRank[min]
+}
+
+// Documentation of the checked settings, linked from findings so they read offline.
+const (
+	docsConfig      = "https://developer.hashicorp.com/consul/docs/agent/config/config-files"
+	docsACL         = "https://developer.hashicorp.com/consul/docs/security/acl"
+	docsGossip      = "https://developer.hashicorp.com/consul/docs/security/encryption/gossip"
+	docsTLS         = "https://developer.hashicorp.com/consul/docs/security/encryption/mtls"
+	docsPerformance = "https://developer.hashicorp.com/consul/docs/install/performance"
+	docsDNSCaching  = "https://developer.hashicorp.com/consul/docs/services/discovery/dns-cache"
+)
+
+// Finding is a risky or non-recommended agent setting.
+type Finding struct {
+	Check    string `json:"check"`
+	Severity string `json:"severity"`
+	// Setting is the DebugConfig setting found, and Value its captured value.
+	Setting        string `json:"setting"`
+	Value          string `json:"value"`
+	Message        string `json:"message"`
+	Recommendation string `json:"recommendation"`
+	Docs           string `json:"docs"`
+}
+
+// Report is the result of linting an agent's DebugConfig.
+type Report struct {
+	Node    string    `json:"node"`
+	Version string    `json:"version"`
+	Server  bool      `json:"server"`
+	Checks  int       `json:"checks"`
+	Found   []Finding `json:"findings"`
+	// Skipped are the checks whose settings the DebugConfig does not hold, e.g. server-only checks on a client.
+	Skipped []string `json:"skipped,omitempty"`
+}
+
+// config is the flattened DebugConfig, by setting path.
+type config map[string]string
+
+// get returns the JSON value of the first of paths the DebugConfig holds.
+func (c config) get(paths ...string) (string, string, bool) {
+	for _, p := range paths {
+		if v, ok := c[p]; ok {
+			return p, v, true
+		}
+	}
+	return "", "", false
+}
+
+func (c config) bool(paths ...string) (string, bool, bool) {
+	p, v, ok := c.get(paths...)
+	return p, v == "true", ok && (v == "true" || v == "false")
+}
+
+func (c config) string(paths ...string) (string, string, bool) {
+	p, v, ok := c.get(paths...)
+	var s string
+	if ok && json.Unmarshal([]byte(v), &s) != nil {
+		return p, "", false
+	}
+	return p, s, ok
+}
+
+func (c config) number(paths ...string) (string, float64, bool) {
+	p, v, ok := c.get(paths...)
+	var n float64
+	if ok && json.Unmarshal([]byte(v), &n) != nil {
+		return p, 0, false
+	}
+	return p, n, ok
+}
+
+func (c config) duration(paths ...string) (string, time.Duration, bool) {
+	p, s, ok := c.string(paths...)
+	d, err := time.ParseDuration(s)
+	return p, d, ok && err == nil
+}
+
+// check evaluates one best practice, returning its findings and whether the DebugConfig held the
+// settings to evaluate it.
+type check struct {
+	name       string
+	serverOnly bool
+	run        func(c config) ([]Finding, bool)
+}
+
+var checks = []check{
+	{name: "acl-default-policy", run: checkACLDefaultPolicy},
+	{name: "acl-down-policy", run: checkACLDownPolicy},
+	{name: "dns-stale-reads", run: checkDNSStale},
+	{name: "raft-snapshots", serverOnly: true, run: checkRaftSnapshots},
+	{name: "raft-multiplier", serverOnly: true, run: checkRaftMultiplier},
+	{name: "gossip-encryption", run: checkGossipEncryption},
+	{name: "tls-verification", run: checkTLSVerification},
+	{name: "update-check", run: checkUpdateCheck},
+	{name: "ui-exposure", run: checkUIExposure},
+	{name: "autopilot-dead-servers", serverOnly: true, run: checkAutopilotCleanup},
+}
+
+// Lint evaluates the DebugConfig of agent against Consul's configuration best practices.
+// Findings are sorted by severity, then check.
+func Lint(agent *read.Agent) (*Report, error) {
+	settings, err := agent.QueryConfig("")
+	if err != nil {
+		return nil, err
+	}
+	c := make(config, len(settings))
+	for _, s := range settings {
+		c[s.Path] = string(s.Value)
+	}
+	_, server, _ := c.bool("ServerMode")
+	r := &Report{Node: agent.Config.NodeName, Version: agent.Config.Version, Server: server || agent.Config.Server}
+	for _, ch := range checks {
+		if ch.serverOnly && !r.Server {
+			r.Skipped = append(r.Skipped, ch.name)
+			continue
+		}
+		found, ok := ch.run(c)
+		if !ok {
+			r.Skipped = append(r.Skipped, ch.name)
+			continue
+		}
+		r.Checks++
+		for _, f := range found {
+			f.Check = ch.name
+			r.Found = append(r.Found, f)
+		}
+	}
+	sort.SliceStable(r.Found, func(i, j int) bool {
+		return severityRank[r.Found[i].Severity] < severityRank[r.Found[j].Severity]
+	})
+	return r, nil
+}
+
+func checkACLDefaultPolicy(c config) ([]Finding, bool) {
+	enabledPath, enabled, eok := c.bool("ACLResolverSettings.ACLsEnabled", "ACLsEnabled")
+	policyPath, policy, pok := c.string("ACLResolverSettings.ACLDefaultPolicy", "ACLDefaultPolicy")
+	if !eok {
+		return nil, false
+	}
+	if !enabled {
+		return []Finding{{
+			Severity: SeverityCritical, Setting: enabledPath, Value: "false",
+			Message:        "ACLs are disabled, so any client with network access can read and change every catalog, KV and intention entry",
+			Recommendation: "enable ACLs with acl.enabled = true and acl.default_policy = \"deny\"",
+			Docs:           docsACL,
+		}}, true
+	}
+	if pok && policy == "allow" {
+		return []Finding{{
+			Severity: SeverityWarning, Setting: policyPath, Value: policy,
+			Message:        "ACL default policy is allow, so requests without a matching token rule are permitted",
+			Recommendation: "set acl.default_policy = \"deny\" in production and grant access with explicit policies",
+			Docs:           docsConfig + "#acl_default_policy",
+		}}, true
+	}
+	return nil, pok
+}
+
+func checkACLDownPolicy(c config) ([]Finding, bool) {
+	_, enabled, eok := c.bool("ACLResolverSettings.ACLsEnabled", "ACLsEnabled")
+	p, policy, ok := c.string("ACLResolverSettings.ACLDownPolicy", "ACLDownPolicy")
+	if !ok || !eok {
+		return nil, false
+	}
+	if !enabled {
+		return nil, true
+	}
+	switch policy {
+	case "allow":
+		return []Finding{{
+			Severity: SeverityCritical, Setting: p, Value: policy,
+			Message:        "ACL down policy is allow, so every request is permitted whenever the leader cannot resolve tokens",
+			Recommendation: "use acl.down_policy = \"extend-cache\" or \"async-cache\"",
+			Docs:           docsConfig + "#acl_down_policy",
+		}}, true
+	case "deny":
+		return []Finding{{
+			Severity: SeverityInfo, Setting: p, Value: policy,
+			Message:        "ACL down policy is deny, so requests fail for cached tokens too while the leader is unreachable",
+			Recommendation: "use acl.down_policy = \"extend-cache\" to keep serving known tokens during leader outages",
+			Docs:           docsConfig + "#acl_down_policy",
+		}}, true
+	}
+	return nil, true
+}
+
+func checkDNSStale(c config) ([]Finding, bool) {
+	p, allow, ok := c.bool("DNSAllowStale")
+	if !ok {
+		return nil, false
+	}
+	if !allow {
+		return []Finding{{
+			Severity: SeverityWarning, Setting: p, Value: "false",
+			Message:        "DNS stale reads are disabled, so every DNS query is forwarded to the leader",
+			Recommendation: "set dns_config.allow_stale = true so any server can answer DNS queries",
+			Docs:           docsDNSCaching,
+		}}, true
+	}
+	if p, maxStale, ok := c.duration("DNSMaxStale"); ok && maxStale < 10*time.Second {
+		return []Finding{{
+			Severity: SeverityInfo, Setting: p, Value: maxStale.String(),
+			Message:        "DNS max stale is short, so DNS queries fall back to the leader as soon as a server lags",
+			Recommendation: "keep dns_config.max_stale at its default of 87600h unless clients need strictly fresh answers",
+			Docs:           docsConfig + "#max_stale",
+		}}, true
+	}
+	return nil, true
+}
+
+func checkRaftSnapshots(c config) ([]Finding, bool) {
+	var found []Finding
+	tp, threshold, tok := c.number("RaftSnapshotThreshold")
+	lp, trailing, lok := c.number("RaftTrailingLogs")
+	if tok && threshold < 16384 {
+		found = append(found, Finding{
+			Severity: SeverityInfo, Setting: tp, Value: fmt.Sprint(threshold),
+			Message:        "raft snapshot threshold is below the default, so servers snapshot more often and spend more disk IO on it",
+			Recommendation: "keep raft_snapshot_threshold at 16384 or raise it on busy clusters",
+			Docs:           docsConfig + "#raft_snapshot_threshold",
+		})
+	}
+	if lok && trailing < 10240 {
+		found = append(found, Finding{
+			Severity: SeverityWarning, Setting: lp, Value: fmt.Sprint(trailing),
+			Message:        "raft trailing logs are below the default, so lagging followers are more likely to need a full snapshot install",
+			Recommendation: "keep raft_trailing_logs at 10240 or more, raising it when followers repeatedly install snapshots",
+			Docs:           docsConfig + "#raft_trailing_logs",
+		})
+	}
+	return found, tok || lok
+}
+
+func checkRaftMultiplier(c config) ([]Finding, bool) {
+	p, multiplier, ok := c.number("PerformanceRaftMultiplier", "RaftMultiplier")
+	value := fmt.Sprint(multiplier)
+	if !ok {
+		// The DebugConfig holds the election timeout scaled by the multiplier from a 1s base.
+		var timeout time.Duration
+		if p, timeout, ok = c.duration("ConsulRaftElectionTimeout"); !ok {
+			return nil, false
+		}
+		multiplier = timeout.Seconds()
+		value = fmt.Sprintf("%s (raft_multiplier %g)", timeout, multiplier)
+	}
+	if multiplier <= 1 {
+		return nil, true
+	}
+	return []Finding{{
+		Severity: SeverityWarning, Setting: p, Value: value,
+		Message:        "raft timing is scaled for minimal hardware, so leader failures take longer to detect",
+		Recommendation: "set performance.raft_multiplier = 1 on production servers sized to Consul's recommendations",
+		Docs:           docsPerformance,
+	}}, true
+}
+
+func checkGossipEncryption(c config) ([]Finding, bool) {
+	p, key, ok := c.string("EncryptKey")
+	if !ok {
+		return nil, false
+	}
+	if key == "" {
+		return []Finding{{
+			Severity: SeverityCritical, Setting: p, Value: `""`,
+			Message:        "gossip encryption is disabled, so serf traffic can be read and forged on the network",
+			Recommendation: "configure encrypt with a key from 'consul keygen'",
+			Docs:           docsGossip,
+		}}, true
+	}
+	var found []Finding
+	for _, setting := range []string{"EncryptVerifyIncoming", "EncryptVerifyOutgoing"} {
+		if p, verify, ok := c.bool(setting); ok && !verify {
+			found = append(found, Finding{
+				Severity: SeverityWarning, Setting: p, Value: "false",
+				Message:        "gossip encryption is not enforced, so unencrypted gossip is still accepted or sent",
+				Recommendation: "set encrypt_verify_incoming and encrypt_verify_outgoing to true once every agent has the key",
+				Docs:           docsGossip,
+			})
+		}
+	}
+	return found, true
+}
+
+func checkTLSVerification(c config) ([]Finding, bool) {
+	var found []Finding
+	evaluated := false
+	settings := []struct {
+		paths   []string
+		message string
+	}{
+		{[]string{"TLS.InternalRPC.VerifyIncoming", "VerifyIncoming", "VerifyIncomingRPC"},
+			"incoming RPC connections are not required to present a client certificate"},
+		{[]string{"TLS.InternalRPC.VerifyOutgoing", "VerifyOutgoing"},
+			"outgoing RPC connections are not required to use TLS"},
+		{[]string{"TLS.InternalRPC.VerifyServerHostname", "VerifyServerHostname"},
+			"server certificates are not checked for server.<datacenter>.<domain>, so any client certificate can act as a server"},
+	}
+	for _, s := range settings {
+		p, verify, ok := c.bool(s.paths...)
+		if !ok {
+			continue
+		}
+		evaluated = true
+		if !verify {
+			found = append(found, Finding{
+				Severity: SeverityWarning, Setting: p, Value: "false",
+				Message:        s.message,
+				Recommendation: "set tls.internal_rpc verify_incoming, verify_outgoing and verify_server_hostname to true",
+				Docs:           docsTLS,
+			})
+		}
+	}
+	return found, evaluated
+}
+
+func checkUpdateCheck(c config) ([]Finding, bool) {
+	p, disabled, ok := c.bool("DisableUpdateCheck")
+	if !ok {
+		return nil, false
+	}
+	if disabled {
+		return nil, true
+	}
+	return []Finding{{
+		Severity: SeverityInfo, Setting: p, Value: "false",
+		Message:        "the agent contacts checkpoint.hashicorp.com for update and security bulletin checks",
+		Recommendation: "set disable_update_check = true on agents without outbound internet access or where this is not allowed",
+		Docs:           docsConfig + "#disable_update_check",
+	}}, true
+}
+
+func checkUIExposure(c config) ([]Finding, bool) {
+	p, enabled, ok := c.bool("UIConfig.Enabled", "UIEnabled")
+	if !ok {
+		return nil, false
+	}
+	if !enabled {
+		return nil, true
+	}
+	var public []string
+	for i := 0; ; i++ {
+		_, addr, ok := c.string(fmt.Sprintf("ClientAddrs[%d]", i))
+		if !ok {
+			break
+		}
+		if addr == "0.0.0.0" || addr == "::" || addr == "[::]" {
+			public = append(public, addr)
+		}
+	}
+	if len(public) == 0 {
+		return nil, true
+	}
+	severity := SeverityWarning
+	if _, acls, ok := c.bool("ACLResolverSettings.ACLsEnabled", "ACLsEnabled"); ok && !acls {
+		severity = SeverityCritical
+	}
+	return []Finding{{
+		Severity: severity, Setting: p, Value: "true",
+		Message:        fmt.Sprintf("the UI and HTTP API are served on every interface (client_addr %s)", strings.Join(public, ", ")),
+		Recommendation: "bind client_addr to a private address or put the UI behind a proxy, and enable ACLs",
+		Docs:           docsConfig + "#ui_config_enabled",
+	}}, true
+}
+
+func checkAutopilotCleanup(c config) ([]Finding, bool) {
+	p, cleanup, ok := c.bool("AutopilotCleanupDeadServers")
+	if !ok {
+		return nil, false
+	}
+	if cleanup {
+		return nil, true
+	}
+	return []Finding{{
+		Severity: SeverityWarning, Setting: p, Value: "false",
+		Message:        "failed servers are not removed from the raft configuration, so they count against quorum until removed by hand",
+		Recommendation: "set autopilot.cleanup_dead_servers = true",
+		Docs:           docsConfig + "#cleanup_dead_servers",
+	}}, true
+}
+
+// Filter returns the findings at least as severe as min.
+func (r *Report) Filter(min string) []Finding {
+	var found []Finding
+	for _, f := range r.Found {
+		if AtLeast(f.Severity, min) {
+			found = append(found, f)
+		}
+	}
+	return found
+}
+
+// Summary renders the findings at least as severe as min for the terminal.
+func Summary(r *Report, min string) string {
+	var b strings.Builder
+	role := "client"
+	if r.Server {
+		role = "server"
+	}
+	fmt.Fprintf(&b, "Agent: %s (%s, consul %s)\n", r.Node, role, r.Version)
+	found := r.Filter(min)
+	counts := make(map[string]int)
+	for _, f := range found {
+		counts[f.Severity]++
+	}
+	fmt.Fprintf(&b, "Checks: %d evaluated, %d skipped; %d critical, %d warning, %d info\n",
+		r.Checks, len(r.Skipped), counts[SeverityCritical], counts[SeverityWarning], counts[SeverityInfo])
+	if len(found) == 0 {
+		b.WriteString("\nNo findings.")
+		return b.String()
+	}
+	rows := []string{"Severity\x1fCheck\x1fSetting\x1fValue"}
+	for _, f := range found {
+		rows = append(rows, fmt.Sprintf("%s\x1f%s\x1f%s\x1f%s", f.Severity, f.Check, f.Setting, f.Value))
+	}
+	b.WriteString("\n")
+	b.WriteString(columnize.Format(rows, &columnize.Config{Delim: string([]byte{0x1f}), Glue: " "}))
+	b.WriteString("\n")
+	for _, f := range found {
+		fmt.Fprintf(&b, "\n[%s] %s: %s\n  Recommendation: %s\n  Docs: %s\n", f.Severity, f.Check, f.Message, f.Recommendation, f.Docs)
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
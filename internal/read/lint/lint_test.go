@@ -0,0 +1,70 @@
+package lint
+
+import (
+	"consul-debug-read/internal/read"
+	"encoding/json"
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	agent := &read.Agent{RawDebugConfig: json.RawMessage(`{
+		"ServerMode": true,
+		"ACLResolverSettings": {"ACLsEnabled": true, "ACLDefaultPolicy": "allow", "ACLDownPolicy": "extend-cache"},
+		"DNSAllowStale": true,
+		"DNSMaxStale": "87600h0m0s",
+		"RaftSnapshotThreshold": 16384,
+		"RaftTrailingLogs": 1000,
+		"ConsulRaftElectionTimeout": "1s",
+		"EncryptKey": "hidden",
+		"EncryptVerifyIncoming": true,
+		"EncryptVerifyOutgoing": false,
+		"TLS": {"InternalRPC": {"VerifyIncoming": true, "VerifyOutgoing": true, "VerifyServerHostname": true}},
+		"DisableUpdateCheck": true,
+		"UIConfig": {"Enabled": true},
+		"ClientAddrs": ["127.0.0.1", "0.0.0.0"]
+	}`)}
+
+	r, err := Lint(agent)
+	if err != nil {
+		t.Fatalf("Lint: %v", err)
+	}
+	if !r.Server {
+		t.Error("expected ServerMode to mark the agent a server")
+	}
+	if len(r.Skipped) != 1 || r.Skipped[0] != "autopilot-dead-servers" {
+		t.Errorf("expected only autopilot-dead-servers skipped, got %v", r.Skipped)
+	}
+	found := make(map[string]string)
+	for _, f := range r.Found {
+		found[f.Setting] = f.Severity
+		if f.Docs == "" || f.Recommendation == "" {
+			t.Errorf("expected docs and recommendation for %s", f.Setting)
+		}
+	}
+	expected := map[string]string{
+		"ACLResolverSettings.ACLDefaultPolicy": SeverityWarning,
+		"RaftTrailingLogs":                     SeverityWarning,
+		"EncryptVerifyOutgoing":                SeverityWarning,
+		"UIConfig.Enabled":                     SeverityWarning,
+	}
+	if len(found) != len(expected) {
+		t.Errorf("expected findings %v, got %v", expected, found)
+	}
+	for setting, severity := range expected {
+		if found[setting] != severity {
+			t.Errorf("expected %s finding for %s, got %q", severity, setting, found[setting])
+		}
+	}
+
+	// A client with ACLs disabled exposing the UI skips the server checks and escalates the exposure.
+	agent.RawDebugConfig = json.RawMessage(`{"ServerMode": false, "ACLsEnabled": false, "UIConfig": {"Enabled": true}, "ClientAddrs": ["::"]}`)
+	if r, err = Lint(agent); err != nil {
+		t.Fatalf("Lint: %v", err)
+	}
+	if len(r.Filter(SeverityCritical)) != 2 || len(r.Found) != 2 {
+		t.Errorf("expected ACL and UI exposure critical findings, got %+v", r.Found)
+	}
+	if r.Checks != 2 {
+		t.Errorf("expected 2 checks evaluated, got %d (skipped %v)", r.Checks, r.Skipped)
+	}
+}
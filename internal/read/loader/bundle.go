@@ -0,0 +1,396 @@
+// Package loader lazily decodes consul debug bundles for the consul-debug-read commands, which
+// work on the internal read types. The public API built on it is pkg/bundle.
+package loader
+
+import (
+	"consul-debug-read/internal/read"
+	"consul-debug-read/internal/read/log"
+	"consul-debug-read/pkg/redact"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Parts of a bundle, named after the bundle file they are decoded from.
+const (
+	PartIndex   = "index"
+	PartAgent   = "agent"
+	PartMembers = "members"
+	PartHost    = "host"
+	PartMetrics = "metrics"
+)
+
+// parts are the bundle parts, each decoded into its own Debug and merged into the bundle's.
+var parts = []struct {
+	name  string
+	file  string
+	merge func(dst, src *read.Debug)
+}{
+	{PartIndex, "index.json", func(dst, src *read.Debug) { dst.Index = src.Index }},
+	{PartAgent, "agent.json", func(dst, src *read.Debug) {
+		members := dst.Agent.Members
+		dst.Agent = src.Agent
+		dst.Agent.Members = members
+	}},
+	{PartMembers, "members.json", func(dst, src *read.Debug) { dst.Agent.Members = src.Agent.Members }},
+	{PartHost, "host.json", func(dst, src *read.Debug) { dst.Host = src.Host }},
+	{PartMetrics, "metrics.json", func(dst, src *read.Debug) { dst.Metrics = src.Metrics }},
+}
+
+// Decoded bundle contents.
+type (
+	Debug          = read.Debug
+	Agent          = read.Agent
+	Config         = read.Config
+	DebugConfig    = read.DebugConfig
+	Stats          = read.Stats
+	Member         = read.Member
+	Host           = read.Host
+	Index          = read.Index
+	Metrics        = read.Metrics
+	Metric         = read.Metric
+	Gauge          = read.Gauge
+	Points         = read.Points
+	Counters       = read.Counters
+	Samples        = read.Samples
+	RaftServer     = read.RaftServer
+	RaftPeer       = read.RaftPeer
+	Series         = read.Series
+	SeriesSample   = read.SeriesSample
+	ProfileCapture = read.ProfileCapture
+	LogEntry       = log.LogEntry
+	// RPCCall is an RPC served by the agent, from its rpc_server_call DEBUG log entries.
+	RPCCall = log.Entry
+)
+
+// DecodeError is returned when a bundle file cannot be read or decoded. It names the bundle
+// directory, file, byte offset and mistyped field, and unwraps to the underlying error.
+type DecodeError = read.DecodeError
+
+// DamagedCapture is a capture of metrics.json skipped because it did not decode; see Metrics.Damaged.
+type DamagedCapture = read.DamagedCapture
+
+// Progress reports a bundle part starting or finishing decoding.
+type Progress struct {
+	Part string
+	File string
+	// Size is the size of the file in bytes.
+	Size int64
+	// Done is set once the part has decoded, with Err set when it failed.
+	Done bool
+	// Cached is set when the part was decoded from the cache of WithCache.
+	Cached  bool
+	Elapsed time.Duration
+	Err     error
+}
+
+// Option configures a Bundle opened by Open.
+type Option func(*Bundle)
+
+// WithProgress calls fn as each bundle part starts and finishes decoding. Calls are never
+// concurrent, but may come from any goroutine.
+func WithProgress(fn func(Progress)) Option {
+	return func(b *Bundle) { b.progress = fn }
+}
+
+// Bundle is an opened consul debug bundle. It is safe for concurrent use: Decode, Debug and the
+// accessors return snapshots of the contents decoded so far, which later decodes leave unchanged.
+// Callers must not modify them.
+type Bundle struct {
+	dir string
+
+	mu sync.Mutex
+	// data is the latest snapshot, replaced rather than modified as each part is merged.
+	data    *read.Debug
+	decodes map[string]*decode
+
+	progressMu sync.Mutex
+	progress   func(Progress)
+
+	cacheDir  string
+	cacheOnce sync.Once
+	cacheKey  string
+	cacheErr  error
+
+	redactor *redact.Redactor
+}
+
+// decode is the decoding of one bundle part, done once decoded.
+type decode struct {
+	done      chan struct{}
+	err       error
+	abandoned bool
+}
+
+// Open opens the bundle at path, an extracted bundle directory or a bundle .tar.gz archive, which
+// is extracted alongside the archive. Only index.json is decoded up front.
+func Open(path string, opts ...Option) (*Bundle, error) {
+	dir := path
+	if strings.HasSuffix(path, ".tar.gz") {
+		var err error
+		if dir, err = read.ExtractBundle(path); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "index.json")); err != nil {
+		return nil, fmt.Errorf("%s is not a consul debug bundle: %w", path, err)
+	}
+	b := &Bundle{dir: dir, data: &read.Debug{}, decodes: make(map[string]*decode)}
+	for _, opt := range opts {
+		opt(b)
+	}
+	if _, err := b.Decode(PartIndex); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Load opens the bundle at path and decodes index.json, agent.json and whichever of members.json,
+// host.json and metrics.json the bundle captured. Damaged metrics.json captures are skipped and
+// listed in Metrics.Damaged rather than failing the load.
+func Load(path string, opts ...Option) (*Debug, error) {
+	b, err := Open(path, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return b.DecodeAvailable()
+}
+
+// Dir returns the extracted bundle directory.
+func (b *Bundle) Dir() string { return b.dir }
+
+// Has reports whether the bundle captured the file of part.
+func (b *Bundle) Has(part string) bool {
+	for _, p := range parts {
+		if p.name == part {
+			_, err := os.Stat(filepath.Join(b.dir, p.file))
+			return err == nil
+		}
+	}
+	return false
+}
+
+// Decode decodes the given parts, each at most once, and returns a snapshot of the bundle contents
+// decoded so far. Failures are *DecodeError; a part that failed keeps failing with the same error.
+func (b *Bundle) Decode(names ...string) (*Debug, error) {
+	return b.DecodeContext(context.Background(), names...)
+}
+
+// DecodeContext is Decode, decoding the given parts concurrently so that it takes as long as the
+// slowest part. Once ctx is done it returns ctx.Err() and abandons decodes it started; parts
+// abandoned this way are decoded again by later calls.
+func (b *Bundle) DecodeContext(ctx context.Context, names ...string) (*Debug, error) {
+	for _, name := range names {
+		if !known(name) {
+			return nil, fmt.Errorf("unknown bundle part %q", name)
+		}
+	}
+	var waits []*decode
+	b.mu.Lock()
+	for _, p := range parts {
+		if !contains(names, p.name) {
+			continue
+		}
+		d, ok := b.decodes[p.name]
+		if !ok {
+			d = &decode{done: make(chan struct{})}
+			b.decodes[p.name] = d
+			go b.decode(ctx, p.name, p.file, p.merge, d)
+		}
+		waits = append(waits, d)
+	}
+	b.mu.Unlock()
+
+	for _, d := range waits {
+		select {
+		case <-d.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	// Report failures in part order, regardless of which decode finished first.
+	for _, d := range waits {
+		if d.abandoned {
+			// Another caller gave up on a decode this call was waiting on.
+			return b.DecodeContext(ctx, names...)
+		}
+		if d.err != nil {
+			return nil, d.err
+		}
+	}
+	return b.Debug(), nil
+}
+
+func (b *Bundle) decode(ctx context.Context, name, file string, merge func(dst, src *read.Debug), d *decode) {
+	var size int64
+	if info, err := os.Stat(filepath.Join(b.dir, file)); err == nil {
+		size = info.Size()
+	}
+	b.report(Progress{Part: name, File: file, Size: size})
+	start := time.Now()
+
+	var part read.Debug
+	var cached bool
+	var err error
+	if name == PartMetrics && b.cacheDir != "" {
+		cached, err = b.decodeCachedMetrics(ctx, &part)
+	} else {
+		err = part.DecodeJSONContext(ctx, b.dir, name)
+	}
+	if err == nil {
+		err = b.redactPart(name, &part)
+	}
+
+	b.mu.Lock()
+	if err == nil {
+		// Merge into a copy, leaving snapshots already returned untouched.
+		next := *b.data
+		merge(&next, &part)
+		b.data = &next
+	} else if ctx.Err() != nil && errors.Is(err, ctx.Err()) {
+		// Abandoned rather than failed, so a later call decodes the part again.
+		d.abandoned = true
+		delete(b.decodes, name)
+	}
+	d.err = err
+	b.mu.Unlock()
+	// Report before releasing waiters, so progress is complete once DecodeContext returns.
+	b.report(Progress{Part: name, File: file, Size: size, Done: true, Cached: cached, Elapsed: time.Since(start), Err: err})
+	close(d.done)
+}
+
+func (b *Bundle) report(p Progress) {
+	if b.progress == nil {
+		return
+	}
+	b.progressMu.Lock()
+	defer b.progressMu.Unlock()
+	b.progress(p)
+}
+
+func known(name string) bool {
+	for _, p := range parts {
+		if p.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Debug returns a snapshot of the bundle contents decoded so far, for use with the analysis
+// functions of Debug.
+func (b *Bundle) Debug() *Debug {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.data
+}
+
+// DecodeAvailable decodes index.json, agent.json and every other part the bundle captured.
+func (b *Bundle) DecodeAvailable() (*Debug, error) {
+	names := []string{PartIndex, PartAgent}
+	for _, part := range []string{PartMembers, PartHost, PartMetrics} {
+		if b.Has(part) {
+			names = append(names, part)
+		}
+	}
+	return b.Decode(names...)
+}
+
+// Index returns the capture settings of index.json.
+func (b *Bundle) Index() Index {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.data.Index
+}
+
+// Agent returns the agent self information of agent.json.
+func (b *Bundle) Agent() (*Agent, error) {
+	data, err := b.Decode(PartAgent)
+	if err != nil {
+		return nil, err
+	}
+	return &data.Agent, nil
+}
+
+// Members returns the serf members of members.json.
+func (b *Bundle) Members() ([]Member, error) {
+	data, err := b.Decode(PartMembers)
+	if err != nil {
+		return nil, err
+	}
+	return data.Agent.Members, nil
+}
+
+// Raft returns the latest raft configuration seen by a server agent.
+func (b *Bundle) Raft() ([]RaftPeer, error) {
+	data, err := b.Decode(PartAgent)
+	if err != nil {
+		return nil, err
+	}
+	if !data.Agent.Config.Server {
+		return nil, errors.New("raft configuration is only captured by server agents")
+	}
+	return data.RaftPeers()
+}
+
+// Host returns the host information of host.json.
+func (b *Bundle) Host() (*Host, error) {
+	data, err := b.Decode(PartHost)
+	if err != nil {
+		return nil, err
+	}
+	return &data.Host, nil
+}
+
+// Metrics returns the metric captures of metrics.json.
+func (b *Bundle) Metrics() (*Metrics, error) {
+	data, err := b.Decode(PartMetrics)
+	if err != nil {
+		return nil, err
+	}
+	return &data.Metrics, nil
+}
+
+// Select returns the metric series matching a selector such as
+// consul.raft.commitTime{quantile="0.99"}; see the metrics query command.
+func (b *Bundle) Select(query string) ([]Series, error) {
+	sel, err := read.ParseMetricSelector(query)
+	if err != nil {
+		return nil, err
+	}
+	metrics, err := b.Metrics()
+	if err != nil {
+		return nil, err
+	}
+	return metrics.Select(sel)
+}
+
+// Profiles returns the per-interval pprof captures in chronological order.
+func (b *Bundle) Profiles() ([]ProfileCapture, error) {
+	return read.ReadProfileCaptures(b.dir)
+}
+
+// LogFile returns the path of the bundle's consul.log.
+func (b *Bundle) LogFile() string {
+	return filepath.Join(b.dir, "consul.log")
+}
+
+// RPCCalls returns the RPCs served by the agent, optionally only those of one method, from the
+// rpc_server_call entries of a DEBUG or TRACE level consul.log.
+func (b *Bundle) RPCCalls(method string) ([]RPCCall, error) {
+	return log.ParseRPCMethods(b.LogFile(), method)
+}
@@ -0,0 +1,328 @@
+package loader
+
+import (
+	"consul-debug-read/pkg/redact"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func writeBundle(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestLoad(t *testing.T) {
+	index := `{"Version":2,"AgentVersion":"1.17.2","Interval":"10s","Duration":"20s","Targets":["agent","metrics"]}`
+	metric := `{"Timestamp":"2024-02-07 12:40:00 -0500 EST","Gauges":[{"Name":"consul.runtime.num_goroutines","Value":120}]}`
+
+	dir := writeBundle(t, map[string]string{
+		"index.json":   index,
+		"agent.json":   `{"Config":{"NodeName":"server-1","Server":true}}`,
+		"metrics.json": metric + "\n" + `{"Timestamp":"2024-02-07 12:40:10 -0500 EST","Gau`,
+	})
+	b, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if b.Agent.Config.NodeName != "server-1" || len(b.Metrics.Metrics) != 1 || len(b.Metrics.Damaged) != 1 {
+		t.Errorf("expected server-1 with one capture and one damaged capture, got %s, %d, %d",
+			b.Agent.Config.NodeName, len(b.Metrics.Metrics), len(b.Metrics.Damaged))
+	}
+
+	dir = writeBundle(t, map[string]string{
+		"index.json": index,
+		"agent.json": `{"Config":{"NodeName":"server-1","Server":"yes"}}`,
+	})
+	_, err = Load(dir)
+	var decodeErr *DecodeError
+	if !errors.As(err, &decodeErr) {
+		t.Fatalf("expected a *DecodeError, got %v", err)
+	}
+	var typeErr *json.UnmarshalTypeError
+	if decodeErr.File != "agent.json" || decodeErr.Path != dir || decodeErr.Field != "Config.Server" || decodeErr.Offset <= 0 || !errors.As(err, &typeErr) {
+		t.Errorf("unexpected decode error %+v", decodeErr)
+	}
+
+	_, err = Load(writeBundle(t, map[string]string{"index.json": index}))
+	if !errors.As(err, &decodeErr) || decodeErr.File != "agent.json" || !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected a missing agent.json, got %v", err)
+	}
+}
+
+func TestOpen(t *testing.T) {
+	dir := writeBundle(t, map[string]string{
+		"index.json": `{"Version":2,"AgentVersion":"1.17.2","Interval":"10s","Duration":"20s","Targets":["agent","metrics","logs"]}`,
+		"agent.json": `{"Config":{"NodeName":"client-1","Server":false}}`,
+		"metrics.json": `{"Timestamp":"2024-02-07 12:40:00 -0500 EST","Gauges":[{"Name":"consul.runtime.num_goroutines","Value":120}],` +
+			`"Counters":[{"Name":"consul.rpc.request","Count":4,"Rate":0.4,"Sum":4,"Min":1,"Max":1,"Mean":1}]}`,
+		"consul.log": "2024-02-07T12:40:00.000-0500 [INFO]  agent: Synced node info\n" +
+			"2024-02-07T12:40:05.000-0500 [ERROR] agent.client: RPC failed to server: error=\"rpc error\"\n" +
+			"2024-02-07T12:40:07.000-0500 [INFO]  agent.client: Retrying\n",
+	})
+	b, err := Open(dir)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if b.Index().AgentVersion != "1.17.2" || b.Debug().Agent.Config.NodeName != "" {
+		t.Errorf("expected only index.json to be decoded on open")
+	}
+	if b.Has(PartHost) || !b.Has(PartMetrics) {
+		t.Errorf("expected metrics.json and no host.json")
+	}
+	if _, err = b.Host(); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected a missing host.json, got %v", err)
+	}
+	if _, err = b.Raft(); err == nil {
+		t.Errorf("expected no raft configuration for a client agent")
+	}
+
+	logs, err := b.Logs(LogFilter{Levels: []string{LevelError}})
+	if err != nil {
+		t.Fatalf("Logs: %v", err)
+	}
+	defer logs.Close()
+	var messages []string
+	for logs.Next() {
+		messages = append(messages, logs.Entry().Message)
+	}
+	if err = logs.Err(); err != nil || len(messages) != 1 || messages[0] != `RPC failed to server: error="rpc error"` {
+		t.Errorf("expected the single ERROR entry, got %q, %v", messages, err)
+	}
+	entries, err := b.LogEntries(LogFilter{Source: "agent.client"})
+	if err != nil || len(entries) != 2 {
+		t.Errorf("expected 2 agent.client entries, got %d, %v", len(entries), err)
+	}
+
+	samples, err := b.Samples()
+	if err != nil {
+		t.Fatalf("Samples: %v", err)
+	}
+	var got []Sample
+	for samples.Next() {
+		got = append(got, samples.Sample())
+	}
+	if samples.Err() != nil || len(got) != 2 {
+		t.Fatalf("expected 2 samples, got %d, %v", len(got), samples.Err())
+	}
+	if got[0].Type != TypeGauge || got[0].Value != 120 || got[1].Type != TypeCounter || got[1].Value != 4 || got[1].Rate != 0.4 {
+		t.Errorf("unexpected samples %+v", got)
+	}
+}
+
+func TestDecodeContext(t *testing.T) {
+	dir := writeBundle(t, map[string]string{
+		"index.json":   `{"Version":2,"AgentVersion":"1.17.2","Interval":"10s","Duration":"20s"}`,
+		"agent.json":   `{"Config":{"NodeName":"server-1","Server":true}}`,
+		"members.json": `[{"Name":"server-1","Addr":"10.0.0.1","Port":8301,"Status":1}]`,
+		"host.json":    `{"Host":{"platform":"linux"}}`,
+	})
+	var mu sync.Mutex
+	var done []string
+	b, err := Open(dir, WithProgress(func(p Progress) {
+		mu.Lock()
+		defer mu.Unlock()
+		if p.Done && p.Err == nil && p.Part != PartIndex {
+			done = append(done, p.Part)
+		}
+	}))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err = b.DecodeContext(ctx, PartAgent, PartMembers); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected a cancelled decode, got %v", err)
+	}
+	if _, err = b.Decode(PartIndex, "nope"); err == nil {
+		t.Errorf("expected an unknown part to fail")
+	}
+
+	data, err := b.DecodeContext(context.Background(), PartAgent, PartMembers, PartHost)
+	if err != nil {
+		t.Fatalf("DecodeContext: %v", err)
+	}
+	if data.Agent.Config.NodeName != "server-1" || len(data.Agent.Members) != 1 || data.Host.Host.Platform != "linux" {
+		t.Errorf("expected agent, members and host to be decoded, got %+v", data)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	sort.Strings(done)
+	if len(done) != 3 || done[0] != PartAgent || done[1] != PartHost || done[2] != PartMembers {
+		t.Errorf("expected progress for agent, host and members, got %v", done)
+	}
+	if data.Metrics.Metrics != nil {
+		t.Errorf("expected metrics.json to be left undecoded")
+	}
+}
+
+// TestConcurrentDecode is meant to be run with -race.
+func TestConcurrentDecode(t *testing.T) {
+	dir := writeBundle(t, map[string]string{
+		"index.json":   `{"Version":2,"AgentVersion":"1.17.2","Interval":"10s","Duration":"20s"}`,
+		"agent.json":   `{"Config":{"NodeName":"server-1","Server":true}}`,
+		"members.json": `[{"Name":"server-1","Addr":"10.0.0.1","Port":8301,"Status":1}]`,
+		"host.json":    `{"Host":{"platform":"linux"}}`,
+	})
+	b, err := Open(dir)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	before := b.Debug()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			agent, err := b.Agent()
+			if err != nil {
+				t.Errorf("Agent: %v", err)
+				return
+			}
+			if agent.Config.NodeName != "server-1" || len(agent.Members) > 1 {
+				t.Errorf("unexpected agent %+v", agent.Config)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			data, err := b.Decode(PartMembers, PartHost)
+			if err != nil {
+				t.Errorf("Decode: %v", err)
+				return
+			}
+			if len(data.Agent.Members) != 1 || data.Host.Host.Platform != "linux" {
+				t.Errorf("unexpected members %+v", data.Agent.Members)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if before.Agent.Config.NodeName != "" || before.Agent.Members != nil {
+		t.Errorf("expected a snapshot to be left unchanged by later decodes, got %+v", before.Agent)
+	}
+	if data := b.Debug(); data.Agent.Config.NodeName != "server-1" || len(data.Agent.Members) != 1 {
+		t.Errorf("expected agent and members to be merged, got %+v", data.Agent)
+	}
+}
+
+func TestCache(t *testing.T) {
+	metric := `{"Timestamp":"2024-02-07 12:40:00 -0500 EST","Gauges":[{"Name":"consul.runtime.num_goroutines","Value":120}]}`
+	dir := writeBundle(t, map[string]string{
+		"index.json":   `{"Version":2,"AgentVersion":"1.17.2","Interval":"10s","Duration":"20s"}`,
+		"metrics.json": metric + "\n" + `{"Timestamp":"2024-02-07 12:40:10 -0500 EST","Gau`,
+		"consul.log": "2024-02-07T12:40:00.000-0500 [INFO]  agent: Synced node info\n" +
+			"2024-02-07T12:40:05.000-0500 [WARN]  agent.server.raft: heartbeat timeout reached\n",
+	})
+	cache := t.TempDir()
+	open := func() (*Bundle, bool) {
+		var cached bool
+		b, err := Open(dir, WithCache(cache), WithProgress(func(p Progress) {
+			if p.Part == PartMetrics && p.Done {
+				cached = p.Cached
+			}
+		}))
+		if err != nil {
+			t.Fatalf("Open: %v", err)
+		}
+		metrics, err := b.Metrics()
+		if err != nil {
+			t.Fatalf("Metrics: %v", err)
+		}
+		if len(metrics.Metrics) != 1 || len(metrics.Damaged) != 1 || len(metrics.MetricsMap["consul.runtime.num_goroutines"]) != 1 {
+			t.Errorf("expected one capture, one damaged capture and an index, got %+v", metrics)
+		}
+		entries, err := b.LogEntries(LogFilter{Levels: []string{LevelWarn}})
+		if err != nil || len(entries) != 1 || entries[0].Source != "agent.server.raft" {
+			t.Errorf("expected the WARN entry, got %+v, %v", entries, err)
+		}
+		return b, cached
+	}
+
+	b, cached := open()
+	if cached {
+		t.Errorf("expected metrics.json to be decoded on first open")
+	}
+	cacheDir, err := b.CacheDir()
+	if err != nil {
+		t.Fatalf("CacheDir: %v", err)
+	}
+	for _, name := range []string{metricsCacheFile, logsCacheFile} {
+		if _, err = os.Stat(filepath.Join(cacheDir, name)); err != nil {
+			t.Errorf("expected %s to be cached: %v", name, err)
+		}
+	}
+	if _, cached = open(); !cached {
+		t.Errorf("expected metrics.json to be read from the cache on second open")
+	}
+
+	if err = os.WriteFile(filepath.Join(dir, "metrics.json"), []byte(metric), 0644); err != nil {
+		t.Fatal(err)
+	}
+	b, err = Open(dir, WithCache(cache))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if changed, _ := b.CacheDir(); changed == cacheDir {
+		t.Errorf("expected a changed metrics.json to change the cache directory")
+	}
+}
+
+func TestRedactor(t *testing.T) {
+	metric := `{"Timestamp":"2024-02-07 12:40:00 -0500 EST","Counters":[{"Name":"consul.client.rpc","Count":1,"Labels":{"token":"s3cr3t","method":"KVS.Get"}}]}`
+	dir := writeBundle(t, map[string]string{
+		"index.json":   `{"Version":2,"AgentVersion":"1.17.2","Interval":"10s","Duration":"20s"}`,
+		"agent.json":   `{"Config":{"NodeName":"server-1"},"DebugConfig":{"EncryptKey":"c2VjcmV0","ACLTokens":{"ACLAgentToken":"6a2b1c3d-aaaa-bbbb-cccc-123456789abc"}}}`,
+		"metrics.json": metric,
+		"consul.log":   "2024-02-07T12:40:00.000-0500 [WARN]  agent: Coordinate update blocked by ACLs: accessorID=1b4c5e2f-0a6b-4c83-9b1e-5d7e8f9a0b1c\n",
+	})
+	for _, cache := range []string{"", t.TempDir()} {
+		opts := []Option{WithRedactor(redact.Default())}
+		if cache != "" {
+			opts = append(opts, WithCache(cache))
+		}
+		b, err := Open(dir, opts...)
+		if err != nil {
+			t.Fatalf("Open: %v", err)
+		}
+		data, err := b.Decode(PartAgent, PartMetrics)
+		if err != nil {
+			t.Fatalf("Decode: %v", err)
+		}
+		if data.Agent.DebugConfig.EncryptKey != redact.Marker || data.Agent.DebugConfig.ACLTokens.ACLAgentToken != redact.Marker {
+			t.Errorf("expected redacted DebugConfig secrets, got %+v", data.Agent.DebugConfig.ACLTokens)
+		}
+		if strings.Contains(string(data.Agent.RawDebugConfig), "c2VjcmV0") {
+			t.Errorf("expected the raw DebugConfig redacted, got %s", data.Agent.RawDebugConfig)
+		}
+		if labels := data.Metrics.Metrics[0].Counters[0].Labels; labels["token"] != redact.Marker || labels["method"] != "KVS.Get" {
+			t.Errorf("expected the token label redacted, got %v", labels)
+		}
+		entries, err := b.LogEntries(LogFilter{})
+		if err != nil || len(entries) != 1 || !strings.HasSuffix(entries[0].Message, "accessorID="+redact.Marker) {
+			t.Errorf("expected the accessor ID redacted, got %+v, %v", entries, err)
+		}
+	}
+
+	b, err := Open(dir)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	agent, err := b.Agent()
+	if err != nil || agent.DebugConfig.EncryptKey != "c2VjcmV0" {
+		t.Errorf("expected secrets as captured without a redactor, got %q, %v", agent.DebugConfig.EncryptKey, err)
+	}
+}
@@ -0,0 +1,236 @@
+package loader
+
+import (
+	"bufio"
+	"consul-debug-read/internal/read"
+	"consul-debug-read/internal/read/log"
+	"context"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+)
+
+// cacheFormat is hashed into every cache key, so bump it whenever the layout of the cache files
+// changes and older caches are ignored.
+const cacheFormat = "consul-debug-read cache v1"
+
+// Cache files of a bundle cache directory.
+const (
+	metricsCacheFile = "metrics.gob"
+	logsCacheFile    = "logs.gob"
+)
+
+// cachedFiles are the bundle files the cache stands in for, and so the files its key hashes.
+var cachedFiles = []string{"metrics.json", "consul.log"}
+
+// WithCache caches the decoded metrics.json captures and parsed consul.log entries under dir, in
+// a directory named after the SHA-256 of the bundle's metrics.json and consul.log, so opening the
+// same bundle again reads the compact cache instead of parsing them. A bundle whose files change
+// hashes to a new directory; stale directories are left for the caller to remove.
+func WithCache(dir string) Option {
+	return func(b *Bundle) { b.cacheDir = dir }
+}
+
+// CacheDir returns the cache directory of the bundle, hashing metrics.json and consul.log on first use.
+func (b *Bundle) CacheDir() (string, error) {
+	if b.cacheDir == "" {
+		return "", errors.New("bundle was opened without a cache")
+	}
+	b.cacheOnce.Do(func() { b.cacheKey, b.cacheErr = hashBundle(b.dir) })
+	if b.cacheErr != nil {
+		return "", b.cacheErr
+	}
+	return filepath.Join(b.cacheDir, b.cacheKey), nil
+}
+
+func hashBundle(dir string) (string, error) {
+	h := sha256.New()
+	_, _ = io.WriteString(h, cacheFormat)
+	for _, name := range cachedFiles {
+		f, err := os.Open(filepath.Join(dir, name))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		info, err := f.Stat()
+		if err == nil {
+			_, _ = fmt.Fprintf(h, "\x00%s\x00%d\x00", name, info.Size())
+			_, err = io.Copy(h, f)
+		}
+		f.Close()
+		if err != nil {
+			return "", err
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// cachedMetrics is the cached form of Metrics; MetricsMap is rebuilt on load.
+type cachedMetrics struct {
+	Metrics []Metric
+	Damaged []cachedDamage
+}
+
+type cachedDamage struct {
+	Offset    int64
+	Length    int64
+	Truncated bool
+	Err       string
+}
+
+// decodeCachedMetrics decodes metrics.json into part from the cache, falling back to decoding
+// metrics.json and caching the result. It reports whether the cache was used.
+func (b *Bundle) decodeCachedMetrics(ctx context.Context, part *read.Debug) (bool, error) {
+	dir, err := b.CacheDir()
+	if err != nil {
+		hclog.L().Debug("unable to use bundle cache", "error", err)
+		return false, part.DecodeJSONContext(ctx, b.dir, PartMetrics)
+	}
+	file := filepath.Join(dir, metricsCacheFile)
+	var cached cachedMetrics
+	err = readCache(ctx, file, func(dec *gob.Decoder) error { return dec.Decode(&cached) })
+	if err == nil {
+		part.Metrics.Metrics = cached.Metrics
+		for _, d := range cached.Damaged {
+			part.Metrics.Damaged = append(part.Metrics.Damaged, DamagedCapture{
+				Offset: d.Offset, Length: d.Length, Truncated: d.Truncated, Err: errors.New(d.Err),
+			})
+		}
+		part.Metrics.WarnDamaged()
+		part.BuildMetricsIndex()
+		return true, nil
+	}
+	if ctx.Err() != nil {
+		return false, ctx.Err()
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		hclog.L().Debug("ignoring unreadable metrics cache", "file", file, "error", err)
+	}
+
+	if err = part.DecodeJSONContext(ctx, b.dir, PartMetrics); err != nil {
+		return false, err
+	}
+	cached = cachedMetrics{Metrics: part.Metrics.Metrics}
+	for _, d := range part.Metrics.Damaged {
+		cached.Damaged = append(cached.Damaged, cachedDamage{Offset: d.Offset, Length: d.Length, Truncated: d.Truncated, Err: d.Err.Error()})
+	}
+	if err = writeCache(file, func(enc *gob.Encoder) error { return enc.Encode(cached) }); err != nil {
+		hclog.L().Debug("failed to cache metrics", "file", file, "error", err)
+	}
+	return false, nil
+}
+
+// cachedLogs returns an iterator over the cached consul.log entries matching filter, first
+// parsing consul.log into the cache when needed.
+func (b *Bundle) cachedLogs(filter LogFilter) (*LogIterator, error) {
+	dir, err := b.CacheDir()
+	if err != nil {
+		return nil, err
+	}
+	file := filepath.Join(dir, logsCacheFile)
+	if _, err = os.Stat(file); errors.Is(err, os.ErrNotExist) {
+		if err = b.cacheLogs(file); err != nil {
+			return nil, err
+		}
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	return &LogIterator{file: f, scanner: &cachedScanner{dec: gob.NewDecoder(bufio.NewReader(f)), filter: filter}}, nil
+}
+
+// cacheLogs parses every entry of consul.log into file, as a stream of gob encoded entries.
+func (b *Bundle) cacheLogs(file string) error {
+	logFile, err := os.Open(b.LogFile())
+	if err != nil {
+		return err
+	}
+	defer logFile.Close()
+	levels := strings.Join([]string{LevelTrace, LevelDebug, LevelInfo, LevelWarn, LevelError}, "|")
+	return writeCache(file, func(enc *gob.Encoder) error {
+		scanner := log.NewScanner(logFile, levels, "", time.Time{}, time.Time{})
+		for scanner.Scan() {
+			entry := scanner.Entry()
+			if err := enc.Encode(&entry); err != nil {
+				return err
+			}
+		}
+		return scanner.Err()
+	})
+}
+
+// cachedScanner scans the cached consul.log entries matching a LogFilter.
+type cachedScanner struct {
+	dec    *gob.Decoder
+	filter LogFilter
+	entry  LogEntry
+	err    error
+}
+
+func (s *cachedScanner) Scan() bool {
+	for s.err == nil {
+		var entry LogEntry
+		if err := s.dec.Decode(&entry); err != nil {
+			if err != io.EOF {
+				s.err = err
+			}
+			return false
+		}
+		if s.filter.match(entry) {
+			s.entry = entry
+			return true
+		}
+	}
+	return false
+}
+
+func (s *cachedScanner) Entry() LogEntry { return s.entry }
+
+func (s *cachedScanner) Err() error { return s.err }
+
+// readCache decodes the cache file with decode.
+func readCache(ctx context.Context, file string, decode func(*gob.Decoder) error) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return decode(gob.NewDecoder(bufio.NewReader(read.NewContextReader(ctx, f))))
+}
+
+// writeCache writes the cache file with encode, replacing it only once it is complete so that
+// concurrent readers never see a partial file.
+func writeCache(file string, encode func(*gob.Encoder) error) error {
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(file), filepath.Base(file)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	w := bufio.NewWriter(tmp)
+	err = encode(gob.NewEncoder(w))
+	if err == nil {
+		err = w.Flush()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), file)
+}
@@ -0,0 +1,118 @@
+package loader
+
+import (
+	"consul-debug-read/internal/read/log"
+	"consul-debug-read/pkg/redact"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+)
+
+// Log levels of consul.log entries.
+const (
+	LevelTrace = log.TraceLevel
+	LevelDebug = log.DebugLevel
+	LevelInfo  = log.InfoLevel
+	LevelWarn  = log.WarnLevel
+	LevelError = log.ErrorLevel
+)
+
+// LogFilter selects consul.log entries. Zero values select everything.
+type LogFilter struct {
+	Levels []string
+	// Source is the exact logger name, e.g. agent.server.raft.
+	Source string
+	Since  time.Time
+	Until  time.Time
+}
+
+// LogIterator iterates over the consul.log entries matching a LogFilter:
+//
+//	for it.Next() {
+//		entry := it.Entry()
+//	}
+//	if err := it.Err(); err != nil {
+type LogIterator struct {
+	file     *os.File
+	scanner  entryScanner
+	redactor *redact.Redactor
+}
+
+// entryScanner scans log entries, from consul.log or from the cache.
+type entryScanner interface {
+	Scan() bool
+	Entry() LogEntry
+	Err() error
+}
+
+// Logs returns an iterator over the consul.log entries matching filter, which must be closed.
+// With WithCache, consul.log is parsed into the cache once and later reads come from the cache.
+func (b *Bundle) Logs(filter LogFilter) (*LogIterator, error) {
+	if b.cacheDir != "" {
+		it, err := b.cachedLogs(filter)
+		if err == nil {
+			it.redactor = b.redactor
+			return it, nil
+		}
+		hclog.L().Debug("unable to use cached logs, parsing consul.log", "error", err)
+	}
+	file, err := os.Open(b.LogFile())
+	if err != nil {
+		return nil, err
+	}
+	levels := filter.Levels
+	if len(levels) == 0 {
+		levels = []string{LevelTrace, LevelDebug, LevelInfo, LevelWarn, LevelError}
+	}
+	return &LogIterator{
+		file:     file,
+		scanner:  log.NewScanner(file, strings.Join(levels, "|"), filter.Source, filter.Since, filter.Until),
+		redactor: b.redactor,
+	}, nil
+}
+
+// match reports whether entry is selected by the filter.
+func (f LogFilter) match(entry LogEntry) bool {
+	if len(f.Levels) > 0 && !contains(f.Levels, entry.Level) {
+		return false
+	}
+	if f.Source != "" && entry.Source != f.Source {
+		return false
+	}
+	if !f.Since.IsZero() && entry.Timestamp.Before(f.Since) {
+		return false
+	}
+	return f.Until.IsZero() || !entry.Timestamp.After(f.Until)
+}
+
+// LogEntries returns every consul.log entry matching filter.
+func (b *Bundle) LogEntries(filter LogFilter) ([]LogEntry, error) {
+	it, err := b.Logs(filter)
+	if err != nil {
+		return nil, err
+	}
+	defer it.Close()
+	var entries []LogEntry
+	for it.Next() {
+		entries = append(entries, it.Entry())
+	}
+	return entries, it.Err()
+}
+
+// Next advances to the next entry, returning false when there are no more or on error.
+func (it *LogIterator) Next() bool { return it.scanner.Scan() }
+
+// Entry returns the current entry, its message redacted with the bundle's WithRedactor.
+func (it *LogIterator) Entry() LogEntry {
+	entry := it.scanner.Entry()
+	entry.Message = it.redactor.String(entry.Message)
+	return entry
+}
+
+// Err returns the first error met while iterating.
+func (it *LogIterator) Err() error { return it.scanner.Err() }
+
+// Close closes consul.log.
+func (it *LogIterator) Close() error { return it.file.Close() }
@@ -0,0 +1,99 @@
+package loader
+
+import (
+	"consul-debug-read/internal/read"
+	"consul-debug-read/pkg/redact"
+	"encoding/json"
+)
+
+// WithRedactor redacts secrets with r as the bundle is read: the DebugConfig of agent.json, the
+// labels of metrics.json and the messages of consul.log entries. Caches hold the bundle as
+// captured, so the same cache serves any redactor.
+func WithRedactor(r *redact.Redactor) Option {
+	return func(b *Bundle) { b.redactor = r }
+}
+
+// Redactor returns the redactor of WithRedactor, nil when the bundle is read as captured. Callers
+// reading bundle files themselves, such as consul.log, apply it to what they display.
+func (b *Bundle) Redactor() *redact.Redactor { return b.redactor }
+
+// redactPart redacts the decoded part before it is merged into the bundle contents.
+func (b *Bundle) redactPart(name string, part *read.Debug) error {
+	if b.redactor == nil {
+		return nil
+	}
+	switch name {
+	case PartAgent:
+		return redactAgent(b.redactor, &part.Agent)
+	case PartMetrics:
+		if redactMetricLabels(b.redactor, &part.Metrics) {
+			part.BuildMetricsIndex()
+		}
+	}
+	return nil
+}
+
+// redactAgent redacts the DebugConfig of agent, decoding the typed DebugConfig again from the
+// redacted document so both agree.
+func redactAgent(r *redact.Redactor, agent *read.Agent) error {
+	if len(agent.RawDebugConfig) == 0 {
+		return nil
+	}
+	raw, err := r.JSON(agent.RawDebugConfig)
+	if err != nil {
+		return err
+	}
+	agent.RawDebugConfig = raw
+	agent.DebugConfig = read.DebugConfig{}
+	// Type errors were already reported when agent.json was decoded.
+	_ = json.Unmarshal(raw, &agent.DebugConfig)
+	return nil
+}
+
+// redactMetricLabels redacts metric label values, reporting whether any changed. Label values
+// repeat across captures, so each distinct label is only redacted once.
+func redactMetricLabels(r *redact.Redactor, metrics *read.Metrics) bool {
+	seen := make(map[[2]string]string)
+	changed := false
+	labels := func(m map[string]string) map[string]string {
+		var out map[string]string
+		for k, v := range m {
+			redacted, ok := seen[[2]string{k, v}]
+			if !ok {
+				redacted = r.Value(k, v)
+				seen[[2]string{k, v}] = redacted
+			}
+			if redacted == v {
+				continue
+			}
+			if out == nil {
+				out = make(map[string]string, len(m))
+				for k2, v2 := range m {
+					out[k2] = v2
+				}
+			}
+			out[k] = redacted
+		}
+		if out == nil {
+			return m
+		}
+		changed = true
+		return out
+	}
+	for i := range metrics.Metrics {
+		m := &metrics.Metrics[i]
+		for j := range m.Gauges {
+			m.Gauges[j].Labels = labels(m.Gauges[j].Labels)
+		}
+		for j := range m.Points {
+			m.Points[j].Labels = labels(m.Points[j].Labels)
+		}
+		for j := range m.Counters {
+			m.Counters[j].Labels = labels(m.Counters[j].Labels)
+		}
+		for j := range m.Samples {
+			m.Samples[j].Labels = labels(m.Samples[j].Labels)
+		}
+	}
+	return changed
+}
@@ -0,0 +1,93 @@
+package loader
+
+import (
+	"consul-debug-read/internal/read"
+	"time"
+)
+
+// Metric sample types.
+const (
+	TypeGauge   = "gauge"
+	TypePoints  = "points"
+	TypeCounter = "counter"
+	TypeSample  = "sample"
+)
+
+// Sample is one metric value of one metrics.json capture. Value is the gauge or points value, the
+// counter count or the sample mean, as shown by the metrics commands; the aggregate fields are only
+// set for counters and samples.
+type Sample struct {
+	Timestamp time.Time
+	Name      string
+	Type      string
+	Labels    map[string]string
+	Value     float64
+
+	Count  int
+	Rate   float64
+	Sum    float64
+	Min    float64
+	Max    float64
+	Mean   float64
+	Stddev float64
+}
+
+// SampleIterator iterates over every metric sample of metrics.json in capture order.
+type SampleIterator struct {
+	captures []Metric
+	capture  int
+	pending  []Sample
+	current  Sample
+	err      error
+}
+
+// Samples returns an iterator over the metric samples of metrics.json.
+func (b *Bundle) Samples() (*SampleIterator, error) {
+	metrics, err := b.Metrics()
+	if err != nil {
+		return nil, err
+	}
+	return &SampleIterator{captures: metrics.Metrics}, nil
+}
+
+// Next advances to the next sample, returning false when there are no more or on error.
+func (it *SampleIterator) Next() bool {
+	for len(it.pending) == 0 {
+		if it.err != nil || it.capture >= len(it.captures) {
+			return false
+		}
+		it.pending, it.err = captureSamples(it.captures[it.capture])
+		it.capture++
+	}
+	it.current, it.pending = it.pending[0], it.pending[1:]
+	return true
+}
+
+// Sample returns the current sample.
+func (it *SampleIterator) Sample() Sample { return it.current }
+
+// Err returns the first error met while iterating, such as an unparsable capture timestamp.
+func (it *SampleIterator) Err() error { return it.err }
+
+func captureSamples(m Metric) ([]Sample, error) {
+	ts, err := read.ParseMetricTimestamp(m.Timestamp)
+	if err != nil {
+		return nil, err
+	}
+	samples := make([]Sample, 0, len(m.Gauges)+len(m.Points)+len(m.Counters)+len(m.Samples))
+	for _, g := range m.Gauges {
+		samples = append(samples, Sample{Timestamp: ts, Name: g.Name, Type: TypeGauge, Labels: g.Labels, Value: g.Value})
+	}
+	for _, p := range m.Points {
+		samples = append(samples, Sample{Timestamp: ts, Name: p.Name, Type: TypePoints, Labels: p.Labels, Value: p.Points})
+	}
+	for _, c := range m.Counters {
+		samples = append(samples, Sample{Timestamp: ts, Name: c.Name, Type: TypeCounter, Labels: c.Labels, Value: float64(c.Count),
+			Count: c.Count, Rate: c.Rate, Sum: c.Sum, Min: c.Min, Max: c.Max, Mean: c.Mean, Stddev: c.Stddev})
+	}
+	for _, s := range m.Samples {
+		samples = append(samples, Sample{Timestamp: ts, Name: s.Name, Type: TypeSample, Labels: s.Labels, Value: s.Mean,
+			Count: s.Count, Rate: s.Rate, Sum: s.Sum, Min: s.Min, Max: s.Max, Mean: s.Mean, Stddev: s.Stddev})
+	}
+	return samples, nil
+}
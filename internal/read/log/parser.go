@@ -4,6 +4,7 @@ import (
 	"bufio"
 	common "consul-debug-read/internal/read"
 	"fmt"
+	"io"
 	"os"
 	"regexp"
 	"strings"
@@ -100,61 +101,101 @@ func parseTimestamp(timestampStr string) (time.Time, error) {
 
 // ParseLog parses a log file for entries of a specified level and source, then returns a slice of LogEntry.
 func ParseLog(filePath, levelFilter, sourceFilter string, startTime, endTime time.Time) ([]LogEntry, error) {
-	// Default to INFO if no level is specified
-	if levelFilter == "" {
-		levelFilter = InfoLevel
-	}
-
 	file, err := os.Open(filePath)
 	if err != nil {
 		return nil, err
 	}
 	defer file.Close()
-	cleanup := func(err error) error {
-		_ = file.Close()
-		return err
-	}
 
 	var logEntries []LogEntry
-	scanner := bufio.NewScanner(file)
-	// Modify the regex to be prepared for dynamic source filtering, if provided
-	logRegexPattern := fmt.Sprintf(`%s \[(%s)\] ([^\:]+): (.+)`, common.TimeStampRegex, levelFilter)
-	logRegex := regexp.MustCompile(logRegexPattern)
-
+	scanner := NewScanner(file, levelFilter, sourceFilter, startTime, endTime)
 	for scanner.Scan() {
-		line := scanner.Text()
-		if matches := logRegex.FindStringSubmatch(line); matches != nil {
-			var timestamp time.Time
-			timestamp, err = parseTimestamp(matches[1])
-			if err != nil {
-				return []LogEntry{}, err
-			}
-			level := matches[3]
-			source := matches[4]
-			message := matches[5]
+		logEntries = append(logEntries, scanner.Entry())
+	}
+	if err = scanner.Err(); err != nil {
+		return []LogEntry{}, err
+	}
+	return logEntries, nil
+}
 
-			// Time range filtering
-			if !startTime.IsZero() && timestamp.Before(startTime) {
-				continue // Skip entries before the start time
-			}
-			if !endTime.IsZero() && timestamp.After(endTime) {
-				continue // Skip entries after the end time
-			}
+// Scanner streams the entries of a consul log matching a level, source and time range, one at a
+// time, so that logs need not be held in memory.
+type Scanner struct {
+	scanner      *bufio.Scanner
+	logRegex     *regexp.Regexp
+	sourceFilter string
+	startTime    time.Time
+	endTime      time.Time
+	entry        LogEntry
+	err          error
+}
 
-			// Check if the current log's source matches the specified source filter
-			// If sourceFilter is empty, include all sources. Otherwise, filter by the specified source.
-			if sourceFilter == "" || source == sourceFilter {
-				logEntries = append(logEntries, LogEntry{
-					Timestamp: timestamp,
-					Level:     level,
-					Source:    source,
-					Message:   message,
-				})
+// NewScanner returns a Scanner over the log read from r. levelFilter is a level or a |-separated
+// list of levels, defaulting to INFO; an empty sourceFilter or zero time includes every source or
+// time.
+func NewScanner(r io.Reader, levelFilter, sourceFilter string, startTime, endTime time.Time) *Scanner {
+	// Default to INFO if no level is specified
+	if levelFilter == "" {
+		levelFilter = InfoLevel
+	}
+	// Modify the regex to be prepared for dynamic source filtering, if provided; levels are padded
+	// to a common width, so the source follows one or more spaces
+	logRegexPattern := fmt.Sprintf(`%s \[(%s)\] +([^\:]+): (.+)`, common.TimeStampRegex, levelFilter)
+	return &Scanner{
+		scanner:      bufio.NewScanner(r),
+		logRegex:     regexp.MustCompile(logRegexPattern),
+		sourceFilter: sourceFilter,
+		startTime:    startTime,
+		endTime:      endTime,
+	}
+}
+
+// Scan advances to the next matching entry, returning false at the end of the log or on error.
+func (s *Scanner) Scan() bool {
+	if s.err != nil {
+		return false
+	}
+	for s.scanner.Scan() {
+		line := s.scanner.Text()
+		matches := s.logRegex.FindStringSubmatch(line)
+		if matches == nil {
+			continue
+		}
+		timestamp, err := parseTimestamp(matches[1])
+		if err != nil {
+			s.err = err
+			return false
+		}
+		level := matches[3]
+		source := matches[4]
+		message := matches[5]
+
+		// Time range filtering
+		if !s.startTime.IsZero() && timestamp.Before(s.startTime) {
+			continue // Skip entries before the start time
+		}
+		if !s.endTime.IsZero() && timestamp.After(s.endTime) {
+			continue // Skip entries after the end time
+		}
+
+		// Check if the current log's source matches the specified source filter
+		// If sourceFilter is empty, include all sources. Otherwise, filter by the specified source.
+		if s.sourceFilter == "" || source == s.sourceFilter {
+			s.entry = LogEntry{
+				Timestamp: timestamp,
+				Level:     level,
+				Source:    source,
+				Message:   message,
 			}
+			return true
 		}
 	}
-	if err = file.Close(); err != nil {
-		return []LogEntry{}, cleanup(err)
-	}
-	return logEntries, scanner.Err()
+	s.err = s.scanner.Err()
+	return false
 }
+
+// Entry returns the entry found by the last call to Scan.
+func (s *Scanner) Entry() LogEntry { return s.entry }
+
+// Err returns the first error met while scanning.
+func (s *Scanner) Err() error { return s.err }
@@ -55,6 +55,8 @@ type Metric struct {
 type Metrics struct {
 	Metrics    []Metric
 	MetricsMap map[string][]map[string]interface{}
+	// Damaged are the captures of metrics.json skipped because they did not decode.
+	Damaged []DamagedCapture
 }
 
 type Index struct {
@@ -80,7 +82,7 @@ func getUnitAndType(name string, telemetry []AgentTelemetryMetric) (string, stri
 // BuildMetricsIndex
 // Builds metrics map from the ingested metrics.json,
 // extracts metric name, value, labels, and timestamp
-// for retrieval via query, and uploads to boltDB.
+// for retrieval via query.
 func (b *Debug) BuildMetricsIndex() {
 	b.Metrics.MetricsMap = make(map[string][]map[string]interface{})
 
@@ -94,6 +96,7 @@ func (b *Debug) BuildMetricsIndex() {
 				"timestamp": timestamp,
 				"value":     gauge.Value,
 				"labels":    gauge.Labels,
+				"type":      "gauge",
 			}
 			b.Metrics.MetricsMap[gauge.Name] = append(b.Metrics.MetricsMap[gauge.Name], metricData)
 		}
@@ -105,6 +108,7 @@ func (b *Debug) BuildMetricsIndex() {
 				"timestamp": timestamp,
 				"value":     point.Points,
 				"labels":    point.Labels,
+				"type":      "points",
 			}
 			b.Metrics.MetricsMap[point.Name] = append(b.Metrics.MetricsMap[point.Name], metricData)
 		}
@@ -116,6 +120,7 @@ func (b *Debug) BuildMetricsIndex() {
 				"timestamp": timestamp,
 				"value":     counter.Count,
 				"labels":    counter.Labels,
+				"type":      "counter",
 			}
 			b.Metrics.MetricsMap[counter.Name] = append(b.Metrics.MetricsMap[counter.Name], metricData)
 		}
@@ -127,6 +132,7 @@ func (b *Debug) BuildMetricsIndex() {
 				"timestamp": timestamp,
 				"value":     sample.Mean,
 				"labels":    sample.Labels,
+				"type":      "sample",
 			}
 			b.Metrics.MetricsMap[sample.Name] = append(b.Metrics.MetricsMap[sample.Name], metricData)
 		}
@@ -159,31 +165,45 @@ func formatMetricValue(mValue interface{}, unit string) (string, error) {
 }
 
 // GetMetricValues / extracts all timestamped occurrences of metric values by name
+//
+// name may carry label matchers, e.g. consul.rpc.request{type=write,leader=~"true|false"}
 func (b *Debug) GetMetricValues(name string, validate, byValue, short bool) (string, error) {
-	var err error
+	selector, err := ParseMetricSelector(name)
+	if err != nil {
+		return "", err
+	}
 
 	// Get telemetry metrics
-	stringInfo, telemetryInfo, _ := GetTelemetryMetrics()
+	stringInfo, telemetryInfo, err := GetTelemetryMetrics(b.Index.AgentVersion)
+	if err != nil {
+		return "", err
+	}
 	if validate {
-		if ok := validateName(name, stringInfo); !ok {
-			errString := fmt.Sprintf("'%s' not a valid telemetry metric name\n  visit: %s for a full list of consul telemetry metrics", name, TelemetryURL)
+		if ok := validateName(selector.Name, stringInfo); !ok {
+			errString := fmt.Sprintf("'%s' not a valid telemetry metric name for consul %s", selector.Name, b.Index.AgentVersion)
+			documented := make([]string, 0, len(telemetryInfo))
+			for _, m := range telemetryInfo {
+				documented = append(documented, m.Name)
+			}
+			if suggestions := SuggestNames(selector.Name, documented, maxSuggestions); len(suggestions) > 0 {
+				errString += "\n  did you mean: " + strings.Join(suggestions, ", ")
+			}
+			errString += "\n  run: consul-debug-read metrics -list-available-telemetry for a full list of consul telemetry metrics"
 			return "", fmt.Errorf(errString)
 		}
 	}
 
 	// Retrieve metric data and matching metric names
-	metricData, matchedNames, found := b.Metrics.extractMetricValueByName(name)
+	metricData, matchedNames, found := b.Metrics.extractMetricValueByName(selector)
 	if !found {
 		// No metrics found matching the given name
-		result := []string{fmt.Sprintf("*\x1f%s\x1f=>\x1fnil\x1fvalue(s)\x1freturned\x1f", name)}
-		output := columnize.Format(result, &columnize.Config{Delim: string([]byte{0x1f}), Glue: " "})
-		return output, nil
+		return b.Metrics.noValuesReturned(name, selector), nil
 	}
 
 	var result []string
 
 	// Iterate through matched metric names and process data
-	for _, matchedName := range matchedNames {
+	for idx, matchedName := range matchedNames {
 		unit, metricType := getUnitAndType(matchedName, telemetryInfo)
 
 		// Build header for each matched metric name
@@ -196,7 +216,7 @@ func (b *Debug) GetMetricValues(name string, validate, byValue, short bool) (str
 		}
 
 		// Process metric data for the current matched name
-		for _, data := range metricData {
+		for _, data := range metricData[idx : idx+1] {
 			for _, scrape := range data {
 				timestamp := scrape["timestamp"].(string)
 				mValue := scrape["value"]
@@ -207,6 +227,7 @@ func (b *Debug) GetMetricValues(name string, validate, byValue, short bool) (str
 				for k, v := range mLabels {
 					labels = append(labels, fmt.Sprintf("%s=%v", k, v))
 				}
+				sort.Strings(labels)
 
 				// Process metric value
 				var formattedValue string
@@ -280,41 +301,151 @@ func (b *Debug) GetMetricValues(name string, validate, byValue, short bool) (str
 }
 
 // matchMetricsByRegex matches metric names using a given regex and returns the matching data and metric names.
-func matchMetricsByRegex(metricsMap map[string][]map[string]interface{}, pattern string) ([][]map[string]interface{}, []string, bool) {
+// Only scrapes whose labels satisfy the selector's label matchers are returned, and names are sorted for
+// repeatable output.
+func matchMetricsByRegex(metricsMap map[string][]map[string]interface{}, pattern string, selector *MetricSelector) ([][]map[string]interface{}, []string, bool) {
 	regex := regexp.MustCompile(pattern)
 	var matches [][]map[string]interface{}
 	var matchedNames []string
 	found := false
 
-	for name, data := range metricsMap {
+	for name := range metricsMap {
 		if regex.MatchString(name) {
-			matches = append(matches, data)
 			matchedNames = append(matchedNames, name)
-			found = true
 		}
 	}
+	sort.Strings(matchedNames)
+
+	names := matchedNames[:0]
+	for _, name := range matchedNames {
+		var data []map[string]interface{}
+		for _, scrape := range metricsMap[name] {
+			labels, _ := scrape["labels"].(map[string]string)
+			if selector.MatchesLabels(labels) {
+				data = append(data, scrape)
+			}
+		}
+		if len(data) == 0 {
+			continue
+		}
+		matches = append(matches, data)
+		names = append(names, name)
+		found = true
+	}
 
-	return matches, matchedNames, found
+	return matches, names, found
 }
 
 // extractMetricValueByName uses regex to pull the matching metrics data and metric names from the metrics map.
 // It returns a slice of matched data, a slice of matched names, and a boolean indicating if the metric was found.
-func (m Metrics) extractMetricValueByName(metricName string) ([][]map[string]interface{}, []string, bool) {
-	// Replace * with regex wildcard .* if present
-	if strings.Contains(metricName, "*") {
-		pattern := strings.ReplaceAll(regexp.QuoteMeta(metricName), `\*`, ".*")
-		return matchMetricsByRegex(m.MetricsMap, pattern)
+func (m Metrics) extractMetricValueByName(selector *MetricSelector) ([][]map[string]interface{}, []string, bool) {
+	return matchMetricsByRegex(m.MetricsMap, selector.namePattern(), selector)
+}
+
+// noValuesReturned renders the empty result for a query, followed by the closest captured metric
+// names when the queried name itself was never captured.
+func (m Metrics) noValuesReturned(query string, selector *MetricSelector) string {
+	result := []string{fmt.Sprintf("*\x1f%s\x1f=>\x1fnil\x1fvalue(s)\x1freturned\x1f", query)}
+	output := columnize.Format(result, &columnize.Config{Delim: string([]byte{0x1f}), Glue: " "})
+	if _, captured := m.MetricsMap[selector.Name]; captured {
+		// The name exists, only the label matchers excluded every series
+		return output
+	}
+	if suggestions := m.SuggestMetricNames(selector.Name); len(suggestions) > 0 {
+		output += "\n\nDid you mean one of these captured metrics?\n  " + strings.Join(suggestions, "\n  ")
 	}
+	return output
+}
 
-	// No wildcard case
-	return matchMetricsByRegex(m.MetricsMap, `.*`+regexp.QuoteMeta(metricName))
+// GetMetricValuesGroupBy aggregates the values of every series matching the query by the given
+// label keys, producing one row per capture timestamp and distinct label-value group.
+//
+//	$ consul-debug-read metrics -name 'consul.client.rpc' -group-by=method
+func (b *Debug) GetMetricValuesGroupBy(query string, groupBy []string) (string, error) {
+	selector, err := ParseMetricSelector(query)
+	if err != nil {
+		return "", err
+	}
+	series, err := b.Metrics.Select(selector)
+	if err != nil {
+		return "", err
+	}
+	if len(series) == 0 {
+		return b.Metrics.noValuesReturned(query, selector), nil
+	}
+
+	type groupKey struct {
+		name      string
+		timestamp int64
+		labels    string
+	}
+	type groupValue struct {
+		ts     string
+		values []string
+		count  int
+		sum    float64
+		max    float64
+	}
+	groups := make(map[groupKey]*groupValue)
+	var keys []groupKey
+	for _, s := range series {
+		values := make([]string, len(groupBy))
+		for i, label := range groupBy {
+			values[i] = s.Labels[label]
+			if values[i] == "" {
+				values[i] = "-"
+			}
+		}
+		for _, sample := range s.Samples {
+			k := groupKey{name: s.Name, timestamp: sample.Timestamp.Unix(), labels: strings.Join(values, "\x1f")}
+			g, ok := groups[k]
+			if !ok {
+				g = &groupValue{ts: sample.Timestamp.Format(MetricsTimestampLayout), values: values, max: sample.Value}
+				groups[k] = g
+				keys = append(keys, k)
+			}
+			g.count++
+			g.sum += sample.Value
+			if sample.Value > g.max {
+				g.max = sample.Value
+			}
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].name != keys[j].name {
+			return keys[i].name < keys[j].name
+		}
+		if keys[i].timestamp != keys[j].timestamp {
+			return keys[i].timestamp < keys[j].timestamp
+		}
+		return keys[i].labels < keys[j].labels
+	})
+
+	header := "Timestamp\x1fMetric\x1f" + strings.Join(groupBy, "\x1f") + "\x1fSeries\x1fSum\x1fAvg\x1fMax\x1f"
+	result := []string{header}
+	for _, k := range keys {
+		g := groups[k]
+		result = append(result, fmt.Sprintf("%s\x1f%s\x1f%s\x1f%d\x1f%.4f\x1f%.4f\x1f%.4f\x1f",
+			g.ts, k.name, strings.Join(g.values, "\x1f"), g.count, g.sum, g.sum/float64(g.count), g.max))
+	}
+	return columnize.Format(result, &columnize.Config{Delim: string([]byte{0x1f}), Glue: " "}), nil
 }
 
 func (b *Debug) Summary() string {
 	title := "Metrics Bundle Summary"
 	ul := strings.Repeat("-", len(title))
-	captures, _ := b.numberOfCaptures()
-	return fmt.Sprintf("%s\n%s\nDatacenter: %v\nHostname: %s\nAgent Version: %s\nRaft State: %s\nInterval: %s\nDuration: %s\nCapture Targets: %v\nTotal Captures: %d\nCapture Time Start: %s\nCapture Time Stop: %s\n",
+	captures := fmt.Sprint(len(b.Metrics.Metrics))
+	if expected, err := b.numberOfCaptures(); err == nil && expected != len(b.Metrics.Metrics) {
+		captures += fmt.Sprintf(" (%d expected)", expected)
+	}
+	if len(b.Metrics.Damaged) > 0 {
+		captures += fmt.Sprintf(", %d damaged capture(s) skipped", len(b.Metrics.Damaged))
+	}
+	start, stop := "n/a", "n/a"
+	if n := len(b.Metrics.Metrics); n > 0 {
+		start, stop = b.Metrics.Metrics[0].Timestamp, b.Metrics.Metrics[n-1].Timestamp
+	}
+	return fmt.Sprintf("%s\n%s\nDatacenter: %v\nHostname: %s\nAgent Version: %s\nRaft State: %s\nInterval: %s\nDuration: %s\nCapture Targets: %v\nTotal Captures: %s\nCapture Time Start: %s\nCapture Time Stop: %s\n",
 		title,
 		ul,
 		b.Agent.Config.Datacenter,
@@ -325,6 +456,6 @@ func (b *Debug) Summary() string {
 		b.Index.Duration,
 		b.Index.Targets,
 		captures,
-		b.Metrics.Metrics[0].Timestamp,
-		b.Metrics.Metrics[len(b.Metrics.Metrics)-1].Timestamp)
+		start,
+		stop)
 }
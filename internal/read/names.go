@@ -0,0 +1,191 @@
+package read
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	// maxSuggestions is the number of closest metric names offered when a name matches nothing.
+	maxSuggestions = 5
+	// suggestionThreshold is the highest normalized distance still considered a plausible typo.
+	suggestionThreshold = 0.3
+	// suggestionSpread drops suggestions scoring much worse than the best match.
+	suggestionSpread = 0.15
+)
+
+// meshProxyMetricReg matches the mesh proxy metrics, named after the services of each deployment
+// and so never in the telemetry catalog.
+var meshProxyMetricReg = regexp.MustCompile(`^consul\.proxy\..+$`)
+
+// CapturedMetric describes one metric name present in the bundle's metrics.json.
+type CapturedMetric struct {
+	Name       string   `json:"name"`
+	Type       string   `json:"type"`
+	LabelKeys  []string `json:"label_keys"`
+	Samples    int      `json:"samples"`
+	Documented bool     `json:"documented"`
+}
+
+// CapturedMetrics enumerates every captured metric name sorted by name, flagging whether each is
+// documented in the given telemetry catalog entries.
+func (m Metrics) CapturedMetrics(telemetry []AgentTelemetryMetric) []CapturedMetric {
+	documented := make(map[string]bool, len(telemetry))
+	for _, t := range telemetry {
+		documented[t.Name] = true
+	}
+	captured := make([]CapturedMetric, 0, len(m.MetricsMap))
+	for name, scrapes := range m.MetricsMap {
+		entry := CapturedMetric{
+			Name:       name,
+			Samples:    len(scrapes),
+			Documented: documented[name] || meshProxyMetricReg.MatchString(name),
+		}
+		keys := make(map[string]struct{})
+		types := make(map[string]struct{})
+		for _, scrape := range scrapes {
+			if t, ok := scrape["type"].(string); ok {
+				types[t] = struct{}{}
+			}
+			labels, _ := scrape["labels"].(map[string]string)
+			for k := range labels {
+				keys[k] = struct{}{}
+			}
+		}
+		entry.Type = strings.Join(sortedSet(types), "|")
+		entry.LabelKeys = sortedSet(keys)
+		captured = append(captured, entry)
+	}
+	sort.Slice(captured, func(i, j int) bool { return captured[i].Name < captured[j].Name })
+	return captured
+}
+
+// SuggestMetricNames returns the captured metric names closest to name, best match first.
+func (m Metrics) SuggestMetricNames(name string) []string {
+	candidates := make([]string, 0, len(m.MetricsMap))
+	for k := range m.MetricsMap {
+		candidates = append(candidates, k)
+	}
+	return SuggestNames(name, candidates, maxSuggestions)
+}
+
+// SuggestNames ranks candidates by similarity to name and returns up to limit plausible matches.
+//
+// A candidate is scored by its edit distance to name, normalized by length, and is favored when it
+// extends name as a prefix or shares most of name's dot separated segments, so both typos
+// (consul.raft.comitTime) and partial names (raft.commitTime, consul.raft) find their metric.
+func SuggestNames(name string, candidates []string, limit int) []string {
+	query := strings.TrimPrefix(strings.ToLower(strings.Trim(name, "*.^$ ")), "consul.")
+	if query == "" {
+		return nil
+	}
+	querySegments := nameSegments(query)
+
+	type scored struct {
+		name  string
+		score float64
+	}
+	var matches []scored
+	for _, c := range candidates {
+		candidate := strings.TrimPrefix(strings.ToLower(c), "consul.")
+		if candidate == query {
+			continue
+		}
+		longest := len(query)
+		if len(candidate) > longest {
+			longest = len(candidate)
+		}
+		score := float64(levenshtein(query, candidate)) / float64(longest)
+		if strings.HasPrefix(candidate, query) {
+			// Prefer short extensions of the query, e.g. consul.raft.apply => consul.raft.apply.count
+			if prefixScore := 0.1 + 0.2*float64(len(candidate)-len(query))/float64(len(candidate)); prefixScore < score {
+				score = prefixScore
+			}
+		}
+		if len(querySegments) > 0 {
+			shared := sharedSegments(querySegments, nameSegments(candidate))
+			if segmentScore := 1 - float64(shared)/float64(len(querySegments)) + 0.05; segmentScore < score {
+				score = segmentScore
+			}
+		}
+		if score <= suggestionThreshold {
+			matches = append(matches, scored{name: c, score: score})
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].score != matches[j].score {
+			return matches[i].score < matches[j].score
+		}
+		return matches[i].name < matches[j].name
+	})
+	var names []string
+	for i := 0; i < len(matches) && i < limit; i++ {
+		if matches[i].score > matches[0].score+suggestionSpread {
+			break
+		}
+		names = append(names, matches[i].name)
+	}
+	return names
+}
+
+// nameSegments splits a metric name into its dot separated segments, ignoring the consul prefix.
+func nameSegments(name string) []string {
+	var segments []string
+	for _, s := range strings.Split(name, ".") {
+		if s != "" && s != "consul" {
+			segments = append(segments, s)
+		}
+	}
+	return segments
+}
+
+func sharedSegments(query, candidate []string) int {
+	present := make(map[string]bool, len(candidate))
+	for _, s := range candidate {
+		present[s] = true
+	}
+	shared := 0
+	for _, s := range query {
+		if present[s] {
+			shared++
+		}
+	}
+	return shared
+}
+
+// levenshtein returns the edit distance between a and b.
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = prev[j-1] + cost
+			if prev[j]+1 < curr[j] {
+				curr[j] = prev[j] + 1
+			}
+			if curr[j-1]+1 < curr[j] {
+				curr[j] = curr[j-1] + 1
+			}
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+func sortedSet(set map[string]struct{}) []string {
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,31 @@
+package read
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSuggestNames(t *testing.T) {
+	candidates := []string{
+		"consul.raft.apply",
+		"consul.raft.commitTime",
+		"consul.raft.commitNumLogs",
+		"consul.raft.restore",
+		"consul.runtime.sys_bytes",
+		"consul.runtime.heap_objects",
+	}
+	cases := []struct {
+		name string
+		want []string
+	}{
+		{name: "consul.raft.comitTime", want: []string{"consul.raft.commitTime"}},
+		{name: "runtime.sys_byte", want: []string{"consul.runtime.sys_bytes"}},
+		{name: "consul.runtime", want: []string{"consul.runtime.heap_objects", "consul.runtime.sys_bytes"}},
+		{name: "consul.catalog.register", want: nil},
+	}
+	for _, tc := range cases {
+		if got := SuggestNames(tc.name, candidates, maxSuggestions); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("SuggestNames(%q) = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
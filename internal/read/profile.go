@@ -0,0 +1,187 @@
+package read
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const (
+	// ProfileCaptureLayout is the directory name consul debug gives each interval capture.
+	ProfileCaptureLayout = "2006-01-02T15-04-05-0700"
+	goroutineProfileFile = "goroutine.prof"
+)
+
+var (
+	profileCaptureDirReg = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}T\d{2}-\d{2}-\d{2}[-+]\d{4}$`)
+	goroutineTotalReg    = regexp.MustCompile(`^goroutine profile: total (\d+)`)
+)
+
+// ProfileCapture is one per-interval pprof capture directory of a bundle.
+type ProfileCapture struct {
+	Timestamp time.Time
+	Dir       string
+	// Files are the profile files captured in the interval, e.g. goroutine.prof, heap.prof.
+	Files []string
+	// Goroutines is the goroutine count from goroutine.prof, or -1 when unavailable.
+	Goroutines int
+}
+
+// ReadProfileCaptures returns the bundle's interval capture directories in chronological order.
+func ReadProfileCaptures(debugPath string) ([]ProfileCapture, error) {
+	dirs, err := os.ReadDir(debugPath)
+	if err != nil {
+		return nil, err
+	}
+	var captures []ProfileCapture
+	for _, d := range dirs {
+		if !d.IsDir() || !profileCaptureDirReg.MatchString(d.Name()) {
+			continue
+		}
+		ts, err := time.Parse(ProfileCaptureLayout, d.Name())
+		if err != nil {
+			return nil, fmt.Errorf("invalid capture directory %s: %v", d.Name(), err)
+		}
+		capture := ProfileCapture{Timestamp: ts, Dir: filepath.Join(debugPath, d.Name()), Goroutines: -1}
+		files, err := os.ReadDir(capture.Dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range files {
+			if !f.IsDir() {
+				capture.Files = append(capture.Files, f.Name())
+			}
+		}
+		if n, err := CountGoroutines(filepath.Join(capture.Dir, goroutineProfileFile)); err == nil {
+			capture.Goroutines = n
+		}
+		captures = append(captures, capture)
+	}
+	sort.Slice(captures, func(i, j int) bool { return captures[i].Timestamp.Before(captures[j].Timestamp) })
+	return captures, nil
+}
+
+// CountGoroutines returns the number of goroutines recorded in a goroutine profile, either in the
+// text form (debug=1, "goroutine profile: total N") or the default gzipped pprof protobuf form.
+func CountGoroutines(profilePath string) (int, error) {
+	raw, err := os.ReadFile(profilePath)
+	if err != nil {
+		return 0, err
+	}
+	if len(raw) > 2 && raw[0] == 0x1f && raw[1] == 0x8b {
+		zr, err := gzip.NewReader(bytes.NewReader(raw))
+		if err != nil {
+			return 0, err
+		}
+		if raw, err = io.ReadAll(zr); err != nil {
+			return 0, err
+		}
+		return countProfileSamples(raw)
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(raw))
+	if scanner.Scan() {
+		if m := goroutineTotalReg.FindStringSubmatch(scanner.Text()); m != nil {
+			return strconv.Atoi(m[1])
+		}
+	}
+	// An uncompressed protobuf profile
+	return countProfileSamples(raw)
+}
+
+// countProfileSamples sums the first value of every sample in a pprof protobuf profile, which for
+// goroutine profiles is the goroutine count. Only the fields needed are decoded:
+//
+//	Profile.sample = 2 (repeated Sample)
+//	Sample.value   = 2 (repeated int64, packed or not)
+func countProfileSamples(raw []byte) (int, error) {
+	total, samples := 0, 0
+	err := walkProtoFields(raw, func(field int, wireType int, value uint64, data []byte) error {
+		if field != 2 || wireType != 2 {
+			return nil
+		}
+		samples++
+		first := true
+		return walkProtoFields(data, func(field int, wireType int, value uint64, data []byte) error {
+			if field != 2 || !first {
+				return nil
+			}
+			switch wireType {
+			case 0:
+				total += int(value)
+				first = false
+			case 2:
+				v, n := binary.Uvarint(data)
+				if n <= 0 {
+					return errors.New("invalid packed sample value")
+				}
+				total += int(v)
+				first = false
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return 0, fmt.Errorf("invalid pprof profile: %v", err)
+	}
+	if samples == 0 {
+		return 0, errors.New("invalid pprof profile: no samples")
+	}
+	return total, nil
+}
+
+// walkProtoFields calls fn for each top level field of a protobuf message. Varint fields pass
+// their value, length delimited fields their payload; fixed width fields are skipped.
+func walkProtoFields(raw []byte, fn func(field int, wireType int, value uint64, data []byte) error) error {
+	for len(raw) > 0 {
+		key, n := binary.Uvarint(raw)
+		if n <= 0 {
+			return errors.New("truncated field key")
+		}
+		raw = raw[n:]
+		field, wireType := int(key>>3), int(key&7)
+		switch wireType {
+		case 0:
+			v, n := binary.Uvarint(raw)
+			if n <= 0 {
+				return errors.New("truncated varint")
+			}
+			raw = raw[n:]
+			if err := fn(field, wireType, v, nil); err != nil {
+				return err
+			}
+		case 1:
+			if len(raw) < 8 {
+				return errors.New("truncated fixed64")
+			}
+			raw = raw[8:]
+		case 2:
+			l, n := binary.Uvarint(raw)
+			if n <= 0 || uint64(len(raw)-n) < l {
+				return errors.New("truncated length delimited field")
+			}
+			data := raw[n : n+int(l)]
+			raw = raw[n+int(l):]
+			if err := fn(field, wireType, 0, data); err != nil {
+				return err
+			}
+		case 5:
+			if len(raw) < 4 {
+				return errors.New("truncated fixed32")
+			}
+			raw = raw[4:]
+		default:
+			return fmt.Errorf("unsupported wire type %d", wireType)
+		}
+	}
+	return nil
+}
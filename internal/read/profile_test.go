@@ -0,0 +1,39 @@
+package read
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"testing"
+)
+
+func TestCountGoroutines(t *testing.T) {
+	dir := t.TempDir()
+	block := make(chan struct{})
+	defer close(block)
+	for i := 0; i < 10; i++ {
+		go func() { <-block }()
+	}
+
+	for _, debug := range []int{0, 1} {
+		path := filepath.Join(dir, "goroutine.prof")
+		f, err := os.Create(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := runtime.NumGoroutine()
+		if err = pprof.Lookup("goroutine").WriteTo(f, debug); err != nil {
+			t.Fatal(err)
+		}
+		_ = f.Close()
+
+		got, err := CountGoroutines(path)
+		if err != nil {
+			t.Fatalf("debug=%d: %v", debug, err)
+		}
+		if got < 11 || got > want+2 {
+			t.Errorf("debug=%d: CountGoroutines = %d, want about %d", debug, got, want)
+		}
+	}
+}
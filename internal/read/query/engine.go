@@ -0,0 +1,704 @@
+package query
+
+import (
+	"consul-debug-read/internal/read"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MetricNameLabel is the reserved label holding a series' metric name.
+const MetricNameLabel = "__name__"
+
+// DefaultLookback is how far back an instant vector selector looks for the latest sample.
+const DefaultLookback = 5 * time.Minute
+
+// Labels is a series label set, including __name__ for raw selectors.
+type Labels map[string]string
+
+// Signature returns a stable string identifying the label set.
+func (l Labels) Signature() string {
+	return read.FormatLabels(l)
+}
+
+// Point is a single timestamped value.
+type Point struct {
+	T time.Time
+	V float64
+}
+
+// Sample is a Point belonging to a labeled series, as part of an instant Vector.
+type Sample struct {
+	Metric Labels
+	Point
+}
+
+// Vector is the result of evaluating an expression at a single instant.
+type Vector []Sample
+
+// Scalar is a plain numeric result.
+type Scalar float64
+
+// RangeSeries is a labeled series of points produced by a range evaluation.
+type RangeSeries struct {
+	Metric Labels
+	Points []Point
+}
+
+// Matrix is the result of evaluating an expression over a time range.
+type Matrix []RangeSeries
+
+// matrixArg is an evaluated range vector selector, passed to range functions.
+type matrixArg struct {
+	rng    time.Duration
+	series []rangeSeries
+}
+
+type rangeSeries struct {
+	RangeSeries
+	counter bool
+}
+
+type evalSeries struct {
+	labels  Labels
+	counter bool
+	points  []Point
+}
+
+// Engine evaluates PromQL-lite expressions against the metrics index of a bundle.
+//
+// Consul's in-memory sink reports counters and samples as per-interval aggregates rather than
+// monotonic totals, so rate() and increase() over a counter sum the per-interval counts
+// within the window instead of computing a difference between the first and last values.
+type Engine struct {
+	Lookback time.Duration
+
+	metrics read.Metrics
+	// sanitized maps Prometheus style names (consul_raft_apply) to metrics.json names.
+	sanitized map[string]string
+	cache     map[string][]evalSeries
+	start     time.Time
+	end       time.Time
+}
+
+// NewEngine builds an Engine over a decoded metrics index.
+func NewEngine(metrics read.Metrics) *Engine {
+	e := &Engine{
+		Lookback:  DefaultLookback,
+		metrics:   metrics,
+		sanitized: make(map[string]string, len(metrics.MetricsMap)),
+		cache:     make(map[string][]evalSeries),
+	}
+	for name, scrapes := range metrics.MetricsMap {
+		e.sanitized[read.SanitizeMetricName(name)] = name
+		for _, scrape := range scrapes {
+			ts, err := read.ParseMetricTimestamp(fmt.Sprintf("%v", scrape["timestamp"]))
+			if err != nil {
+				continue
+			}
+			if e.start.IsZero() || ts.Before(e.start) {
+				e.start = ts
+			}
+			if ts.After(e.end) {
+				e.end = ts
+			}
+		}
+	}
+	return e
+}
+
+// Bounds returns the first and last capture timestamps of the underlying metrics.
+func (e *Engine) Bounds() (time.Time, time.Time) {
+	return e.start, e.end
+}
+
+// MetricNames returns the sorted metrics.json names available to queries.
+func (e *Engine) MetricNames() []string {
+	names := make([]string, 0, len(e.metrics.MetricsMap))
+	for name := range e.metrics.MetricsMap {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Series returns the label sets of every series matching the selector with at least one
+// sample between start and end (inclusive). Zero start or end times leave that bound open.
+func (e *Engine) Series(vs *VectorSelector, start, end time.Time) ([]Labels, error) {
+	series, err := e.selectSeries(vs)
+	if err != nil {
+		return nil, err
+	}
+	var out []Labels
+	for _, s := range series {
+		for _, p := range s.points {
+			if (start.IsZero() || !p.T.Before(start)) && (end.IsZero() || !p.T.After(end)) {
+				out = append(out, s.labels)
+				break
+			}
+		}
+	}
+	return out, nil
+}
+
+// Instant evaluates the expression at time t, returning a Vector or Scalar.
+func (e *Engine) Instant(input string, t time.Time) (interface{}, error) {
+	expr, err := Parse(input)
+	if err != nil {
+		return nil, err
+	}
+	return e.eval(expr, t)
+}
+
+// Range evaluates the expression at every step between start and end (inclusive).
+func (e *Engine) Range(input string, start, end time.Time, step time.Duration) (Matrix, error) {
+	if step <= 0 {
+		return nil, fmt.Errorf("step must be positive")
+	}
+	if end.Before(start) {
+		return nil, fmt.Errorf("end time must not be before start time")
+	}
+	if end.Sub(start)/step > 11000 {
+		return nil, fmt.Errorf("exceeded maximum resolution of 11,000 points per series; increase the step")
+	}
+	expr, err := Parse(input)
+	if err != nil {
+		return nil, err
+	}
+	series := make(map[string]*RangeSeries)
+	var order []string
+	for t := start; !t.After(end); t = t.Add(step) {
+		v, err := e.eval(expr, t)
+		if err != nil {
+			return nil, err
+		}
+		var vec Vector
+		switch r := v.(type) {
+		case Scalar:
+			vec = Vector{{Metric: Labels{}, Point: Point{T: t, V: float64(r)}}}
+		case Vector:
+			vec = r
+		default:
+			return nil, fmt.Errorf("range queries require a scalar or instant vector expression")
+		}
+		for _, s := range vec {
+			sig := s.Metric.Signature()
+			rs, ok := series[sig]
+			if !ok {
+				rs = &RangeSeries{Metric: s.Metric}
+				series[sig] = rs
+				order = append(order, sig)
+			}
+			rs.Points = append(rs.Points, Point{T: t, V: s.V})
+		}
+	}
+	sort.Strings(order)
+	result := make(Matrix, 0, len(order))
+	for _, sig := range order {
+		result = append(result, *series[sig])
+	}
+	return result, nil
+}
+
+func (e *Engine) eval(expr Expr, t time.Time) (interface{}, error) {
+	switch n := expr.(type) {
+	case *NumberLiteral:
+		return Scalar(n.Value), nil
+	case *ParenExpr:
+		return e.eval(n.Expr, t)
+	case *UnaryExpr:
+		v, err := e.eval(n.Expr, t)
+		if err != nil {
+			return nil, err
+		}
+		switch r := v.(type) {
+		case Scalar:
+			return -r, nil
+		case Vector:
+			out := make(Vector, 0, len(r))
+			for _, s := range r {
+				out = append(out, Sample{Metric: dropName(s.Metric), Point: Point{T: s.T, V: -s.V}})
+			}
+			return out, nil
+		}
+		return nil, fmt.Errorf("unary minus is only supported on scalars and instant vectors")
+	case *VectorSelector:
+		return e.instantVector(n, t)
+	case *MatrixSelector:
+		return e.rangeVector(n, t)
+	case *Call:
+		return e.evalCall(n, t)
+	case *AggregateExpr:
+		return e.evalAggregate(n, t)
+	case *BinaryExpr:
+		return e.evalBinary(n, t)
+	}
+	return nil, fmt.Errorf("unsupported expression %s", expr)
+}
+
+// selectSeries resolves a selector against the index, matching names exactly or by
+// their sanitized Prometheus form, and caches the result for repeated range steps.
+func (e *Engine) selectSeries(vs *VectorSelector) ([]evalSeries, error) {
+	key := vs.String()
+	if cached, ok := e.cache[key]; ok {
+		return cached, nil
+	}
+	var nameMatchers []*read.LabelMatcher
+	sel := &read.MetricSelector{Name: vs.Name}
+	for _, m := range vs.Matchers {
+		if m.Name == MetricNameLabel {
+			nameMatchers = append(nameMatchers, m)
+			continue
+		}
+		sel.Matchers = append(sel.Matchers, m)
+	}
+	target := vs.Name
+	if _, ok := e.metrics.MetricsMap[target]; !ok {
+		if original, ok := e.sanitized[target]; ok {
+			target = original
+		}
+	}
+	matchName := func(name string) bool {
+		if vs.Name != "" && name != target {
+			return false
+		}
+		for _, m := range nameMatchers {
+			if !m.Matches(map[string]string{MetricNameLabel: name}) && !m.Matches(map[string]string{MetricNameLabel: read.SanitizeMetricName(name)}) {
+				return false
+			}
+		}
+		return true
+	}
+	found, err := e.metrics.SelectFunc(matchName, sel)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]evalSeries, 0, len(found))
+	for _, s := range found {
+		labels := Labels{MetricNameLabel: s.Name}
+		for k, v := range s.Labels {
+			labels[k] = v
+		}
+		es := evalSeries{labels: labels, counter: s.Type == "counter"}
+		for _, sample := range s.Samples {
+			es.points = append(es.points, Point{T: sample.Timestamp, V: sample.Value})
+		}
+		sort.Slice(es.points, func(i, j int) bool { return es.points[i].T.Before(es.points[j].T) })
+		result = append(result, es)
+	}
+	e.cache[key] = result
+	return result, nil
+}
+
+func (e *Engine) instantVector(vs *VectorSelector, t time.Time) (Vector, error) {
+	series, err := e.selectSeries(vs)
+	if err != nil {
+		return nil, err
+	}
+	var out Vector
+	for _, s := range series {
+		var latest *Point
+		for i := range s.points {
+			p := s.points[i]
+			if p.T.After(t) {
+				break
+			}
+			if !p.T.Before(t.Add(-e.Lookback)) {
+				latest = &s.points[i]
+			}
+		}
+		if latest != nil {
+			out = append(out, Sample{Metric: s.labels, Point: Point{T: t, V: latest.V}})
+		}
+	}
+	return out, nil
+}
+
+func (e *Engine) rangeVector(ms *MatrixSelector, t time.Time) (matrixArg, error) {
+	series, err := e.selectSeries(ms.Vector)
+	if err != nil {
+		return matrixArg{}, err
+	}
+	from := t.Add(-ms.Range)
+	out := matrixArg{rng: ms.Range}
+	for _, s := range series {
+		rs := rangeSeries{RangeSeries: RangeSeries{Metric: s.labels}, counter: s.counter}
+		for _, p := range s.points {
+			if p.T.After(from) && !p.T.After(t) {
+				rs.Points = append(rs.Points, p)
+			}
+		}
+		if len(rs.Points) > 0 {
+			out.series = append(out.series, rs)
+		}
+	}
+	return out, nil
+}
+
+func dropName(l Labels) Labels {
+	out := make(Labels, len(l))
+	for k, v := range l {
+		if k != MetricNameLabel {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+type function struct {
+	args []string // "matrix", "vector" or "scalar"
+	call func(e *Engine, args []interface{}, t time.Time) (interface{}, error)
+}
+
+var functions map[string]function
+
+func init() {
+	overTime := func(agg func(points []Point) float64) function {
+		return function{args: []string{"matrix"}, call: func(_ *Engine, args []interface{}, t time.Time) (interface{}, error) {
+			var out Vector
+			for _, rs := range args[0].(matrixArg).series {
+				out = append(out, Sample{Metric: dropName(rs.Metric), Point: Point{T: t, V: agg(rs.Points)}})
+			}
+			return out, nil
+		}}
+	}
+	functions = map[string]function{
+		"rate":     {args: []string{"matrix"}, call: callRate(true)},
+		"increase": {args: []string{"matrix"}, call: callRate(false)},
+		"delta": overTime(func(p []Point) float64 {
+			return p[len(p)-1].V - p[0].V
+		}),
+		"avg_over_time": overTime(func(p []Point) float64 {
+			return sumPoints(p) / float64(len(p))
+		}),
+		"sum_over_time":   overTime(sumPoints),
+		"count_over_time": overTime(func(p []Point) float64 { return float64(len(p)) }),
+		"min_over_time": overTime(func(p []Point) float64 {
+			m := p[0].V
+			for _, x := range p {
+				m = math.Min(m, x.V)
+			}
+			return m
+		}),
+		"max_over_time": overTime(func(p []Point) float64 {
+			m := p[0].V
+			for _, x := range p {
+				m = math.Max(m, x.V)
+			}
+			return m
+		}),
+		"last_over_time": overTime(func(p []Point) float64 { return p[len(p)-1].V }),
+		"abs": {args: []string{"vector"}, call: func(_ *Engine, args []interface{}, _ time.Time) (interface{}, error) {
+			var out Vector
+			for _, s := range args[0].(Vector) {
+				out = append(out, Sample{Metric: dropName(s.Metric), Point: Point{T: s.T, V: math.Abs(s.V)}})
+			}
+			return out, nil
+		}},
+		"scalar": {args: []string{"vector"}, call: func(_ *Engine, args []interface{}, _ time.Time) (interface{}, error) {
+			v := args[0].(Vector)
+			if len(v) != 1 {
+				return Scalar(math.NaN()), nil
+			}
+			return Scalar(v[0].V), nil
+		}},
+	}
+}
+
+// callRate implements rate() (perSecond) and increase().
+//
+// Per-interval counters contribute the sum of their counts within the window; any other
+// series is treated as a monotonic counter, summing positive deltas with reset detection.
+func callRate(perSecond bool) func(e *Engine, args []interface{}, t time.Time) (interface{}, error) {
+	return func(_ *Engine, args []interface{}, t time.Time) (interface{}, error) {
+		arg := args[0].(matrixArg)
+		var out Vector
+		for _, rs := range arg.series {
+			var total float64
+			if rs.counter {
+				total = sumPoints(rs.Points)
+			} else {
+				if len(rs.Points) < 2 {
+					continue
+				}
+				for i := 1; i < len(rs.Points); i++ {
+					d := rs.Points[i].V - rs.Points[i-1].V
+					if d < 0 {
+						d = rs.Points[i].V
+					}
+					total += d
+				}
+			}
+			if perSecond {
+				total /= arg.rng.Seconds()
+			}
+			out = append(out, Sample{Metric: dropName(rs.Metric), Point: Point{T: t, V: total}})
+		}
+		return out, nil
+	}
+}
+
+func sumPoints(points []Point) float64 {
+	var sum float64
+	for _, p := range points {
+		sum += p.V
+	}
+	return sum
+}
+
+func checkCall(c *Call) error {
+	fn, ok := functions[c.Func]
+	if !ok {
+		return fmt.Errorf("unknown function %q", c.Func)
+	}
+	if len(c.Args) != len(fn.args) {
+		return fmt.Errorf("%s() expects %d argument(s), got %d", c.Func, len(fn.args), len(c.Args))
+	}
+	for i, kind := range fn.args {
+		_, isMatrix := c.Args[i].(*MatrixSelector)
+		if kind == "matrix" && !isMatrix {
+			return fmt.Errorf("%s() expects a range vector argument, e.g. %s(%s[1m])", c.Func, c.Func, c.Args[i])
+		}
+		if kind != "matrix" && isMatrix {
+			return fmt.Errorf("%s() does not accept a range vector argument", c.Func)
+		}
+	}
+	return nil
+}
+
+func (e *Engine) evalCall(c *Call, t time.Time) (interface{}, error) {
+	fn := functions[c.Func]
+	args := make([]interface{}, 0, len(c.Args))
+	for i, a := range c.Args {
+		v, err := e.eval(a, t)
+		if err != nil {
+			return nil, err
+		}
+		if fn.args[i] == "vector" {
+			if _, ok := v.(Vector); !ok {
+				return nil, fmt.Errorf("%s() expects an instant vector argument", c.Func)
+			}
+		}
+		args = append(args, v)
+	}
+	return fn.call(e, args, t)
+}
+
+func (e *Engine) evalAggregate(a *AggregateExpr, t time.Time) (interface{}, error) {
+	v, err := e.eval(a.Expr, t)
+	if err != nil {
+		return nil, err
+	}
+	vec, ok := v.(Vector)
+	if !ok {
+		return nil, fmt.Errorf("%s() expects an instant vector argument", a.Op)
+	}
+	type group struct {
+		labels Labels
+		values []float64
+	}
+	groups := make(map[string]*group)
+	var order []string
+	for _, s := range vec {
+		labels := Labels{}
+		if a.Without {
+			excluded := map[string]bool{MetricNameLabel: true}
+			for _, l := range a.Grouping {
+				excluded[l] = true
+			}
+			for k, val := range s.Metric {
+				if !excluded[k] {
+					labels[k] = val
+				}
+			}
+		} else {
+			for _, l := range a.Grouping {
+				if val, ok := s.Metric[l]; ok {
+					labels[l] = val
+				}
+			}
+		}
+		sig := labels.Signature()
+		g, ok := groups[sig]
+		if !ok {
+			g = &group{labels: labels}
+			groups[sig] = g
+			order = append(order, sig)
+		}
+		g.values = append(g.values, s.V)
+	}
+	sort.Strings(order)
+	out := make(Vector, 0, len(order))
+	for _, sig := range order {
+		g := groups[sig]
+		var r float64
+		switch a.Op {
+		case "sum", "avg":
+			for _, x := range g.values {
+				r += x
+			}
+			if a.Op == "avg" {
+				r /= float64(len(g.values))
+			}
+		case "min":
+			r = g.values[0]
+			for _, x := range g.values {
+				r = math.Min(r, x)
+			}
+		case "max":
+			r = g.values[0]
+			for _, x := range g.values {
+				r = math.Max(r, x)
+			}
+		case "count":
+			r = float64(len(g.values))
+		}
+		out = append(out, Sample{Metric: g.labels, Point: Point{T: t, V: r}})
+	}
+	return out, nil
+}
+
+func (e *Engine) evalBinary(b *BinaryExpr, t time.Time) (interface{}, error) {
+	lhs, err := e.eval(b.LHS, t)
+	if err != nil {
+		return nil, err
+	}
+	rhs, err := e.eval(b.RHS, t)
+	if err != nil {
+		return nil, err
+	}
+	switch l := lhs.(type) {
+	case Scalar:
+		switch r := rhs.(type) {
+		case Scalar:
+			v, keep := applyOp(b.Op, float64(l), float64(r))
+			if isComparison(b.Op) {
+				if keep {
+					return Scalar(1), nil
+				}
+				return Scalar(0), nil
+			}
+			return Scalar(v), nil
+		case Vector:
+			return vectorScalar(b.Op, r, float64(l), true), nil
+		}
+	case Vector:
+		switch r := rhs.(type) {
+		case Scalar:
+			return vectorScalar(b.Op, l, float64(r), false), nil
+		case Vector:
+			return vectorVector(b.Op, l, r), nil
+		}
+	}
+	return nil, fmt.Errorf("binary %q is only supported between scalars and instant vectors", b.Op)
+}
+
+func isComparison(op string) bool {
+	switch op {
+	case "==", "!=", ">", "<", ">=", "<=":
+		return true
+	}
+	return false
+}
+
+// applyOp returns the arithmetic result of the operation, or for comparisons whether the
+// comparison holds (and the left-hand value).
+func applyOp(op string, l, r float64) (float64, bool) {
+	switch op {
+	case "+":
+		return l + r, true
+	case "-":
+		return l - r, true
+	case "*":
+		return l * r, true
+	case "/":
+		return l / r, true
+	case "%":
+		return math.Mod(l, r), true
+	case "==":
+		return l, l == r
+	case "!=":
+		return l, l != r
+	case ">":
+		return l, l > r
+	case "<":
+		return l, l < r
+	case ">=":
+		return l, l >= r
+	case "<=":
+		return l, l <= r
+	}
+	return math.NaN(), false
+}
+
+func vectorScalar(op string, vec Vector, s float64, scalarLeft bool) Vector {
+	var out Vector
+	for _, sample := range vec {
+		l, r := sample.V, s
+		if scalarLeft {
+			l, r = s, sample.V
+		}
+		v, keep := applyOp(op, l, r)
+		if !keep {
+			continue
+		}
+		metric := dropName(sample.Metric)
+		if isComparison(op) {
+			// comparisons filter, keeping the vector's own value and name
+			v = sample.V
+			metric = sample.Metric
+		}
+		out = append(out, Sample{Metric: metric, Point: Point{T: sample.T, V: v}})
+	}
+	return out
+}
+
+// vectorVector performs one-to-one matching on identical label sets, ignoring __name__.
+func vectorVector(op string, lhs, rhs Vector) Vector {
+	right := make(map[string]Sample, len(rhs))
+	for _, s := range rhs {
+		right[dropName(s.Metric).Signature()] = s
+	}
+	var out Vector
+	for _, l := range lhs {
+		metric := dropName(l.Metric)
+		r, ok := right[metric.Signature()]
+		if !ok {
+			continue
+		}
+		v, keep := applyOp(op, l.V, r.V)
+		if !keep {
+			continue
+		}
+		if isComparison(op) {
+			v = l.V
+			metric = l.Metric
+		}
+		out = append(out, Sample{Metric: metric, Point: Point{T: l.T, V: v}})
+	}
+	return out
+}
+
+// FormatMetric renders a label set as name{k="v",...} for display.
+func FormatMetric(l Labels) string {
+	var parts []string
+	keys := make([]string, 0, len(l))
+	for k := range l {
+		if k != MetricNameLabel {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		parts = append(parts, fmt.Sprintf("%s=%q", k, l[k]))
+	}
+	if len(parts) == 0 {
+		if l[MetricNameLabel] == "" {
+			return "{}"
+		}
+		return l[MetricNameLabel]
+	}
+	return fmt.Sprintf("%s{%s}", l[MetricNameLabel], strings.Join(parts, ","))
+}
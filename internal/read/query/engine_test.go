@@ -0,0 +1,112 @@
+package query
+
+import (
+	"consul-debug-read/internal/read"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testMetrics() read.Metrics {
+	return read.Metrics{MetricsMap: map[string][]map[string]interface{}{
+		"consul.client.rpc": {
+			{"timestamp": "2024-02-07 12:40:00 -0500 EST", "value": 10, "labels": map[string]string{"method": "Catalog.Register"}, "type": "counter"},
+			{"timestamp": "2024-02-07 12:40:00 -0500 EST", "value": 20, "labels": map[string]string{"method": "Health.ServiceNodes"}, "type": "counter"},
+			{"timestamp": "2024-02-07 12:40:10 -0500 EST", "value": 30, "labels": map[string]string{"method": "Catalog.Register"}, "type": "counter"},
+			{"timestamp": "2024-02-07 12:40:10 -0500 EST", "value": 40, "labels": map[string]string{"method": "Health.ServiceNodes"}, "type": "counter"},
+		},
+		"consul.runtime.heap_objects": {
+			{"timestamp": "2024-02-07 12:40:00 -0500 EST", "value": 100.0, "labels": map[string]string{}, "type": "gauge"},
+			{"timestamp": "2024-02-07 12:40:10 -0500 EST", "value": 300.0, "labels": map[string]string{}, "type": "gauge"},
+		},
+	}}
+}
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		input string
+		want  string
+	}{
+		{`consul.raft.apply`, `consul.raft.apply`},
+		{`sum by (method) (rate(consul.client.rpc{method!="Status.Ping"}[1m]))`, `sum by (method) (rate(consul.client.rpc{method!="Status.Ping"}[1m0s]))`},
+		{`sum(consul.client.rpc) without (method)`, `sum without (method) (consul.client.rpc)`},
+		{`consul.mesh.active-root-ca.expiry - 1 * 2`, `consul.mesh.active-root-ca.expiry - 1 * 2`},
+		{`consul.raft.commitTime > 1e-3`, `consul.raft.commitTime > 0.001`},
+		{`increase(consul.raft.apply[1d])`, `increase(consul.raft.apply[24h0m0s])`},
+		{`2.5E+2 - 1e-3-1`, `250 - 0.001 - 1`},
+	}
+	for _, tc := range cases {
+		expr, err := Parse(tc.input)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", tc.input, err)
+		}
+		if got := expr.String(); got != tc.want {
+			t.Errorf("%q: got %s, want %s", tc.input, got, tc.want)
+		}
+	}
+	for _, bad := range []string{`rate(consul.client.rpc)`, `sum(`, `consul.rpc[abc]`, `{}`} {
+		if _, err := Parse(bad); err == nil {
+			t.Errorf("%q: expected error, got none", bad)
+		}
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	cases := map[string]time.Duration{
+		"1m30s": 90 * time.Second,
+		"1.5m":  90 * time.Second,
+		"500ms": 500 * time.Millisecond,
+		"1d":    24 * time.Hour,
+		"2w3d":  17 * 24 * time.Hour,
+		"1y":    365 * 24 * time.Hour,
+		"1h5ms": time.Hour + 5*time.Millisecond,
+	}
+	for input, want := range cases {
+		if got, err := ParseDuration(input); err != nil || got != want {
+			t.Errorf("ParseDuration(%q) = %s, %v, want %s", input, got, err, want)
+		}
+	}
+	for _, bad := range []string{"", "d", "1x", "3d1w", "1d1d"} {
+		if _, err := ParseDuration(bad); err == nil || !strings.Contains(err.Error(), "ms, s, m, h, d, w or y") {
+			t.Errorf("ParseDuration(%q): expected an error naming the units, got %v", bad, err)
+		}
+	}
+}
+
+func TestEngineInstant(t *testing.T) {
+	e := NewEngine(testMetrics())
+	_, end := e.Bounds()
+
+	res, err := e.Instant(`sum(increase(consul_client_rpc[1m]))`, end)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vec, ok := res.(Vector)
+	if !ok || len(vec) != 1 || vec[0].V != 100 {
+		t.Fatalf("sum(increase(...)) = %v, want single sample of 100", res)
+	}
+
+	res, err = e.Instant(`consul.runtime.heap_objects / 100 > 2`, end)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vec = res.(Vector); len(vec) != 1 || vec[0].V != 3 {
+		t.Fatalf("comparison filter = %v, want single sample of 3", vec)
+	}
+
+	if _, err = e.Instant(`consul.runtime.heap_objects`, end.Add(-time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEngineRange(t *testing.T) {
+	e := NewEngine(testMetrics())
+	start, end := e.Bounds()
+	matrix, err := e.Range(`max_over_time(consul.runtime.heap_objects[1m])`, start, end, 10*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matrix) != 1 || len(matrix[0].Points) != 2 || matrix[0].Points[1].V != 300 {
+		t.Fatalf("unexpected range result: %+v", matrix)
+	}
+}
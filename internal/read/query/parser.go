@@ -0,0 +1,473 @@
+package query
+
+import (
+	"consul-debug-read/internal/read"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Expr is a node of a parsed query expression.
+type Expr interface {
+	String() string
+}
+
+// NumberLiteral is a scalar constant, e.g. 1000.
+type NumberLiteral struct {
+	Value float64
+}
+
+// VectorSelector selects every series of a metric name matching the label matchers.
+type VectorSelector struct {
+	Name     string
+	Matchers []*read.LabelMatcher
+}
+
+// MatrixSelector is a VectorSelector with a range, e.g. consul.client.rpc[1m].
+type MatrixSelector struct {
+	Vector *VectorSelector
+	Range  time.Duration
+}
+
+// Call is a function call, e.g. rate(consul.client.rpc[1m]).
+type Call struct {
+	Func string
+	Args []Expr
+}
+
+// AggregateExpr is an aggregation across series, e.g. sum by (method) (...).
+type AggregateExpr struct {
+	Op       string
+	Expr     Expr
+	Grouping []string
+	Without  bool
+}
+
+// BinaryExpr is an arithmetic or comparison operation between two expressions.
+type BinaryExpr struct {
+	Op  string
+	LHS Expr
+	RHS Expr
+}
+
+// UnaryExpr is a negated expression.
+type UnaryExpr struct {
+	Expr Expr
+}
+
+// ParenExpr is a parenthesized expression.
+type ParenExpr struct {
+	Expr Expr
+}
+
+func (n *NumberLiteral) String() string { return strconv.FormatFloat(n.Value, 'g', -1, 64) }
+
+func (v *VectorSelector) String() string {
+	if len(v.Matchers) == 0 {
+		return v.Name
+	}
+	parts := make([]string, 0, len(v.Matchers))
+	for _, m := range v.Matchers {
+		parts = append(parts, m.String())
+	}
+	return fmt.Sprintf("%s{%s}", v.Name, strings.Join(parts, ","))
+}
+
+func (m *MatrixSelector) String() string { return fmt.Sprintf("%s[%s]", m.Vector, m.Range) }
+
+func (c *Call) String() string {
+	args := make([]string, 0, len(c.Args))
+	for _, a := range c.Args {
+		args = append(args, a.String())
+	}
+	return fmt.Sprintf("%s(%s)", c.Func, strings.Join(args, ", "))
+}
+
+func (a *AggregateExpr) String() string {
+	if len(a.Grouping) == 0 && !a.Without {
+		return fmt.Sprintf("%s(%s)", a.Op, a.Expr)
+	}
+	kw := "by"
+	if a.Without {
+		kw = "without"
+	}
+	return fmt.Sprintf("%s %s (%s) (%s)", a.Op, kw, strings.Join(a.Grouping, ", "), a.Expr)
+}
+
+func (b *BinaryExpr) String() string { return fmt.Sprintf("%s %s %s", b.LHS, b.Op, b.RHS) }
+func (u *UnaryExpr) String() string  { return fmt.Sprintf("-%s", u.Expr) }
+func (p *ParenExpr) String() string  { return fmt.Sprintf("(%s)", p.Expr) }
+
+var (
+	aggregations = map[string]bool{"sum": true, "avg": true, "min": true, "max": true, "count": true}
+
+	// precedence of binary operators, higher binds tighter.
+	precedence = map[string]int{
+		"==": 1, "!=": 1, ">": 1, "<": 1, ">=": 1, "<=": 1,
+		"+": 2, "-": 2,
+		"*": 3, "/": 3, "%": 3,
+	}
+)
+
+type parser struct {
+	input string
+	pos   int
+}
+
+// Parse parses a PromQL-lite expression.
+//
+// Metric names may contain '.', ':' and '-' (e.g. consul.mesh.active-root-ca.expiry), so the
+// subtraction operator must be surrounded by whitespace when its left operand is a metric name.
+func Parse(input string) (Expr, error) {
+	p := &parser{input: input}
+	expr, err := p.parseExpr(0)
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.pos < len(p.input) {
+		return nil, p.errorf("unexpected %q", p.input[p.pos:])
+	}
+	return expr, nil
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("parse error at char %d: %s", p.pos+1, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) skipSpace() {
+	for p.pos < len(p.input) && strings.ContainsRune(" \t\r\n", rune(p.input[p.pos])) {
+		p.pos++
+	}
+}
+
+func (p *parser) peekOperator() string {
+	p.skipSpace()
+	rest := p.input[p.pos:]
+	for _, op := range []string{"==", "!=", ">=", "<=", ">", "<", "+", "-", "*", "/", "%"} {
+		if strings.HasPrefix(rest, op) {
+			return op
+		}
+	}
+	return ""
+}
+
+func (p *parser) parseExpr(minPrec int) (Expr, error) {
+	lhs, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op := p.peekOperator()
+		prec, ok := precedence[op]
+		if !ok || prec <= minPrec {
+			return lhs, nil
+		}
+		p.pos += len(op)
+		rhs, err := p.parseExpr(prec)
+		if err != nil {
+			return nil, err
+		}
+		lhs = &BinaryExpr{Op: op, LHS: lhs, RHS: rhs}
+	}
+}
+
+func (p *parser) parseUnary() (Expr, error) {
+	p.skipSpace()
+	if p.pos < len(p.input) && p.input[p.pos] == '-' {
+		p.pos++
+		expr, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &UnaryExpr{Expr: expr}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (Expr, error) {
+	p.skipSpace()
+	if p.pos >= len(p.input) {
+		return nil, p.errorf("unexpected end of input")
+	}
+	c := p.input[p.pos]
+	switch {
+	case c == '(':
+		p.pos++
+		expr, err := p.parseExpr(0)
+		if err != nil {
+			return nil, err
+		}
+		if err = p.expect(')'); err != nil {
+			return nil, err
+		}
+		return &ParenExpr{Expr: expr}, nil
+	case (c >= '0' && c <= '9') || c == '.':
+		return p.parseNumber()
+	case c == '{':
+		return p.parseSelector("")
+	case isIdentStart(c):
+		ident := p.parseIdent()
+		p.skipSpace()
+		if aggregations[ident] {
+			return p.parseAggregate(ident)
+		}
+		if _, ok := functions[ident]; ok && p.pos < len(p.input) && p.input[p.pos] == '(' {
+			return p.parseCall(ident)
+		}
+		return p.parseSelector(ident)
+	}
+	return nil, p.errorf("unexpected character %q", c)
+}
+
+func (p *parser) expect(c byte) error {
+	p.skipSpace()
+	if p.pos >= len(p.input) || p.input[p.pos] != c {
+		return p.errorf("expected %q", c)
+	}
+	p.pos++
+	return nil
+}
+
+func (p *parser) parseNumber() (Expr, error) {
+	start := p.pos
+	for p.pos < len(p.input) && strings.ContainsRune("0123456789.eE", rune(p.input[p.pos])) {
+		p.pos++
+		// An exponent may be signed, as in 1e-3.
+		if c := p.input[p.pos-1]; (c == 'e' || c == 'E') && p.pos < len(p.input) && (p.input[p.pos] == '+' || p.input[p.pos] == '-') {
+			p.pos++
+		}
+	}
+	v, err := strconv.ParseFloat(p.input[start:p.pos], 64)
+	if err != nil {
+		return nil, p.errorf("invalid number %q", p.input[start:p.pos])
+	}
+	return &NumberLiteral{Value: v}, nil
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentChar(c byte) bool {
+	return isIdentStart(c) || (c >= '0' && c <= '9') || c == '.' || c == ':'
+}
+
+func (p *parser) parseIdent() string {
+	start := p.pos
+	for p.pos < len(p.input) {
+		c := p.input[p.pos]
+		if isIdentChar(c) {
+			p.pos++
+			continue
+		}
+		// allow hyphenated metric name segments (active-root-ca) but not "a - b"
+		if c == '-' && p.pos+1 < len(p.input) && isIdentStart(p.input[p.pos+1]) {
+			p.pos++
+			continue
+		}
+		break
+	}
+	return p.input[start:p.pos]
+}
+
+// parseSelector parses an optional {matchers} block and [range] after a metric name.
+func (p *parser) parseSelector(name string) (Expr, error) {
+	vs := &VectorSelector{Name: name}
+	p.skipSpace()
+	if p.pos < len(p.input) && p.input[p.pos] == '{' {
+		end, err := p.matchingBrace()
+		if err != nil {
+			return nil, err
+		}
+		block := p.input[p.pos : end+1]
+		p.pos = end + 1
+		sel, err := read.ParseMetricSelector(name + block)
+		if err != nil {
+			return nil, err
+		}
+		vs.Matchers = sel.Matchers
+	}
+	if vs.Name == "" && len(vs.Matchers) == 0 {
+		return nil, p.errorf("vector selector must contain a metric name or label matcher")
+	}
+	p.skipSpace()
+	if p.pos < len(p.input) && p.input[p.pos] == '[' {
+		end := strings.IndexByte(p.input[p.pos:], ']')
+		if end == -1 {
+			return nil, p.errorf("unterminated range selector")
+		}
+		raw := p.input[p.pos+1 : p.pos+end]
+		p.pos += end + 1
+		d, err := ParseDuration(strings.TrimSpace(raw))
+		if err != nil {
+			return nil, p.errorf("invalid range %q: %v", raw, err)
+		}
+		if d <= 0 {
+			return nil, p.errorf("invalid range %q", raw)
+		}
+		return &MatrixSelector{Vector: vs, Range: d}, nil
+	}
+	return vs, nil
+}
+
+// promDurationUnits are the units of Prometheus durations, in the descending order they must be
+// given in.
+var promDurationUnits = []struct {
+	unit string
+	d    time.Duration
+}{
+	{"y", 365 * 24 * time.Hour},
+	{"w", 7 * 24 * time.Hour},
+	{"d", 24 * time.Hour},
+	{"h", time.Hour},
+	{"m", time.Minute},
+	{"s", time.Second},
+	{"ms", time.Millisecond},
+}
+
+// ParseDuration parses a range or step duration: a Go duration such as 1m30s, or a Prometheus
+// duration such as 1d or 2w3d, whose units also include d, w and y.
+func ParseDuration(s string) (time.Duration, error) {
+	if d, err := time.ParseDuration(s); err == nil {
+		return d, nil
+	}
+	invalid := fmt.Errorf("invalid duration %q, expected a number followed by one of the units ms, s, m, h, d, w or y", s)
+	if s == "" {
+		return 0, invalid
+	}
+	var total time.Duration
+	next := 0
+	for rest := s; rest != ""; {
+		digits := 0
+		for digits < len(rest) && rest[digits] >= '0' && rest[digits] <= '9' {
+			digits++
+		}
+		n, err := strconv.ParseInt(rest[:digits], 10, 64)
+		if err != nil {
+			return 0, invalid
+		}
+		rest = rest[digits:]
+		unit := -1
+		for i := next; i < len(promDurationUnits); i++ {
+			u := promDurationUnits[i].unit
+			// ms must not be read as m followed by a stray s.
+			if strings.HasPrefix(rest, u) && !(u == "m" && strings.HasPrefix(rest, "ms")) {
+				unit = i
+				break
+			}
+		}
+		if unit < 0 {
+			return 0, invalid
+		}
+		total += time.Duration(n) * promDurationUnits[unit].d
+		rest = rest[len(promDurationUnits[unit].unit):]
+		next = unit + 1
+	}
+	return total, nil
+}
+
+// matchingBrace returns the index of the '}' closing the '{' at the current position,
+// ignoring braces within quoted label values.
+func (p *parser) matchingBrace() (int, error) {
+	inQuote := false
+	for i := p.pos + 1; i < len(p.input); i++ {
+		switch c := p.input[i]; {
+		case c == '\\' && inQuote:
+			i++
+		case c == '"':
+			inQuote = !inQuote
+		case c == '}' && !inQuote:
+			return i, nil
+		}
+	}
+	return -1, p.errorf("unterminated label matcher block")
+}
+
+func (p *parser) parseCall(name string) (Expr, error) {
+	p.pos++ // (
+	call := &Call{Func: name}
+	p.skipSpace()
+	if p.pos < len(p.input) && p.input[p.pos] == ')' {
+		p.pos++
+	} else {
+		for {
+			arg, err := p.parseExpr(0)
+			if err != nil {
+				return nil, err
+			}
+			call.Args = append(call.Args, arg)
+			p.skipSpace()
+			if p.pos < len(p.input) && p.input[p.pos] == ',' {
+				p.pos++
+				continue
+			}
+			if err = p.expect(')'); err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+	if err := checkCall(call); err != nil {
+		return nil, err
+	}
+	return call, nil
+}
+
+// parseAggregate parses both `sum by (a) (expr)` and `sum(expr) by (a)` forms.
+func (p *parser) parseAggregate(op string) (Expr, error) {
+	agg := &AggregateExpr{Op: op}
+	var err error
+	if agg.Grouping, agg.Without, err = p.parseGrouping(); err != nil {
+		return nil, err
+	}
+	if err = p.expect('('); err != nil {
+		return nil, err
+	}
+	if agg.Expr, err = p.parseExpr(0); err != nil {
+		return nil, err
+	}
+	if err = p.expect(')'); err != nil {
+		return nil, err
+	}
+	if agg.Grouping == nil && !agg.Without {
+		if agg.Grouping, agg.Without, err = p.parseGrouping(); err != nil {
+			return nil, err
+		}
+	}
+	return agg, nil
+}
+
+func (p *parser) parseGrouping() ([]string, bool, error) {
+	p.skipSpace()
+	save := p.pos
+	if p.pos >= len(p.input) || !isIdentStart(p.input[p.pos]) {
+		return nil, false, nil
+	}
+	kw := p.parseIdent()
+	if kw != "by" && kw != "without" {
+		p.pos = save
+		return nil, false, nil
+	}
+	if err := p.expect('('); err != nil {
+		return nil, false, err
+	}
+	labels := []string{}
+	for {
+		p.skipSpace()
+		if p.pos < len(p.input) && p.input[p.pos] == ')' {
+			p.pos++
+			return labels, kw == "without", nil
+		}
+		label := p.parseIdent()
+		if label == "" {
+			return nil, false, p.errorf("expected label name in %s clause", kw)
+		}
+		labels = append(labels, label)
+		p.skipSpace()
+		if p.pos < len(p.input) && p.input[p.pos] == ',' {
+			p.pos++
+		}
+	}
+}
@@ -0,0 +1,314 @@
+package read
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v2"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+const bundleRegistryFile = "bundles.yaml"
+
+// BundleRegistryPath is the registry of named bundles managed by the 'bundle' commands.
+var BundleRegistryPath = filepath.Join(DebugReadConfigDirPath, bundleRegistryFile)
+
+// ticketPrefixReg matches the support ticket number conventionally prefixed to bundle archive
+// names, e.g. 124722consul-debug-2023-10-04T18-29-47Z.tar.gz.
+var ticketPrefixReg = regexp.MustCompile(`^(\d{4,})[-_]?`)
+
+// RegisteredBundle is a named, extracted bundle of the registry.
+type RegisteredBundle struct {
+	Name string `yaml:"name" json:"name"`
+	// Path is the extracted bundle directory.
+	Path string `yaml:"path" json:"path"`
+	// Archive is the .tar.gz the bundle was extracted from, if any.
+	Archive    string `yaml:"archive,omitempty" json:"archive,omitempty"`
+	Ticket     string `yaml:"ticket,omitempty" json:"ticket,omitempty"`
+	Datacenter string `yaml:"datacenter" json:"datacenter"`
+	Node       string `yaml:"node" json:"node"`
+	Version    string `yaml:"version" json:"version"`
+	// CaptureTime is when the capture started, see BundleCaptureTime.
+	CaptureTime time.Time `yaml:"captureTime" json:"capture_time"`
+	Tags        []string  `yaml:"tags,omitempty" json:"tags,omitempty"`
+	AddedAt     time.Time `yaml:"addedAt" json:"added_at"`
+}
+
+// BundleRegistry is the set of registered bundles along with the one in use by default.
+type BundleRegistry struct {
+	Default string             `yaml:"default,omitempty"`
+	Bundles []RegisteredBundle `yaml:"bundles"`
+}
+
+// LoadBundleRegistry reads the registry, returning an empty registry when none was saved yet.
+func LoadBundleRegistry() (*BundleRegistry, error) {
+	var r BundleRegistry
+	raw, err := os.ReadFile(BundleRegistryPath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return &r, nil
+		}
+		return nil, fmt.Errorf("failed to read bundle registry %s: %v", BundleRegistryPath, err)
+	}
+	if err = yaml.Unmarshal(raw, &r); err != nil {
+		return nil, fmt.Errorf("failed to parse bundle registry %s: %v", BundleRegistryPath, err)
+	}
+	return &r, nil
+}
+
+// Save writes the registry with its bundles sorted by name.
+func (r *BundleRegistry) Save() error {
+	sort.Slice(r.Bundles, func(i, j int) bool { return r.Bundles[i].Name < r.Bundles[j].Name })
+	raw, err := yaml.Marshal(r)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(BundleRegistryPath), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(BundleRegistryPath, raw, 0644)
+}
+
+// Get returns the registered bundle with the given name.
+func (r *BundleRegistry) Get(name string) (*RegisteredBundle, bool) {
+	for i := range r.Bundles {
+		if r.Bundles[i].Name == name {
+			return &r.Bundles[i], true
+		}
+	}
+	return nil, false
+}
+
+// Lookup returns the registered bundle with the given name, suggesting similarly named bundles
+// when there is none.
+func (r *BundleRegistry) Lookup(name string) (*RegisteredBundle, error) {
+	if b, ok := r.Get(name); ok {
+		return b, nil
+	}
+	if len(r.Bundles) == 0 {
+		return nil, fmt.Errorf("no bundle named %q: no bundles are registered, see 'consul-debug-read bundle add'", name)
+	}
+	names := make([]string, 0, len(r.Bundles))
+	for _, b := range r.Bundles {
+		names = append(names, b.Name)
+	}
+	msg := fmt.Sprintf("no bundle named %q", name)
+	if suggestions := SuggestNames(name, names, maxSuggestions); len(suggestions) > 0 {
+		msg += ", did you mean: " + strings.Join(suggestions, ", ")
+	}
+	return nil, errors.New(msg)
+}
+
+// FindPath returns the registered bundle extracted at path.
+func (r *BundleRegistry) FindPath(path string) (*RegisteredBundle, bool) {
+	for i := range r.Bundles {
+		if r.Bundles[i].Path == path {
+			return &r.Bundles[i], true
+		}
+	}
+	return nil, false
+}
+
+// Register adds the extracted bundle at path to the registry, reading its node, datacenter,
+// version and capture time from the bundle. The name defaults to <ticket>-<node>-<capture time>,
+// where the ticket defaults to the number prefixed to the archive name, if any. Registering an
+// already registered path returns the existing entry.
+func (r *BundleRegistry) Register(path, archive, name, ticket string) (*RegisteredBundle, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	if existing, ok := r.FindPath(path); ok {
+		return existing, nil
+	}
+	if archive != "" {
+		if archive, err = filepath.Abs(archive); err != nil {
+			return nil, err
+		}
+	}
+
+	var b Debug
+	for _, dataType := range []string{"index", "agent"} {
+		if err = b.DecodeJSON(path, dataType); err != nil {
+			return nil, err
+		}
+	}
+	captured, err := BundleCaptureTime(path, archive)
+	if err != nil {
+		return nil, err
+	}
+	if ticket == "" {
+		source := filepath.Base(path)
+		if archive != "" {
+			source = filepath.Base(archive)
+		}
+		if m := ticketPrefixReg.FindStringSubmatch(source); m != nil {
+			ticket = m[1]
+		}
+	}
+	entry := RegisteredBundle{
+		Path:        path,
+		Archive:     archive,
+		Ticket:      ticket,
+		Datacenter:  b.Agent.Config.Datacenter,
+		Node:        b.Agent.Config.NodeName,
+		Version:     b.Index.AgentVersion,
+		CaptureTime: captured.UTC(),
+		AddedAt:     time.Now().UTC(),
+	}
+
+	if name == "" {
+		parts := []string{entry.Node, entry.CaptureTime.Format("20060102T150405Z")}
+		if ticket != "" {
+			parts = append([]string{ticket}, parts...)
+		}
+		name = strings.Join(parts, "-")
+		for i := 2; ; i++ {
+			if _, taken := r.Get(name); !taken {
+				break
+			}
+			name = fmt.Sprintf("%s-%d", strings.Join(parts, "-"), i)
+		}
+	} else if _, taken := r.Get(name); taken {
+		return nil, fmt.Errorf("a bundle named %q is already registered", name)
+	}
+	entry.Name = name
+	r.Bundles = append(r.Bundles, entry)
+	return &r.Bundles[len(r.Bundles)-1], nil
+}
+
+// BundleCaptureTime returns when the capture of the bundle extracted to dir started: the time of
+// its first metrics.json capture, else the capture time in the name of dir or of its archive,
+// else the modification time of index.json, which extraction may have reset.
+func BundleCaptureTime(dir, archive string) (time.Time, error) {
+	if t, ok := firstCaptureTime(filepath.Join(dir, "metrics.json")); ok {
+		return t, nil
+	}
+	for _, name := range []string{filepath.Base(dir), filepath.Base(archive)} {
+		if t, ok := nameCaptureTime(name); ok {
+			return t, nil
+		}
+	}
+	info, err := os.Stat(filepath.Join(dir, "index.json"))
+	if err != nil {
+		return time.Time{}, err
+	}
+	return info.ModTime(), nil
+}
+
+// firstCaptureTime returns the Timestamp of the first capture of metrics.json, reading no further
+// than that field.
+func firstCaptureTime(metricsFile string) (time.Time, bool) {
+	f, err := os.Open(metricsFile)
+	if err != nil {
+		return time.Time{}, false
+	}
+	defer f.Close()
+	dec := json.NewDecoder(bufio.NewReader(f))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return time.Time{}, false
+	}
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return time.Time{}, false
+		}
+		if key != "Timestamp" {
+			var skip json.RawMessage
+			if err = dec.Decode(&skip); err != nil {
+				return time.Time{}, false
+			}
+			continue
+		}
+		var ts string
+		if err = dec.Decode(&ts); err != nil {
+			return time.Time{}, false
+		}
+		t, err := ParseMetricTimestamp(ts)
+		return t, err == nil
+	}
+	return time.Time{}, false
+}
+
+// Remove unregisters the named bundle, clearing the default when it was the bundle in use.
+func (r *BundleRegistry) Remove(name string) (RegisteredBundle, error) {
+	for i, b := range r.Bundles {
+		if b.Name == name {
+			r.Bundles = append(r.Bundles[:i], r.Bundles[i+1:]...)
+			if r.Default == name {
+				r.Default = ""
+			}
+			return b, nil
+		}
+	}
+	_, err := r.Lookup(name)
+	return RegisteredBundle{}, err
+}
+
+// Stale returns the registered bundles whose extracted directory no longer exists.
+func (r *BundleRegistry) Stale() []RegisteredBundle {
+	var stale []RegisteredBundle
+	for _, b := range r.Bundles {
+		if _, err := os.Stat(filepath.Join(b.Path, "index.json")); err != nil {
+			stale = append(stale, b)
+		}
+	}
+	return stale
+}
+
+// AddTags adds tags to the bundle, ignoring those it already has.
+func (b *RegisteredBundle) AddTags(tags ...string) {
+	for _, tag := range tags {
+		if !b.HasTag(tag) {
+			b.Tags = append(b.Tags, tag)
+		}
+	}
+	sort.Strings(b.Tags)
+}
+
+// RemoveTags removes tags from the bundle.
+func (b *RegisteredBundle) RemoveTags(tags ...string) {
+	remove := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		remove[tag] = true
+	}
+	kept := b.Tags[:0]
+	for _, tag := range b.Tags {
+		if !remove[tag] {
+			kept = append(kept, tag)
+		}
+	}
+	b.Tags = kept
+}
+
+// HasTag reports whether the bundle is tagged with tag.
+func (b *RegisteredBundle) HasTag(tag string) bool {
+	for _, t := range b.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// ResolveBundleArg returns path when it exists, otherwise the path of the registered bundle named path.
+func ResolveBundleArg(path string) (string, error) {
+	if _, err := os.Stat(path); err == nil {
+		return path, nil
+	}
+	r, err := LoadBundleRegistry()
+	if err != nil {
+		return "", err
+	}
+	b, err := r.Lookup(path)
+	if err != nil {
+		return "", fmt.Errorf("%s is neither a bundle path nor a registered bundle: %v", path, err)
+	}
+	return b.Path, nil
+}
@@ -0,0 +1,123 @@
+package read
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeTestBundle(t *testing.T, dir, node string, captured time.Time) {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"index.json": `{"Version":2,"AgentVersion":"1.17.2","Interval":"30s","Duration":"2m0s","Targets":["agent"]}`,
+		"agent.json": `{"Config":{"Datacenter":"dc1","NodeName":"` + node + `","Server":true}}`,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, captured, captured); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestBundleRegistry(t *testing.T) {
+	tmp := t.TempDir()
+	defer func(path string) { BundleRegistryPath = path }(BundleRegistryPath)
+	BundleRegistryPath = filepath.Join(tmp, "bundles.yaml")
+
+	captured := time.Date(2023, 10, 4, 18, 29, 47, 0, time.UTC)
+	first := filepath.Join(tmp, "consul-debug-2023-10-04T18-29-47Z")
+	// Without a capture time in its name, the second bundle falls back to the index.json mtime.
+	second := filepath.Join(tmp, "consul-debug-copy")
+	writeTestBundle(t, first, "server-1", captured)
+	writeTestBundle(t, second, "server-1", captured)
+
+	r, err := LoadBundleRegistry()
+	if err != nil || len(r.Bundles) != 0 {
+		t.Fatalf("expected an empty registry, got %v, %v", r, err)
+	}
+	b, err := r.Register(first, filepath.Join(tmp, "124722consul-debug-2023-10-04T18-29-47Z.tar.gz"), "", "")
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if b.Name != "124722-server-1-20231004T182947Z" || b.Ticket != "124722" || b.Datacenter != "dc1" || !b.CaptureTime.Equal(captured) {
+		t.Errorf("unexpected registered bundle %+v", b)
+	}
+	if again, _ := r.Register(first, "", "other", ""); again.Name != b.Name {
+		t.Errorf("expected re-registering a path to return %s, got %s", b.Name, again.Name)
+	}
+	if _, err = r.Register(second, "", "", "124722"); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if _, ok := r.Get("124722-server-1-20231004T182947Z-2"); !ok {
+		t.Errorf("expected a suffixed name for a colliding bundle, got %+v", r.Bundles)
+	}
+	if _, err = r.Register(second, "", "124722-server-1-20231004T182947Z", ""); err != nil {
+		t.Errorf("expected the registered path to be returned, got %v", err)
+	}
+
+	r.Bundles[0].AddTags("outage", "leader", "outage")
+	r.Default = r.Bundles[0].Name
+	if err = r.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	r, err = LoadBundleRegistry()
+	if err != nil {
+		t.Fatalf("LoadBundleRegistry: %v", err)
+	}
+	if got := strings.Join(r.Bundles[0].Tags, ","); got != "leader,outage" {
+		t.Errorf("expected tags leader,outage, got %s", got)
+	}
+
+	if _, err = r.Lookup("124722-server-1-20231004T182974Z"); err == nil || !strings.Contains(err.Error(), "did you mean") {
+		t.Errorf("expected a suggestion for a mistyped name, got %v", err)
+	}
+
+	if err = os.RemoveAll(second); err != nil {
+		t.Fatal(err)
+	}
+	stale := r.Stale()
+	if len(stale) != 1 || stale[0].Path != second {
+		t.Errorf("expected %s to be stale, got %+v", second, stale)
+	}
+	if _, err = r.Remove(r.Default); err != nil || r.Default != "" {
+		t.Errorf("expected removing the default bundle to clear it, got %v, %q", err, r.Default)
+	}
+}
+
+func TestBundleCaptureTime(t *testing.T) {
+	tmp := t.TempDir()
+	modified := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	named := time.Date(2024, 2, 7, 17, 39, 50, 0, time.UTC)
+	dir := filepath.Join(tmp, "consul-debug-2024-02-07T17-39-50Z")
+	writeTestBundle(t, dir, "server-1", modified)
+
+	if got, err := BundleCaptureTime(dir, ""); err != nil || !got.Equal(named) {
+		t.Errorf("expected the capture time in the directory name, got %s, %v", got, err)
+	}
+	metrics := `{"Counters":[],"Timestamp":"2024-02-07 12:40:00 -0500 EST","Gauges":[]}` + "\n" +
+		`{"Timestamp":"2024-02-07 12:40:10 -0500 EST"}`
+	if err := os.WriteFile(filepath.Join(dir, "metrics.json"), []byte(metrics), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := BundleCaptureTime(dir, ""); err != nil || !got.Equal(named.Add(10*time.Second)) {
+		t.Errorf("expected the time of the first metrics capture, got %s, %v", got, err)
+	}
+
+	renamed := filepath.Join(tmp, "extracted")
+	writeTestBundle(t, renamed, "server-1", modified)
+	if got, err := BundleCaptureTime(renamed, filepath.Join(tmp, "consul-debug-2024-02-07T17-39-50Z.tar.gz")); err != nil || !got.Equal(named) {
+		t.Errorf("expected the capture time in the archive name, got %s, %v", got, err)
+	}
+	if got, err := BundleCaptureTime(renamed, ""); err != nil || !got.Equal(modified) {
+		t.Errorf("expected the index.json modification time, got %s, %v", got, err)
+	}
+}
@@ -0,0 +1,330 @@
+package read
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MatchType is the comparison operator used by a LabelMatcher.
+type MatchType int
+
+const (
+	MatchEqual MatchType = iota
+	MatchNotEqual
+	MatchRegexp
+	MatchNotRegexp
+)
+
+func (t MatchType) String() string {
+	switch t {
+	case MatchEqual:
+		return "="
+	case MatchNotEqual:
+		return "!="
+	case MatchRegexp:
+		return "=~"
+	case MatchNotRegexp:
+		return "!~"
+	}
+	return "?"
+}
+
+// LabelMatcher matches a single metric label against a value or an anchored regular expression.
+type LabelMatcher struct {
+	Name  string
+	Type  MatchType
+	Value string
+	re    *regexp.Regexp
+}
+
+// NewLabelMatcher builds a LabelMatcher, compiling the regex for =~ and !~ matchers.
+func NewLabelMatcher(t MatchType, name, value string) (*LabelMatcher, error) {
+	m := &LabelMatcher{Name: name, Type: t, Value: value}
+	if t == MatchRegexp || t == MatchNotRegexp {
+		re, err := regexp.Compile("^(?:" + value + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex for label %q: %v", name, err)
+		}
+		m.re = re
+	}
+	return m, nil
+}
+
+// Matches reports whether the given label set satisfies the matcher.
+// A missing label is treated as an empty string value.
+func (m *LabelMatcher) Matches(labels map[string]string) bool {
+	v := labels[m.Name]
+	switch m.Type {
+	case MatchEqual:
+		return v == m.Value
+	case MatchNotEqual:
+		return v != m.Value
+	case MatchRegexp:
+		return m.re.MatchString(v)
+	case MatchNotRegexp:
+		return !m.re.MatchString(v)
+	}
+	return false
+}
+
+func (m *LabelMatcher) String() string {
+	return fmt.Sprintf("%s%s%q", m.Name, m.Type, m.Value)
+}
+
+// MetricSelector is a parsed metric query of the form:
+//
+//	consul.rpc.request{type="write",leader=~"true|false",method!="Status.Ping"}
+//
+// The name may contain '*' wildcards and may be omitted entirely ({type=write})
+// to select across all metric names.
+type MetricSelector struct {
+	Name     string
+	Matchers []*LabelMatcher
+}
+
+// ParseMetricSelector parses a metric name with optional label matchers.
+func ParseMetricSelector(query string) (*MetricSelector, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, fmt.Errorf("empty metric selector")
+	}
+	sel := &MetricSelector{}
+	open := strings.Index(query, "{")
+	if open == -1 {
+		sel.Name = query
+		return sel, nil
+	}
+	if !strings.HasSuffix(query, "}") {
+		return nil, fmt.Errorf("unterminated label matcher block in %q", query)
+	}
+	sel.Name = strings.TrimSpace(query[:open])
+	body := query[open+1 : len(query)-1]
+	matchers, err := parseLabelMatchers(body)
+	if err != nil {
+		return nil, fmt.Errorf("invalid selector %q: %v", query, err)
+	}
+	sel.Matchers = matchers
+	return sel, nil
+}
+
+// parseLabelMatchers parses the comma separated body of a {...} matcher block.
+// Values may be bare words or double-quoted strings (which may contain commas).
+func parseLabelMatchers(body string) ([]*LabelMatcher, error) {
+	var matchers []*LabelMatcher
+	i := 0
+	for {
+		for i < len(body) && (body[i] == ' ' || body[i] == ',') {
+			i++
+		}
+		if i >= len(body) {
+			return matchers, nil
+		}
+		start := i
+		for i < len(body) && isLabelNameChar(body[i]) {
+			i++
+		}
+		name := body[start:i]
+		if name == "" {
+			return nil, fmt.Errorf("expected label name at offset %d", start)
+		}
+		for i < len(body) && body[i] == ' ' {
+			i++
+		}
+		var op MatchType
+		switch {
+		case strings.HasPrefix(body[i:], "=~"):
+			op, i = MatchRegexp, i+2
+		case strings.HasPrefix(body[i:], "!~"):
+			op, i = MatchNotRegexp, i+2
+		case strings.HasPrefix(body[i:], "!="):
+			op, i = MatchNotEqual, i+2
+		case strings.HasPrefix(body[i:], "="):
+			op, i = MatchEqual, i+1
+		default:
+			return nil, fmt.Errorf("expected one of =, !=, =~, !~ after label %q", name)
+		}
+		for i < len(body) && body[i] == ' ' {
+			i++
+		}
+		var value string
+		if i < len(body) && body[i] == '"' {
+			var b strings.Builder
+			i++
+			closed := false
+			for i < len(body) {
+				c := body[i]
+				if c == '\\' && i+1 < len(body) {
+					b.WriteByte(body[i+1])
+					i += 2
+					continue
+				}
+				if c == '"' {
+					closed = true
+					i++
+					break
+				}
+				b.WriteByte(c)
+				i++
+			}
+			if !closed {
+				return nil, fmt.Errorf("unterminated quoted value for label %q", name)
+			}
+			value = b.String()
+		} else {
+			start = i
+			for i < len(body) && body[i] != ',' {
+				i++
+			}
+			value = strings.TrimSpace(body[start:i])
+		}
+		m, err := NewLabelMatcher(op, name, value)
+		if err != nil {
+			return nil, err
+		}
+		matchers = append(matchers, m)
+	}
+}
+
+func isLabelNameChar(c byte) bool {
+	return c == '_' || c == '-' || c == '.' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// MatchesLabels reports whether all label matchers of the selector match.
+func (s *MetricSelector) MatchesLabels(labels map[string]string) bool {
+	for _, m := range s.Matchers {
+		if !m.Matches(labels) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *MetricSelector) String() string {
+	if len(s.Matchers) == 0 {
+		return s.Name
+	}
+	parts := make([]string, 0, len(s.Matchers))
+	for _, m := range s.Matchers {
+		parts = append(parts, m.String())
+	}
+	return fmt.Sprintf("%s{%s}", s.Name, strings.Join(parts, ","))
+}
+
+// namePattern converts the selector name into the regex used for metric name lookups.
+//   - empty name   => all metrics
+//   - wildcard '*' => glob style match
+//   - plain name   => unanchored match (matches prior `metrics -name` behavior)
+func (s *MetricSelector) namePattern() string {
+	switch {
+	case s.Name == "":
+		return `.*`
+	case strings.Contains(s.Name, "*"):
+		return strings.ReplaceAll(regexp.QuoteMeta(s.Name), `\*`, ".*")
+	default:
+		return `.*` + regexp.QuoteMeta(s.Name)
+	}
+}
+
+// SeriesSample is a single timestamped value of a Series.
+type SeriesSample struct {
+	Timestamp time.Time
+	Value     float64
+}
+
+// Series is every captured value of a metric name for one distinct label set.
+type Series struct {
+	Name    string
+	Type    string
+	Labels  map[string]string
+	Samples []SeriesSample
+}
+
+// LabelString returns the series labels in sorted k=v form.
+func (s Series) LabelString() string {
+	return FormatLabels(s.Labels)
+}
+
+// FormatLabels renders a label set as a sorted, comma separated list of k=v pairs.
+func FormatLabels(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, fmt.Sprintf("%s=%s", k, labels[k]))
+	}
+	return strings.Join(parts, ",")
+}
+
+// Select returns one Series per (metric name, label set) matching the selector,
+// sorted by name and labels, with samples in capture order.
+func (m Metrics) Select(sel *MetricSelector) ([]Series, error) {
+	re, err := regexp.Compile(sel.namePattern())
+	if err != nil {
+		return nil, err
+	}
+	return m.collectSeries(re.MatchString, sel)
+}
+
+// SelectFunc is like Select, but metric names are chosen by the given predicate
+// instead of the selector's name pattern. Only the selector's label matchers are applied.
+func (m Metrics) SelectFunc(matchName func(name string) bool, sel *MetricSelector) ([]Series, error) {
+	return m.collectSeries(matchName, sel)
+}
+
+func (m Metrics) collectSeries(matchName func(name string) bool, sel *MetricSelector) ([]Series, error) {
+	index := make(map[string]*Series)
+	var keys []string
+	for name, scrapes := range m.MetricsMap {
+		if !matchName(name) {
+			continue
+		}
+		for _, scrape := range scrapes {
+			labels, _ := scrape["labels"].(map[string]string)
+			if !sel.MatchesLabels(labels) {
+				continue
+			}
+			key := name + "{" + FormatLabels(labels) + "}"
+			series, ok := index[key]
+			if !ok {
+				metricType, _ := scrape["type"].(string)
+				series = &Series{Name: name, Type: metricType, Labels: labels}
+				index[key] = series
+				keys = append(keys, key)
+			}
+			ts, err := ParseMetricTimestamp(fmt.Sprintf("%v", scrape["timestamp"]))
+			if err != nil {
+				return nil, err
+			}
+			series.Samples = append(series.Samples, SeriesSample{Timestamp: ts, Value: toFloat(scrape["value"])})
+		}
+	}
+	sort.Strings(keys)
+	result := make([]Series, 0, len(keys))
+	for _, k := range keys {
+		result = append(result, *index[k])
+	}
+	return result, nil
+}
+
+// ParseMetricTimestamp parses the timestamp format used by metrics.json captures.
+func ParseMetricTimestamp(ts string) (time.Time, error) {
+	return time.Parse(MetricsTimestampLayout, ts)
+}
+
+func toFloat(v interface{}) float64 {
+	switch value := v.(type) {
+	case float64:
+		return value
+	case int:
+		return float64(value)
+	case int64:
+		return float64(value)
+	}
+	return 0
+}
@@ -0,0 +1,81 @@
+package read
+
+import (
+	"testing"
+)
+
+func TestParseMetricSelector(t *testing.T) {
+	cases := []struct {
+		query    string
+		name     string
+		matchers []string
+		wantErr  bool
+	}{
+		{query: "consul.raft.apply", name: "consul.raft.apply"},
+		{query: "consul.rpc.request{type=write,leader=true}", name: "consul.rpc.request", matchers: []string{`type="write"`, `leader="true"`}},
+		{query: `consul.http.*{method=~"GET|PUT", path!="v1/agent/self"}`, name: "consul.http.*", matchers: []string{`method=~"GET|PUT"`, `path!="v1/agent/self"`}},
+		{query: `{datacenter!~"dc[23]"}`, name: "", matchers: []string{`datacenter!~"dc[23]"`}},
+		{query: "consul.rpc.request{type=write", wantErr: true},
+		{query: "consul.rpc.request{type}", wantErr: true},
+		{query: `consul.rpc.request{type=~"("}`, wantErr: true},
+	}
+	for _, tc := range cases {
+		sel, err := ParseMetricSelector(tc.query)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("%q: expected error, got none", tc.query)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", tc.query, err)
+		}
+		if sel.Name != tc.name {
+			t.Errorf("%q: name = %q, want %q", tc.query, sel.Name, tc.name)
+		}
+		if len(sel.Matchers) != len(tc.matchers) {
+			t.Fatalf("%q: got %d matchers, want %d", tc.query, len(sel.Matchers), len(tc.matchers))
+		}
+		for i, m := range sel.Matchers {
+			if m.String() != tc.matchers[i] {
+				t.Errorf("%q: matcher[%d] = %s, want %s", tc.query, i, m, tc.matchers[i])
+			}
+		}
+	}
+}
+
+func TestMetricsSelect(t *testing.T) {
+	m := Metrics{MetricsMap: map[string][]map[string]interface{}{
+		"consul.rpc.request": {
+			{"timestamp": "2024-02-07 12:40:00 -0500 EST", "value": 5, "labels": map[string]string{"type": "read"}, "type": "counter"},
+			{"timestamp": "2024-02-07 12:40:00 -0500 EST", "value": 7, "labels": map[string]string{"type": "write"}, "type": "counter"},
+			{"timestamp": "2024-02-07 12:40:10 -0500 EST", "value": 9, "labels": map[string]string{"type": "write"}, "type": "counter"},
+		},
+		"consul.raft.apply": {
+			{"timestamp": "2024-02-07 12:40:00 -0500 EST", "value": 1.5, "labels": map[string]string{}, "type": "sample"},
+		},
+	}}
+
+	sel, err := ParseMetricSelector("consul.rpc.request{type=write}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	series, err := m.Select(sel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(series) != 1 {
+		t.Fatalf("got %d series, want 1", len(series))
+	}
+	if got := len(series[0].Samples); got != 2 {
+		t.Fatalf("got %d samples, want 2", got)
+	}
+	if series[0].Samples[1].Value != 9 || series[0].Type != "counter" {
+		t.Errorf("unexpected series: %+v", series[0])
+	}
+
+	sel, _ = ParseMetricSelector("consul.*")
+	if series, _ = m.Select(sel); len(series) != 3 {
+		t.Errorf("wildcard select: got %d series, want 3", len(series))
+	}
+}
@@ -1,96 +1,247 @@
 package read
 
 import (
+	"bufio"
+	_ "embed"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"github.com/PuerkitoBio/goquery"
+	"github.com/hashicorp/go-hclog"
 	"github.com/ryanuber/columnize"
-	"log"
-	"net/http"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 )
 
 type AgentTelemetryMetric struct {
-	Name string
-	Unit string
-	Type string
+	Name        string `json:"name"`
+	Unit        string `json:"unit"`
+	Type        string `json:"type"`
+	Description string `json:"description,omitempty"`
+	// Since is the first Consul version documenting the metric; empty means it predates the catalog.
+	Since string `json:"since,omitempty"`
+	// Removed is the first Consul version no longer documenting the metric.
+	Removed string `json:"removed,omitempty"`
 }
 
-//type ConsulTelemetryEndpoints struct {
-//	KeyMetricNames
-//}
+// TelemetryCatalog is the set of documented Consul agent telemetry metrics across Consul versions.
+type TelemetryCatalog struct {
+	// DocsVersion is the Consul version of the telemetry docs the catalog was last refreshed from.
+	DocsVersion string                 `json:"docs_version"`
+	Metrics     []AgentTelemetryMetric `json:"metrics"`
+}
 
-// TODO: Make the URL Agent Version adaptable (i.e., alter URL string to corresponding version)
 const (
 	TelemetryURL            = "https://developer.hashicorp.com/consul/docs/agent/telemetry"
+	TelemetryDocsPath       = "website/content/docs/agent/telemetry.mdx"
+	telemetryCatalogFile    = "telemetry.json"
 	telegrafMetricsFilePath = "metrics/telegraf"
 )
 
-func GetTelemetryMetrics() (string, []AgentTelemetryMetric, error) {
-	// Define a data structure to store metric endpoints.
-	telemetryMetrics := []string{"Metric\x1fUnit\x1fType"}
+//go:embed telemetry/catalog.json
+var embeddedTelemetryCatalog []byte
 
-	// Send an HTTP GET request to the Consul telemetry metrics reference page.
-	response, err := http.Get(TelemetryURL)
+// TelemetryCatalogPath is the user override written by 'telemetry update', preferred over the embedded catalog.
+var TelemetryCatalogPath = filepath.Join(DebugReadConfigDirPath, telemetryCatalogFile)
+
+// LoadTelemetryCatalog returns the user's refreshed catalog when present, otherwise the catalog
+// embedded at build time.
+func LoadTelemetryCatalog() (TelemetryCatalog, error) {
+	var catalog TelemetryCatalog
+	raw, err := os.ReadFile(TelemetryCatalogPath)
 	if err != nil {
-		return "", []AgentTelemetryMetric{}, err
-	}
-	defer response.Body.Close()
-	cleanup := func(err error) error {
-		_ = response.Body.Close()
-		return err
+		if !errors.Is(err, os.ErrNotExist) {
+			return catalog, fmt.Errorf("failed to read telemetry catalog %s: %v", TelemetryCatalogPath, err)
+		}
+		raw = embeddedTelemetryCatalog
 	}
-	// Parse the HTML content.
-	doc, err := goquery.NewDocumentFromReader(response.Body)
-	if err != nil {
-		return "", []AgentTelemetryMetric{}, err
+	if err = json.Unmarshal(raw, &catalog); err != nil {
+		return catalog, fmt.Errorf("failed to decode telemetry catalog: %v", err)
 	}
+	return catalog, nil
+}
 
-	// Extract metric endpoints from the HTML.
-	doc.Find("table tbody tr").Each(func(index int, rowHtml *goquery.Selection) {
-		// Parse and extract metric endpoint.
-		endpoint := rowHtml.Find("td:nth-child(1)").Text()
-		metricUnit := rowHtml.Find("td:nth-child(3)").Text()
-		metricType := rowHtml.Find("td:nth-child(4)").Text()
-		if strings.HasPrefix(endpoint, "consul") {
-			telemetryMetrics = append(telemetryMetrics, fmt.Sprintf("%s\x1f%s\x1f%s\x1f",
-				endpoint, metricUnit, metricType))
-		}
-	})
-	var telemetryInfo []AgentTelemetryMetric
-	for i, line := range telemetryMetrics {
-		infoSections := strings.Split(line, string([]byte{0x1f}))
-		if len(infoSections) < 3 || i == 0 {
+// ForVersion returns the metrics documented for the given Consul agent version. An empty or
+// unparseable version returns every metric not yet removed.
+func (t TelemetryCatalog) ForVersion(agentVersion string) []AgentTelemetryMetric {
+	version, ok := parseConsulVersion(agentVersion)
+	var metrics []AgentTelemetryMetric
+	for _, m := range t.Metrics {
+		if !ok {
+			if m.Removed == "" {
+				metrics = append(metrics, m)
+			}
 			continue
 		}
-		info := AgentTelemetryMetric{
-			Name: infoSections[0],
-			Unit: infoSections[1],
-			Type: infoSections[2],
+		if inVersionRange(version, m.Since, m.Removed) {
+			metrics = append(metrics, m)
 		}
-		telemetryInfo = append(telemetryInfo, info)
 	}
-	if err := response.Body.Close(); err != nil {
-		return "", []AgentTelemetryMetric{}, cleanup(err)
+	return metrics
+}
+
+// GetTelemetryMetrics returns a columnized table and the list of telemetry metrics documented for
+// the given Consul agent version.
+func GetTelemetryMetrics(agentVersion string) (string, []AgentTelemetryMetric, error) {
+	catalog, err := LoadTelemetryCatalog()
+	if err != nil {
+		return "", []AgentTelemetryMetric{}, err
+	}
+	telemetryInfo := catalog.ForVersion(agentVersion)
+
+	telemetryMetrics := []string{"Metric\x1fUnit\x1fType"}
+	for _, m := range telemetryInfo {
+		telemetryMetrics = append(telemetryMetrics, fmt.Sprintf("%s\x1f%s\x1f%s\x1f", m.Name, m.Unit, m.Type))
 	}
 	// Build output string in columnized format for readability
 	output := columnize.Format(telemetryMetrics, &columnize.Config{Delim: string([]byte{0x1f}), Glue: " "})
 	return output, telemetryInfo, nil
 }
 
-func ListMetrics() (string, error) {
+func ListMetrics(agentVersion string) (string, error) {
 	var latestMetrics string
 	var err error
-	if latestMetrics, _, err = GetTelemetryMetrics(); err != nil {
+	if latestMetrics, _, err = GetTelemetryMetrics(agentVersion); err != nil {
 		return "", err
 	}
-	fmt.Printf("\nConsul Telemetry Metric Names (pulled from: %s)\n\n", TelemetryURL)
+	if agentVersion == "" {
+		agentVersion = "all versions"
+	}
+	fmt.Printf("\nConsul Telemetry Metric Names (Consul %s, see: %s)\n\n", agentVersion, TelemetryURL)
 	return latestMetrics, nil
 }
 
+// ParseTelemetryDocs extracts the metric rows of the markdown tables in the Consul telemetry docs
+// (website/content/docs/agent/telemetry.mdx), whose columns are Metric | Description | Unit | Type.
+func ParseTelemetryDocs(r io.Reader) ([]AgentTelemetryMetric, error) {
+	var metrics []AgentTelemetryMetric
+	seen := make(map[string]bool)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "|") {
+			continue
+		}
+		cells := strings.Split(strings.Trim(line, "|"), "|")
+		if len(cells) < 4 {
+			continue
+		}
+		for i := range cells {
+			cells[i] = strings.TrimSpace(cells[i])
+		}
+		name := strings.Trim(cells[0], "` ")
+		if !strings.HasPrefix(name, "consul.") || seen[name] {
+			continue
+		}
+		seen[name] = true
+		metrics = append(metrics, AgentTelemetryMetric{
+			Name:        name,
+			Description: cells[1],
+			Unit:        cells[2],
+			Type:        cells[3],
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(metrics) == 0 {
+		return nil, fmt.Errorf("no telemetry metric tables found")
+	}
+	return metrics, nil
+}
+
+// Merge refreshes the catalog with the metrics documented for the given Consul version. New
+// metrics are recorded as introduced in that version and metrics no longer documented are marked
+// as removed in it. Docs older than the catalog's DocsVersion would mark newer metrics as removed,
+// so they are refused unless force is set.
+func (t *TelemetryCatalog) Merge(docs []AgentTelemetryMetric, version string, force bool) error {
+	v, ok := parseConsulVersion(version)
+	if !ok {
+		return fmt.Errorf("invalid consul version %q", version)
+	}
+	if current, ok := parseConsulVersion(t.DocsVersion); ok && compareConsulVersions(v, current) < 0 && !force {
+		return fmt.Errorf("docs of consul %s are older than the catalog's consul %s", version, t.DocsVersion)
+	}
+	documented := make(map[string]AgentTelemetryMetric, len(docs))
+	for _, m := range docs {
+		documented[m.Name] = m
+	}
+	existing := make(map[string]bool, len(t.Metrics))
+	for i, m := range t.Metrics {
+		existing[m.Name] = true
+		doc, ok := documented[m.Name]
+		if !ok {
+			if m.Removed == "" {
+				t.Metrics[i].Removed = version
+			}
+			continue
+		}
+		t.Metrics[i].Unit, t.Metrics[i].Type, t.Metrics[i].Description = doc.Unit, doc.Type, doc.Description
+		t.Metrics[i].Removed = ""
+	}
+	for _, m := range docs {
+		if !existing[m.Name] {
+			m.Since = version
+			t.Metrics = append(t.Metrics, m)
+		}
+	}
+	sort.Slice(t.Metrics, func(i, j int) bool { return t.Metrics[i].Name < t.Metrics[j].Name })
+	t.DocsVersion = version
+	return nil
+}
+
+// parseConsulVersion parses the major.minor.patch prefix of a Consul version such as 1.16.1+ent or v1.17.0-rc1.
+func parseConsulVersion(v string) ([3]int, bool) {
+	var version [3]int
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+	if i := strings.IndexAny(v, "+- "); i >= 0 {
+		v = v[:i]
+	}
+	parts := strings.Split(v, ".")
+	if v == "" || len(parts) > 3 {
+		return version, false
+	}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return version, false
+		}
+		version[i] = n
+	}
+	return version, true
+}
+
+// inVersionRange reports whether version is at or after since and before removed, either of
+// which may be empty.
+func inVersionRange(version [3]int, since, removed string) bool {
+	if s, ok := parseConsulVersion(since); ok && compareConsulVersions(version, s) < 0 {
+		return false
+	}
+	if r, ok := parseConsulVersion(removed); ok && compareConsulVersions(version, r) >= 0 {
+		return false
+	}
+	return true
+}
+
+func compareConsulVersions(a, b [3]int) int {
+	for i := range a {
+		if a[i] != b[i] {
+			if a[i] < b[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
 func (b *Debug) GenerateTelegrafMetrics() error {
 	metrics := b.Metrics.Metrics
-	log.Printf("converting metrics timestamps to RFC3339")
+	hclog.L().Debug("converting metrics timestamps to RFC3339")
 	for i := range metrics {
 		telegrafMetrics := metrics[i]
 		ts := metrics[i].Timestamp
@@ -0,0 +1,63 @@
+package read
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTelemetryCatalog(t *testing.T) {
+	docs := "## Metrics Reference\n\n" +
+		"| Metric | Description | Unit | Type |\n" +
+		"| ------ | ----------- | ---- | ---- |\n" +
+		"| `consul.raft.commitTime` | Measures the time it takes to commit. | ms | timer |\n" +
+		"| `consul.server.isLeader` | Track if a server is a leader. | boolean | gauge |\n" +
+		"| Not a metric | - | - | - |\n"
+	documented, err := ParseTelemetryDocs(strings.NewReader(docs))
+	if err != nil {
+		t.Fatalf("ParseTelemetryDocs: %v", err)
+	}
+	if len(documented) != 2 || documented[0].Name != "consul.raft.commitTime" || documented[1].Unit != "boolean" {
+		t.Fatalf("unexpected parsed metrics: %+v", documented)
+	}
+
+	catalog := TelemetryCatalog{Metrics: []AgentTelemetryMetric{
+		{Name: "consul.raft.commitTime", Unit: "ms", Type: "timer"},
+		{Name: "consul.raft.legacy", Unit: "ms", Type: "timer"},
+	}}
+	if err = catalog.Merge(documented, "1.15.0", false); err != nil {
+		t.Fatalf("Merge: %v", err)
+	}
+
+	names := func(metrics []AgentTelemetryMetric) string {
+		var n []string
+		for _, m := range metrics {
+			n = append(n, m.Name)
+		}
+		return strings.Join(n, ",")
+	}
+	cases := map[string]string{
+		"1.14.3":     "consul.raft.commitTime,consul.raft.legacy",
+		"1.15.0":     "consul.raft.commitTime,consul.server.isLeader",
+		"1.16.1+ent": "consul.raft.commitTime,consul.server.isLeader",
+		"":           "consul.raft.commitTime,consul.server.isLeader",
+	}
+	for version, want := range cases {
+		if got := names(catalog.ForVersion(version)); got != want {
+			t.Errorf("ForVersion(%q) = %s, want %s", version, got, want)
+		}
+	}
+
+	older := []AgentTelemetryMetric{{Name: "consul.raft.legacy", Unit: "ms", Type: "timer"}}
+	if err = catalog.Merge(older, "1.14.3", false); err == nil {
+		t.Errorf("expected docs older than the catalog to be refused")
+	}
+	if catalog.DocsVersion != "1.15.0" || names(catalog.ForVersion("1.15.0")) != "consul.raft.commitTime,consul.server.isLeader" {
+		t.Errorf("expected a refused merge to leave the catalog unchanged, got %+v", catalog)
+	}
+	if err = catalog.Merge(documented, "latest", false); err == nil {
+		t.Errorf("expected an invalid version to be refused")
+	}
+	if err = catalog.Merge(older, "1.14.3", true); err != nil || catalog.DocsVersion != "1.14.3" {
+		t.Errorf("expected a forced merge of older docs, got %v with docs version %s", err, catalog.DocsVersion)
+	}
+}
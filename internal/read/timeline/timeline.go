@@ -0,0 +1,190 @@
+package timeline
+
+import (
+	"consul-debug-read/internal/read"
+	"consul-debug-read/internal/read/log"
+	"consul-debug-read/pkg/redact"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Event sources, in the order events sharing a timestamp are listed.
+const (
+	SourceLog     = "log"
+	SourceMetric  = "metric"
+	SourceProfile = "profile"
+)
+
+// profileChangeRatio is the goroutine count change between captures worth calling out.
+const profileChangeRatio = 0.25
+
+// Event is a single entry of the correlated timeline.
+type Event struct {
+	Time    time.Time `json:"time"`
+	Source  string    `json:"source"`
+	Kind    string    `json:"kind"`
+	Summary string    `json:"summary"`
+}
+
+// Options bounds the timeline window and the signals merged into it.
+type Options struct {
+	Since time.Time
+	Until time.Time
+	// LogFile is the bundle's consul.log; empty skips log events.
+	LogFile string
+	// Redactor redacts secrets from log messages; nil keeps them as captured.
+	Redactor *redact.Redactor
+	// Profiles are the bundle's interval captures; nil skips profile events.
+	Profiles []read.ProfileCapture
+	// TopAnomalies limits the metric anomalies placed on the timeline (0 includes all).
+	TopAnomalies int
+}
+
+// Build merges log events, metric anomalies and profile captures falling within the window into
+// one chronologically ordered timeline. Anomalies are detected from the captures inside the window
+// only, so baselines and the TopAnomalies ranking ignore metrics captured outside it.
+func Build(metrics read.Metrics, opts Options) ([]Event, error) {
+	var events []Event
+
+	if opts.LogFile != "" {
+		logEvents, err := logEvents(opts)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, logEvents...)
+	}
+
+	metricEvents, err := metricEvents(metrics, opts)
+	if err != nil {
+		return nil, err
+	}
+	events = append(events, metricEvents...)
+	events = append(events, profileEvents(opts)...)
+
+	order := map[string]int{SourceLog: 0, SourceMetric: 1, SourceProfile: 2}
+	sort.SliceStable(events, func(i, j int) bool {
+		if !events[i].Time.Equal(events[j].Time) {
+			return events[i].Time.Before(events[j].Time)
+		}
+		return order[events[i].Source] < order[events[j].Source]
+	})
+	return events, nil
+}
+
+func logEvents(opts Options) ([]Event, error) {
+	entries, err := log.ParseLog(opts.LogFile, strings.Join([]string{log.InfoLevel, log.WarnLevel, log.ErrorLevel}, "|"), "", opts.Since, opts.Until)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", opts.LogFile, err)
+	}
+	var events []Event
+	for _, e := range entries {
+		kind := logKind(e)
+		if kind == "" {
+			continue
+		}
+		events = append(events, Event{
+			Time:    e.Timestamp,
+			Source:  SourceLog,
+			Kind:    kind,
+			Summary: fmt.Sprintf("[%s] %s: %s", e.Level, e.Source, opts.Redactor.String(e.Message)),
+		})
+	}
+	return events, nil
+}
+
+// logKind classifies a log entry as a raft, gossip, error or warn event, or "" to leave it out.
+// INFO entries are only kept from raft and gossip sources; every WARN and ERROR entry is kept.
+func logKind(e log.LogEntry) string {
+	switch {
+	case strings.Contains(e.Source, "raft") || strings.Contains(e.Source, "leader") || strings.Contains(e.Source, "autopilot"):
+		return "raft"
+	case strings.Contains(e.Source, "serf") || strings.Contains(e.Source, "memberlist"):
+		return "gossip"
+	case e.Level == log.ErrorLevel:
+		return "error"
+	case e.Level == log.WarnLevel:
+		return "warn"
+	}
+	return ""
+}
+
+func metricEvents(metrics read.Metrics, opts Options) ([]Event, error) {
+	anomalies, err := metrics.DetectAnomaliesBetween(nil, opts.Since, opts.Until, opts.TopAnomalies)
+	if err != nil {
+		return nil, err
+	}
+	var events []Event
+	for _, a := range anomalies {
+		name := a.Name
+		if labels := read.FormatLabels(a.Labels); labels != "" {
+			name = fmt.Sprintf("%s{%s}", a.Name, labels)
+		}
+		var summary string
+		switch a.Kind {
+		case read.AnomalySpike:
+			summary = fmt.Sprintf("%s spiked to %s (baseline %s, %+.0f%%)", name, formatValue(a.After), formatValue(a.Before), percentChange(a))
+		case read.AnomalyLevelShift:
+			summary = fmt.Sprintf("%s shifted from %s to %s (%+.0f%%)", name, formatValue(a.Before), formatValue(a.After), percentChange(a))
+		default:
+			verb := "grew"
+			if a.Kind == read.AnomalyDrop {
+				verb = "dropped"
+			}
+			summary = fmt.Sprintf("%s %s from %s to %s (%+.0f%%) through %s", name, verb, formatValue(a.Before), formatValue(a.After),
+				percentChange(a), a.End.Format(time.TimeOnly))
+		}
+		events = append(events, Event{Time: a.Start, Source: SourceMetric, Kind: string(a.Kind), Summary: summary})
+	}
+	return events, nil
+}
+
+func profileEvents(opts Options) []Event {
+	var events []Event
+	previous := -1
+	for _, p := range opts.Profiles {
+		current := p.Goroutines
+		if !inWindow(p.Timestamp, opts) {
+			previous = current
+			continue
+		}
+		summary := fmt.Sprintf("captured %s", strings.Join(p.Files, ", "))
+		kind := "capture"
+		if current >= 0 {
+			summary = fmt.Sprintf("goroutine.prof: %d goroutines", current)
+			if previous > 0 {
+				change := float64(current-previous) / float64(previous)
+				summary += fmt.Sprintf(" (%+d, %+.0f%% vs previous capture)", current-previous, change*100)
+				if change >= profileChangeRatio || change <= -profileChangeRatio {
+					kind = "goroutines"
+				}
+			}
+		}
+		events = append(events, Event{Time: p.Timestamp, Source: SourceProfile, Kind: kind, Summary: summary})
+		previous = current
+	}
+	return events
+}
+
+func inWindow(t time.Time, opts Options) bool {
+	if !opts.Since.IsZero() && t.Before(opts.Since) {
+		return false
+	}
+	if !opts.Until.IsZero() && t.After(opts.Until) {
+		return false
+	}
+	return true
+}
+
+func percentChange(a read.Anomaly) float64 {
+	sign := 1.0
+	if a.After < a.Before {
+		sign = -1
+	}
+	return sign * a.Severity * 100
+}
+
+func formatValue(v float64) string {
+	return fmt.Sprintf("%.6g", v)
+}
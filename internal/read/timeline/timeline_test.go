@@ -0,0 +1,112 @@
+package timeline
+
+import (
+	"consul-debug-read/internal/read"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuild(t *testing.T) {
+	start := time.Date(2024, 2, 7, 12, 40, 0, 0, time.FixedZone("EST", -5*60*60))
+	at := func(seconds int) time.Time { return start.Add(time.Duration(seconds) * time.Second) }
+
+	series := map[string][]float64{
+		// A spike before the window, which must be left out.
+		"consul.early": {5, 5, 50, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5},
+		// Across the whole capture this is a level shift at 100s; inside the window only the
+		// spike at 150s stands out.
+		"consul.windowed": {100, 100, 100, 100, 100, 100, 100, 100, 100, 100, 10, 10, 10, 10, 10, 14, 10, 10, 10, 10},
+	}
+	metrics := read.Metrics{MetricsMap: map[string][]map[string]interface{}{}}
+	for name, values := range series {
+		for i, v := range values {
+			metrics.MetricsMap[name] = append(metrics.MetricsMap[name], map[string]interface{}{
+				"timestamp": at(i * 10).Format(read.MetricsTimestampLayout),
+				"value":     v,
+				"labels":    map[string]string{},
+				"type":      "gauge",
+			})
+		}
+	}
+
+	const logLayout = "2006-01-02T15:04:05.000-0700"
+	logFile := filepath.Join(t.TempDir(), "consul.log")
+	lines := []string{
+		at(30).Format(logLayout) + " [ERROR] agent: before the window",
+		at(150).Format(logLayout) + " [INFO]  agent: Synced node info",
+		at(150).Format(logLayout) + " [WARN]  agent.server.raft: heartbeat timeout reached, starting election",
+		at(170).Format(logLayout) + " [ERROR] agent.http: request failed",
+	}
+	if err := os.WriteFile(logFile, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := Options{
+		Since:   at(100),
+		Until:   at(190),
+		LogFile: logFile,
+		Profiles: []read.ProfileCapture{
+			{Timestamp: at(80), Files: []string{"goroutine.prof"}, Goroutines: 100},
+			{Timestamp: at(150), Files: []string{"goroutine.prof"}, Goroutines: 200},
+			{Timestamp: at(190), Files: []string{"heap.prof"}, Goroutines: -1},
+		},
+	}
+	events, err := Build(metrics, opts)
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+
+	want := []struct {
+		time    time.Time
+		source  string
+		kind    string
+		summary string
+	}{
+		{at(150), SourceLog, "raft", "heartbeat timeout reached"},
+		{at(150), SourceMetric, string(read.AnomalySpike), "consul.windowed spiked to 14 (baseline 10, +40%)"},
+		{at(150), SourceProfile, "goroutines", "200 goroutines (+100, +100% vs previous capture)"},
+		{at(170), SourceLog, "error", "request failed"},
+		{at(190), SourceProfile, "capture", "captured heap.prof"},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("got %d events, want %d: %+v", len(events), len(want), events)
+	}
+	for i, w := range want {
+		e := events[i]
+		if !e.Time.Equal(w.time) || e.Source != w.source || e.Kind != w.kind || !strings.Contains(e.Summary, w.summary) {
+			t.Errorf("event %d = %+v, want %s %s %s containing %q", i, e, w.time, w.source, w.kind, w.summary)
+		}
+	}
+}
+
+func TestBuildTopAnomalies(t *testing.T) {
+	start := time.Date(2024, 2, 7, 12, 40, 0, 0, time.UTC)
+	metrics := read.Metrics{MetricsMap: map[string][]map[string]interface{}{}}
+	series := map[string][]float64{
+		"consul.small": {10, 10, 10, 10, 10, 10, 10, 10, 10, 10, 10, 20, 10, 10},
+		"consul.large": {10, 10, 10, 10, 10, 10, 10, 10, 10, 10, 10, 10, 90, 10},
+		// The most severe spike of the capture, but outside the window.
+		"consul.outside": {10, 900, 10, 10, 10, 10, 10, 10, 10, 10, 10, 10, 10, 10},
+	}
+	for name, values := range series {
+		for i, v := range values {
+			metrics.MetricsMap[name] = append(metrics.MetricsMap[name], map[string]interface{}{
+				"timestamp": start.Add(time.Duration(i) * 10 * time.Second).Format(read.MetricsTimestampLayout),
+				"value":     v,
+				"labels":    map[string]string{},
+				"type":      "gauge",
+			})
+		}
+	}
+
+	events, err := Build(metrics, Options{Since: start.Add(30 * time.Second), TopAnomalies: 1})
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	if len(events) != 1 || !strings.HasPrefix(events[0].Summary, "consul.large spiked") {
+		t.Fatalf("events = %+v, want only the consul.large spike", events)
+	}
+}
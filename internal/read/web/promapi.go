@@ -0,0 +1,303 @@
+package web
+
+import (
+	"consul-debug-read/internal/read"
+	"consul-debug-read/internal/read/query"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PrometheusAPI serves a read-only subset of the Prometheus HTTP API over a query.Engine, so a
+// Grafana Prometheus data source can chart offline bundle captures.
+//
+// Metric names and label names are exposed in their sanitized Prometheus form
+// (consul.raft.apply => consul_raft_apply), matching the names used by the Consul Prometheus endpoint.
+type PrometheusAPI struct {
+	// mu guards the engine, whose selector cache is not safe for concurrent use.
+	mu     sync.Mutex
+	engine *query.Engine
+}
+
+// NewPrometheusAPI returns a PrometheusAPI evaluating queries with the given engine.
+func NewPrometheusAPI(engine *query.Engine) *PrometheusAPI {
+	return &PrometheusAPI{engine: engine}
+}
+
+// Handler returns the http.Handler serving the /api/v1 endpoints.
+func (a *PrometheusAPI) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/query", a.handleQuery)
+	mux.HandleFunc("/api/v1/query_range", a.handleQueryRange)
+	mux.HandleFunc("/api/v1/series", a.handleSeries)
+	mux.HandleFunc("/api/v1/labels", a.handleLabels)
+	mux.HandleFunc("/api/v1/label/", a.handleLabelValues)
+	return mux
+}
+
+type promResponse struct {
+	Status    string      `json:"status"`
+	Data      interface{} `json:"data,omitempty"`
+	ErrorType string      `json:"errorType,omitempty"`
+	Error     string      `json:"error,omitempty"`
+}
+
+type promQueryData struct {
+	ResultType string      `json:"resultType"`
+	Result     interface{} `json:"result"`
+}
+
+type promSample struct {
+	Metric map[string]string `json:"metric"`
+	Value  [2]interface{}    `json:"value"`
+}
+
+type promSeries struct {
+	Metric map[string]string `json:"metric"`
+	Values [][2]interface{}  `json:"values"`
+}
+
+func (a *PrometheusAPI) handleQuery(w http.ResponseWriter, r *http.Request) {
+	_, end := a.engine.Bounds()
+	t, err := parsePromTime(r.FormValue("time"), end)
+	if err != nil {
+		promError(w, http.StatusBadRequest, "bad_data", err)
+		return
+	}
+	expr := r.FormValue("query")
+	a.mu.Lock()
+	result, err := a.engine.Instant(expr, t)
+	a.mu.Unlock()
+	if err != nil {
+		promError(w, http.StatusBadRequest, "bad_data", err)
+		return
+	}
+	switch v := result.(type) {
+	case query.Scalar:
+		promSuccess(w, promQueryData{ResultType: "scalar", Result: promPoint(t, float64(v))})
+	case query.Vector:
+		samples := make([]promSample, 0, len(v))
+		for _, s := range v {
+			samples = append(samples, promSample{Metric: promLabels(s.Metric), Value: promPoint(s.T, s.V)})
+		}
+		promSuccess(w, promQueryData{ResultType: "vector", Result: samples})
+	default:
+		promError(w, http.StatusBadRequest, "bad_data", fmt.Errorf("query %q must evaluate to a scalar or instant vector", expr))
+	}
+}
+
+func (a *PrometheusAPI) handleQueryRange(w http.ResponseWriter, r *http.Request) {
+	// Unlike instant queries, range queries have no default time range.
+	for _, param := range []string{"start", "end", "step"} {
+		if r.FormValue(param) == "" {
+			promError(w, http.StatusBadRequest, "bad_data", fmt.Errorf("missing %s", param))
+			return
+		}
+	}
+	start, err := parsePromTime(r.FormValue("start"), time.Time{})
+	if err != nil {
+		promError(w, http.StatusBadRequest, "bad_data", fmt.Errorf("invalid start: %v", err))
+		return
+	}
+	end, err := parsePromTime(r.FormValue("end"), time.Time{})
+	if err != nil {
+		promError(w, http.StatusBadRequest, "bad_data", fmt.Errorf("invalid end: %v", err))
+		return
+	}
+	step, err := parsePromDuration(r.FormValue("step"))
+	if err != nil {
+		promError(w, http.StatusBadRequest, "bad_data", fmt.Errorf("invalid step: %v", err))
+		return
+	}
+	a.mu.Lock()
+	matrix, err := a.engine.Range(r.FormValue("query"), start, end, step)
+	a.mu.Unlock()
+	if err != nil {
+		promError(w, http.StatusBadRequest, "bad_data", err)
+		return
+	}
+	series := make([]promSeries, 0, len(matrix))
+	for _, s := range matrix {
+		ps := promSeries{Metric: promLabels(s.Metric), Values: make([][2]interface{}, 0, len(s.Points))}
+		for _, p := range s.Points {
+			ps.Values = append(ps.Values, promPoint(p.T, p.V))
+		}
+		series = append(series, ps)
+	}
+	promSuccess(w, promQueryData{ResultType: "matrix", Result: series})
+}
+
+func (a *PrometheusAPI) handleSeries(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		promError(w, http.StatusBadRequest, "bad_data", err)
+		return
+	}
+	if len(r.Form["match[]"]) == 0 {
+		promError(w, http.StatusBadRequest, "bad_data", fmt.Errorf("no match[] parameter provided"))
+		return
+	}
+	labelSets, err := a.matchSeries(r)
+	if err != nil {
+		promError(w, http.StatusBadRequest, "bad_data", err)
+		return
+	}
+	promSuccess(w, labelSets)
+}
+
+func (a *PrometheusAPI) handleLabels(w http.ResponseWriter, r *http.Request) {
+	labelSets, err := a.matchSeries(r)
+	if err != nil {
+		promError(w, http.StatusBadRequest, "bad_data", err)
+		return
+	}
+	names := make(map[string]struct{})
+	for _, labels := range labelSets {
+		for k := range labels {
+			names[k] = struct{}{}
+		}
+	}
+	promSuccess(w, sortedKeys(names))
+}
+
+// handleLabelValues serves /api/v1/label/<name>/values.
+func (a *PrometheusAPI) handleLabelValues(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/v1/label/"), "/values")
+	if name == "" || strings.Contains(name, "/") {
+		promError(w, http.StatusNotFound, "bad_data", fmt.Errorf("unknown endpoint %s", r.URL.Path))
+		return
+	}
+	labelSets, err := a.matchSeries(r)
+	if err != nil {
+		promError(w, http.StatusBadRequest, "bad_data", err)
+		return
+	}
+	values := make(map[string]struct{})
+	for _, labels := range labelSets {
+		if v, ok := labels[name]; ok {
+			values[v] = struct{}{}
+		}
+	}
+	promSuccess(w, sortedKeys(values))
+}
+
+// matchSeries returns the Prometheus label sets of the series selected by the request's match[]
+// selectors within the optional start/end bounds, or of every series when no match[] is given.
+func (a *PrometheusAPI) matchSeries(r *http.Request) ([]map[string]string, error) {
+	if err := r.ParseForm(); err != nil {
+		return nil, err
+	}
+	start, err := parsePromTime(r.Form.Get("start"), time.Time{})
+	if err != nil {
+		return nil, fmt.Errorf("invalid start: %v", err)
+	}
+	end, err := parsePromTime(r.Form.Get("end"), time.Time{})
+	if err != nil {
+		return nil, fmt.Errorf("invalid end: %v", err)
+	}
+	matches := r.Form["match[]"]
+	if len(matches) == 0 {
+		matches = []string{`{__name__=~".+"}`}
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	seen := make(map[string]bool)
+	var result []map[string]string
+	for _, m := range matches {
+		expr, err := query.Parse(m)
+		if err != nil {
+			return nil, err
+		}
+		vs, ok := expr.(*query.VectorSelector)
+		if !ok {
+			return nil, fmt.Errorf("match[] %q must be a series selector", m)
+		}
+		labelSets, err := a.engine.Series(vs, start, end)
+		if err != nil {
+			return nil, err
+		}
+		for _, labels := range labelSets {
+			if sig := labels.Signature(); !seen[sig] {
+				seen[sig] = true
+				result = append(result, promLabels(labels))
+			}
+		}
+	}
+	if result == nil {
+		result = []map[string]string{}
+	}
+	return result, nil
+}
+
+// promLabels converts engine labels into their sanitized Prometheus form.
+func promLabels(l query.Labels) map[string]string {
+	out := make(map[string]string, len(l))
+	for k, v := range l {
+		if k == query.MetricNameLabel {
+			out[k] = read.SanitizeMetricName(v)
+			continue
+		}
+		out[read.SanitizeLabelName(k)] = v
+	}
+	return out
+}
+
+func promPoint(t time.Time, v float64) [2]interface{} {
+	var value string
+	switch {
+	case math.IsNaN(v):
+		value = "NaN"
+	case math.IsInf(v, 1):
+		value = "+Inf"
+	case math.IsInf(v, -1):
+		value = "-Inf"
+	default:
+		value = strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return [2]interface{}{float64(t.UnixMilli()) / 1000, value}
+}
+
+// parsePromTime parses a Prometheus API timestamp (unix seconds or RFC3339), returning def when empty.
+func parsePromTime(s string, def time.Time) (time.Time, error) {
+	if s == "" {
+		return def, nil
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		sec, frac := math.Modf(f)
+		return time.Unix(int64(sec), int64(frac*float64(time.Second))), nil
+	}
+	return time.Parse(time.RFC3339Nano, s)
+}
+
+// parsePromDuration parses a Prometheus API step, either float seconds or a duration string such
+// as 30s or 1d.
+func parsePromDuration(s string) (time.Duration, error) {
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return time.Duration(f * float64(time.Second)), nil
+	}
+	return query.ParseDuration(s)
+}
+
+func sortedKeys(m map[string]struct{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func promSuccess(w http.ResponseWriter, data interface{}) {
+	writeJSON(w, promResponse{Status: "success", Data: data})
+}
+
+func promError(w http.ResponseWriter, status int, errorType string, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	writeJSON(w, promResponse{Status: "error", ErrorType: errorType, Error: err.Error()})
+}
@@ -0,0 +1,78 @@
+package web
+
+import (
+	"consul-debug-read/internal/read"
+	"consul-debug-read/internal/read/query"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestPrometheusAPI(t *testing.T) {
+	metrics := read.Metrics{MetricsMap: map[string][]map[string]interface{}{
+		"consul.raft.apply": {
+			{"timestamp": "2024-02-07 12:40:00 -0500 EST", "value": 2, "labels": map[string]string{}, "type": "counter"},
+			{"timestamp": "2024-02-07 12:40:10 -0500 EST", "value": 4, "labels": map[string]string{}, "type": "counter"},
+		},
+	}}
+	h := NewPrometheusAPI(query.NewEngine(metrics)).Handler()
+
+	cases := []struct {
+		path string
+		code int
+		want string
+	}{
+		{
+			path: "/api/v1/query_range?query=" + url.QueryEscape("increase(consul_raft_apply[1m])") + "&start=1707327600&end=1707327610&step=10s",
+			code: http.StatusOK,
+			want: `{"status":"success","data":{"resultType":"matrix","result":[{"metric":{},"values":[[1707327600,"2"],[1707327610,"6"]]}]}}`,
+		},
+		{
+			path: "/api/v1/query?query=consul.raft.apply&time=1707327610",
+			code: http.StatusOK,
+			want: `{"status":"success","data":{"resultType":"vector","result":[{"metric":{"__name__":"consul_raft_apply"},"value":[1707327610,"4"]}]}}`,
+		},
+		{
+			path: "/api/v1/label/__name__/values",
+			code: http.StatusOK,
+			want: `{"status":"success","data":["consul_raft_apply"]}`,
+		},
+		{
+			path: "/api/v1/query_range?query=" + url.QueryEscape("increase(consul_raft_apply[1d])") + "&start=1707327600&end=1707327610&step=1d",
+			code: http.StatusOK,
+			want: `{"status":"success","data":{"resultType":"matrix","result":[{"metric":{},"values":[[1707327600,"2"]]}]}}`,
+		},
+		{
+			path: "/api/v1/query_range?query=consul_raft_apply&start=1707327600&end=1707327610&step=1fortnight",
+			code: http.StatusBadRequest,
+			want: `{"status":"error","errorType":"bad_data","error":"invalid step: invalid duration \"1fortnight\", expected a number followed by one of the units ms, s, m, h, d, w or y"}`,
+		},
+		{
+			path: "/api/v1/query_range?query=consul_raft_apply&end=1707327610&step=10s",
+			code: http.StatusBadRequest,
+			want: `{"status":"error","errorType":"bad_data","error":"missing start"}`,
+		},
+		{
+			path: "/api/v1/query_range?query=consul_raft_apply&start=1707327600&step=10s",
+			code: http.StatusBadRequest,
+			want: `{"status":"error","errorType":"bad_data","error":"missing end"}`,
+		},
+		{
+			path: "/api/v1/series",
+			code: http.StatusBadRequest,
+			want: `{"status":"error","errorType":"bad_data","error":"no match[] parameter provided"}`,
+		},
+	}
+	for _, tc := range cases {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tc.path, nil))
+		if rec.Code != tc.code {
+			t.Errorf("%s: status = %d, want %d", tc.path, rec.Code, tc.code)
+		}
+		if got := strings.TrimSpace(rec.Body.String()); got != tc.want {
+			t.Errorf("%s:\n got %s\nwant %s", tc.path, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,266 @@
+package web
+
+import (
+	"consul-debug-read/internal/read"
+	"consul-debug-read/internal/read/log"
+	"consul-debug-read/pkg/redact"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+//go:embed static
+var static embed.FS
+
+const (
+	// defaultLogLimit caps the number of log entries returned by a single search.
+	defaultLogLimit = 500
+	allLogLevels    = "TRACE|DEBUG|INFO|WARN|ERROR"
+)
+
+// Server serves a self-contained dashboard and JSON API over a decoded debug bundle.
+type Server struct {
+	data     *read.Debug
+	logFile  string
+	groups   map[string][]string
+	redactor *redact.Redactor
+
+	logOnce sync.Once
+	logs    []log.LogEntry
+	logErr  error
+}
+
+// NewServer returns a dashboard Server over an already decoded bundle. The bundle must have
+// agent, members, index and metrics data decoded. logFile is the bundle's consul.log path and
+// groups are the key metric groups rendered on the key metrics tab.
+func NewServer(data *read.Debug, logFile string, groups map[string][]string) *Server {
+	return &Server{data: data, logFile: logFile, groups: groups}
+}
+
+// WithRedactor redacts secrets from the consul.log messages the server returns with r.
+func (s *Server) WithRedactor(r *redact.Redactor) *Server {
+	s.redactor = r
+	return s
+}
+
+// Handler returns the http.Handler serving the dashboard UI and its /api endpoints.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	ui, _ := fs.Sub(static, "static")
+	mux.Handle("/", http.FileServer(http.FS(ui)))
+	mux.HandleFunc("/api/overview", s.handleOverview)
+	mux.HandleFunc("/api/metrics", s.handleMetrics)
+	mux.HandleFunc("/api/series", s.handleSeries)
+	mux.HandleFunc("/api/groups", s.handleGroups)
+	mux.HandleFunc("/api/members", s.handleMembers)
+	mux.HandleFunc("/api/raft", s.handleRaft)
+	mux.HandleFunc("/api/logs", s.handleLogs)
+	return mux
+}
+
+type overview struct {
+	Node         string `json:"node"`
+	Datacenter   string `json:"datacenter"`
+	Version      string `json:"version"`
+	Server       bool   `json:"server"`
+	Interval     string `json:"interval"`
+	Duration     string `json:"duration"`
+	CaptureStart string `json:"captureStart"`
+	CaptureEnd   string `json:"captureEnd"`
+	MetricNames  int    `json:"metricNames"`
+}
+
+type metricInfo struct {
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	Series  int    `json:"series"`
+	Samples int    `json:"samples"`
+}
+
+type seriesJSON struct {
+	Name   string            `json:"name"`
+	Type   string            `json:"type"`
+	Labels map[string]string `json:"labels"`
+	// Points are [unix milliseconds, value] pairs.
+	Points [][2]float64 `json:"points"`
+}
+
+type groupJSON struct {
+	Name    string   `json:"name"`
+	Metrics []string `json:"metrics"`
+}
+
+type logJSON struct {
+	Timestamp string `json:"timestamp"`
+	Level     string `json:"level"`
+	Source    string `json:"source"`
+	Message   string `json:"message"`
+}
+
+func (s *Server) handleOverview(w http.ResponseWriter, _ *http.Request) {
+	o := overview{
+		Node:        s.data.Agent.Config.NodeName,
+		Datacenter:  s.data.Agent.Config.Datacenter,
+		Version:     s.data.Agent.Config.Version,
+		Server:      s.data.Agent.Config.Server,
+		Interval:    s.data.Index.Interval,
+		Duration:    s.data.Index.Duration,
+		MetricNames: len(s.data.Metrics.MetricsMap),
+	}
+	if captures := s.data.Metrics.Metrics; len(captures) > 0 {
+		o.CaptureStart = captures[0].Timestamp
+		o.CaptureEnd = captures[len(captures)-1].Timestamp
+	}
+	writeJSON(w, o)
+}
+
+func (s *Server) handleMetrics(w http.ResponseWriter, _ *http.Request) {
+	infos := make([]metricInfo, 0, len(s.data.Metrics.MetricsMap))
+	for name, scrapes := range s.data.Metrics.MetricsMap {
+		info := metricInfo{Name: name, Samples: len(scrapes)}
+		labelSets := make(map[string]struct{})
+		for _, scrape := range scrapes {
+			if info.Type == "" {
+				info.Type, _ = scrape["type"].(string)
+			}
+			labels, _ := scrape["labels"].(map[string]string)
+			labelSets[read.FormatLabels(labels)] = struct{}{}
+		}
+		info.Series = len(labelSets)
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	writeJSON(w, infos)
+}
+
+// handleSeries returns every series of ?query=<selector>. Plain metric names match exactly,
+// names containing '*' are matched as globs.
+func (s *Server) handleSeries(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query().Get("query")
+	sel, err := read.ParseMetricSelector(query)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	var series []read.Series
+	if sel.Name == "" || strings.Contains(sel.Name, "*") {
+		series, err = s.data.Metrics.Select(sel)
+	} else {
+		series, err = s.data.Metrics.SelectFunc(func(name string) bool { return name == sel.Name }, sel)
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	result := make([]seriesJSON, 0, len(series))
+	for _, ser := range series {
+		sj := seriesJSON{Name: ser.Name, Type: ser.Type, Labels: ser.Labels, Points: make([][2]float64, 0, len(ser.Samples))}
+		for _, sample := range ser.Samples {
+			sj.Points = append(sj.Points, [2]float64{float64(sample.Timestamp.UnixMilli()), sample.Value})
+		}
+		result = append(result, sj)
+	}
+	writeJSON(w, result)
+}
+
+func (s *Server) handleGroups(w http.ResponseWriter, _ *http.Request) {
+	groups := make([]groupJSON, 0, len(s.groups))
+	for name, metrics := range s.groups {
+		groups = append(groups, groupJSON{Name: name, Metrics: metrics})
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Name < groups[j].Name })
+	writeJSON(w, groups)
+}
+
+func (s *Server) handleMembers(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, s.data.Agent.MemberSummaries())
+}
+
+func (s *Server) handleRaft(w http.ResponseWriter, _ *http.Request) {
+	if !s.data.Agent.Config.Server {
+		writeError(w, http.StatusNotFound, fmt.Errorf("bundle is from non-server consul agent (client agent), raft configuration unavailable"))
+		return
+	}
+	peers, err := s.data.RaftPeers()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, peers)
+}
+
+// handleLogs searches consul.log entries. Supported parameters:
+//
+//	q      case-insensitive substring of the source or message
+//	level  comma separated log levels (default all)
+//	limit  maximum entries returned (default 500)
+func (s *Server) handleLogs(w http.ResponseWriter, r *http.Request) {
+	s.logOnce.Do(func() {
+		s.logs, s.logErr = log.ParseLog(s.logFile, allLogLevels, "", time.Time{}, time.Time{})
+		for i := range s.logs {
+			s.logs[i].Message = s.redactor.String(s.logs[i].Message)
+		}
+	})
+	if s.logErr != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("failed to parse %s: %v", s.logFile, s.logErr))
+		return
+	}
+
+	params := r.URL.Query()
+	q := strings.ToLower(params.Get("q"))
+	levels := make(map[string]bool)
+	for _, l := range strings.Split(params.Get("level"), ",") {
+		if l = strings.ToUpper(strings.TrimSpace(l)); l != "" {
+			levels[l] = true
+		}
+	}
+	limit := defaultLogLimit
+	if v := params.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid limit %q", v))
+			return
+		}
+		limit = n
+	}
+
+	result := make([]logJSON, 0)
+	for _, entry := range s.logs {
+		if len(levels) > 0 && !levels[entry.Level] {
+			continue
+		}
+		if q != "" && !strings.Contains(strings.ToLower(entry.Source), q) && !strings.Contains(strings.ToLower(entry.Message), q) {
+			continue
+		}
+		result = append(result, logJSON{
+			Timestamp: entry.Timestamp.Format(time.RFC3339Nano),
+			Level:     entry.Level,
+			Source:    entry.Source,
+			Message:   entry.Message,
+		})
+		if len(result) >= limit {
+			break
+		}
+	}
+	writeJSON(w, result)
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,75 @@
+package web
+
+import (
+	"consul-debug-read/internal/read"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+)
+
+func TestSeriesHandler(t *testing.T) {
+	data := &read.Debug{Metrics: read.Metrics{MetricsMap: map[string][]map[string]interface{}{
+		"consul.rpc.request": {
+			{"timestamp": "2024-02-07 12:40:00 -0500 EST", "value": 5, "labels": map[string]string{"type": "read"}, "type": "counter"},
+			{"timestamp": "2024-02-07 12:40:00 -0500 EST", "value": 7, "labels": map[string]string{"type": "write"}, "type": "counter"},
+		},
+		"consul.rpc.request_error": {
+			{"timestamp": "2024-02-07 12:40:00 -0500 EST", "value": 1, "labels": map[string]string{}, "type": "counter"},
+		},
+	}}}
+	h := NewServer(data, "", nil).Handler()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/series?query="+url.QueryEscape("consul.rpc.request{type=write}"), nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body: %s", rec.Code, rec.Body)
+	}
+	var series []seriesJSON
+	if err := json.Unmarshal(rec.Body.Bytes(), &series); err != nil {
+		t.Fatal(err)
+	}
+	if len(series) != 1 || series[0].Labels["type"] != "write" || series[0].Points[0] != [2]float64{1707327600000, 7} {
+		t.Errorf("unexpected series: %+v", series)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/series?query="+url.QueryEscape("consul.rpc.request{type"), nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid selector status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
+
+// TestMembersHandler is meant to be run with -race.
+func TestMembersHandler(t *testing.T) {
+	data := &read.Debug{Agent: read.Agent{Members: []read.Member{
+		{Name: "server-2", Addr: "10.0.0.2", Port: 8301, Status: 1},
+		{Name: "client-1", Addr: "10.0.0.3", Port: 8301, Status: 1},
+		{Name: "server-1", Addr: "10.0.0.1", Port: 8301, Status: 1},
+	}}}
+	h := NewServer(data, "", nil).Handler()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/members", nil))
+			var members []read.MemberSummary
+			if err := json.Unmarshal(rec.Body.Bytes(), &members); err != nil {
+				t.Error(err)
+				return
+			}
+			if len(members) != 3 || members[0].Node != "client-1" || members[2].Node != "server-2" {
+				t.Errorf("expected members sorted by name, got %+v", members)
+			}
+		}()
+	}
+	wg.Wait()
+	if data.Agent.Members[0].Name != "server-2" {
+		t.Errorf("expected the bundle members to be left in capture order, got %+v", data.Agent.Members)
+	}
+}
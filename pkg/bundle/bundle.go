@@ -0,0 +1,279 @@
+// Package bundle reads consul debug bundles for use by other Go programs.
+//
+// A Bundle is opened from an extracted bundle directory or a .tar.gz archive. Its files are only
+// decoded when first accessed, and logs and metric samples can be iterated over without holding
+// them all in memory:
+//
+//	b, err := bundle.Open("bundles/124722consul-debug-2023-10-04T18-29-47Z.tar.gz")
+//	if err != nil {
+//		return err
+//	}
+//	agent, err := b.Agent()
+//	var decodeErr *bundle.DecodeError
+//	if errors.As(err, &decodeErr) {
+//		log.Printf("%s is damaged at byte %d", decodeErr.File, decodeErr.Offset)
+//	}
+//	logs, err := b.Logs(bundle.LogFilter{Levels: []string{bundle.LevelError}})
+//	if err != nil {
+//		return err
+//	}
+//	defer logs.Close()
+//	for logs.Next() {
+//		fmt.Println(logs.Entry().Message)
+//	}
+//
+// When several parts are needed at once, DecodeContext decodes them concurrently and can be
+// cancelled; WithProgress reports each file as it is read:
+//
+//	b, err := bundle.Open(dir, bundle.WithProgress(func(p bundle.Progress) {
+//		if p.Done {
+//			log.Printf("read %s (%d bytes) in %s", p.File, p.Size, p.Elapsed)
+//		}
+//	}))
+//	data, err := b.DecodeContext(ctx, bundle.PartAgent, bundle.PartHost, bundle.PartMetrics)
+//
+// The package API follows semantic versioning, see Version.
+package bundle
+
+import (
+	"consul-debug-read/internal/read"
+	"consul-debug-read/internal/read/loader"
+	"consul-debug-read/internal/read/log"
+	"consul-debug-read/pkg/redact"
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// Version is the semantic version of the package API: minor versions add to it and only a new
+// major version removes or changes exported identifiers.
+const Version = "2.0.0"
+
+// Parts of a bundle, named after the bundle file they are decoded from.
+const (
+	PartIndex   = loader.PartIndex
+	PartAgent   = loader.PartAgent
+	PartMembers = loader.PartMembers
+	PartHost    = loader.PartHost
+	PartMetrics = loader.PartMetrics
+)
+
+// Decoded bundle files, as captured by consul debug.
+type (
+	Config         = read.Config
+	DebugConfig    = read.DebugConfig
+	Stats          = read.Stats
+	Member         = read.Member
+	Host           = read.Host
+	Index          = read.Index
+	Metrics        = read.Metrics
+	Metric         = read.Metric
+	Gauge          = read.Gauge
+	Points         = read.Points
+	Counters       = read.Counters
+	Samples        = read.Samples
+	RaftServer     = read.RaftServer
+	RaftPeer       = read.RaftPeer
+	Series         = read.Series
+	SeriesSample   = read.SeriesSample
+	ProfileCapture = read.ProfileCapture
+	LogEntry       = log.LogEntry
+	// RPCCall is an RPC served by the agent, from its rpc_server_call DEBUG log entries.
+	RPCCall = log.Entry
+)
+
+// DecodeError is returned when a bundle file cannot be read or decoded. It names the bundle
+// directory, file, byte offset and mistyped field, and unwraps to the underlying error.
+type DecodeError = read.DecodeError
+
+// DamagedCapture is a capture of metrics.json skipped because it did not decode; see Metrics.Damaged.
+type DamagedCapture = read.DamagedCapture
+
+// Debug is the decoded contents of a bundle. Parts that were not decoded are left zero.
+type Debug struct {
+	Index   Index
+	Agent   Agent
+	Host    Host
+	Metrics Metrics
+}
+
+// Agent is the agent self information of agent.json, with the serf members of members.json.
+type Agent struct {
+	Config      Config
+	DebugConfig DebugConfig
+	// RawDebugConfig is the DebugConfig object of agent.json as captured, holding every setting.
+	RawDebugConfig json.RawMessage
+	Stats          Stats
+	// Member is the agent's own serf member.
+	Member  Member
+	Members []Member
+}
+
+func newDebug(data *read.Debug) *Debug {
+	return &Debug{Index: data.Index, Agent: newAgent(&data.Agent), Host: data.Host, Metrics: data.Metrics}
+}
+
+func newAgent(agent *read.Agent) Agent {
+	return Agent{
+		Config:         agent.Config,
+		DebugConfig:    agent.DebugConfig,
+		RawDebugConfig: agent.RawDebugConfig,
+		Stats:          agent.Stats,
+		Member:         agent.Member,
+		Members:        agent.Members,
+	}
+}
+
+// Progress reports a bundle part starting or finishing decoding.
+type Progress struct {
+	Part string
+	File string
+	// Size is the size of the file in bytes.
+	Size int64
+	// Done is set once the part has decoded, with Err set when it failed.
+	Done bool
+	// Cached is set when the part was decoded from the cache of WithCache.
+	Cached  bool
+	Elapsed time.Duration
+	Err     error
+}
+
+// Option configures a Bundle opened by Open.
+type Option struct {
+	opt loader.Option
+}
+
+// WithProgress calls fn as each bundle part starts and finishes decoding. Calls are never
+// concurrent, but may come from any goroutine.
+func WithProgress(fn func(Progress)) Option {
+	return Option{loader.WithProgress(func(p loader.Progress) { fn(Progress(p)) })}
+}
+
+// WithCache caches the decoded metrics.json captures and parsed consul.log entries under dir, in
+// a directory named after the SHA-256 of the bundle's metrics.json and consul.log, so opening the
+// same bundle again reads the compact cache instead of parsing them. A bundle whose files change
+// hashes to a new directory; stale directories are left for the caller to remove.
+func WithCache(dir string) Option {
+	return Option{loader.WithCache(dir)}
+}
+
+// WithRedactor redacts secrets with r as the bundle is read: the DebugConfig of agent.json, the
+// labels of metrics.json and the messages of consul.log entries. Caches hold the bundle as
+// captured, so the same cache serves any redactor.
+func WithRedactor(r *redact.Redactor) Option {
+	return Option{loader.WithRedactor(r)}
+}
+
+// Bundle is an opened consul debug bundle. It is safe for concurrent use: Decode, Debug and the
+// accessors return snapshots of the contents decoded so far, which later decodes leave unchanged.
+// Callers must not modify them.
+type Bundle struct {
+	b *loader.Bundle
+}
+
+// Open opens the bundle at path, an extracted bundle directory or a bundle .tar.gz archive, which
+// is extracted alongside the archive. Only index.json is decoded up front.
+func Open(path string, opts ...Option) (*Bundle, error) {
+	loaderOpts := make([]loader.Option, len(opts))
+	for i, opt := range opts {
+		loaderOpts[i] = opt.opt
+	}
+	b, err := loader.Open(path, loaderOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return &Bundle{b: b}, nil
+}
+
+// Load opens the bundle at path and decodes index.json, agent.json and whichever of members.json,
+// host.json and metrics.json the bundle captured. Damaged metrics.json captures are skipped and
+// listed in Metrics.Damaged rather than failing the load.
+func Load(path string, opts ...Option) (*Debug, error) {
+	b, err := Open(path, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return b.DecodeAvailable()
+}
+
+// Dir returns the extracted bundle directory.
+func (b *Bundle) Dir() string { return b.b.Dir() }
+
+// Has reports whether the bundle captured the file of part.
+func (b *Bundle) Has(part string) bool { return b.b.Has(part) }
+
+// Decode decodes the given parts, each at most once, and returns a snapshot of the bundle contents
+// decoded so far. Failures are *DecodeError; a part that failed keeps failing with the same error.
+func (b *Bundle) Decode(names ...string) (*Debug, error) {
+	return b.DecodeContext(context.Background(), names...)
+}
+
+// DecodeContext is Decode, decoding the given parts concurrently so that it takes as long as the
+// slowest part. Once ctx is done it returns ctx.Err() and abandons decodes it started; parts
+// abandoned this way are decoded again by later calls.
+func (b *Bundle) DecodeContext(ctx context.Context, names ...string) (*Debug, error) {
+	data, err := b.b.DecodeContext(ctx, names...)
+	if err != nil {
+		return nil, err
+	}
+	return newDebug(data), nil
+}
+
+// Debug returns a snapshot of the bundle contents decoded so far.
+func (b *Bundle) Debug() *Debug { return newDebug(b.b.Debug()) }
+
+// DecodeAvailable decodes index.json, agent.json and every other part the bundle captured.
+func (b *Bundle) DecodeAvailable() (*Debug, error) {
+	data, err := b.b.DecodeAvailable()
+	if err != nil {
+		return nil, err
+	}
+	return newDebug(data), nil
+}
+
+// Index returns the capture settings of index.json.
+func (b *Bundle) Index() Index { return b.b.Index() }
+
+// Agent returns the agent self information of agent.json, with the members of members.json when
+// they were decoded.
+func (b *Bundle) Agent() (*Agent, error) {
+	agent, err := b.b.Agent()
+	if err != nil {
+		return nil, err
+	}
+	a := newAgent(agent)
+	return &a, nil
+}
+
+// Members returns the serf members of members.json.
+func (b *Bundle) Members() ([]Member, error) { return b.b.Members() }
+
+// Raft returns the latest raft configuration seen by a server agent.
+func (b *Bundle) Raft() ([]RaftPeer, error) { return b.b.Raft() }
+
+// Host returns the host information of host.json.
+func (b *Bundle) Host() (*Host, error) { return b.b.Host() }
+
+// Metrics returns the metric captures of metrics.json.
+func (b *Bundle) Metrics() (*Metrics, error) { return b.b.Metrics() }
+
+// Select returns the metric series matching a selector such as
+// consul.raft.commitTime{quantile="0.99"}; see the metrics query command.
+func (b *Bundle) Select(query string) ([]Series, error) { return b.b.Select(query) }
+
+// Profiles returns the per-interval pprof captures in chronological order.
+func (b *Bundle) Profiles() ([]ProfileCapture, error) { return b.b.Profiles() }
+
+// LogFile returns the path of the bundle's consul.log.
+func (b *Bundle) LogFile() string { return b.b.LogFile() }
+
+// RPCCalls returns the RPCs served by the agent, optionally only those of one method, from the
+// rpc_server_call entries of a DEBUG or TRACE level consul.log.
+func (b *Bundle) RPCCalls(method string) ([]RPCCall, error) { return b.b.RPCCalls(method) }
+
+// CacheDir returns the cache directory of the bundle, hashing metrics.json and consul.log on first use.
+func (b *Bundle) CacheDir() (string, error) { return b.b.CacheDir() }
+
+// Redactor returns the redactor of WithRedactor, nil when the bundle is read as captured. Callers
+// reading bundle files themselves, such as consul.log, apply it to what they display.
+func (b *Bundle) Redactor() *redact.Redactor { return b.b.Redactor() }
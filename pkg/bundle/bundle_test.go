@@ -0,0 +1,113 @@
+package bundle
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeBundle(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestLoad(t *testing.T) {
+	dir := writeBundle(t, map[string]string{
+		"index.json": `{"Version":2,"AgentVersion":"1.17.2","Interval":"10s","Duration":"20s","Targets":["agent","members","metrics"]}`,
+		"agent.json": `{"Config":{"NodeName":"server-1","Server":true},"DebugConfig":{"Datacenter":"dc1","NodeName":"server-1"},` +
+			`"Member":{"Name":"server-1","Addr":"10.0.0.1"}}`,
+		"members.json": `[{"Name":"server-1","Addr":"10.0.0.1","Status":1},{"Name":"client-1","Addr":"10.0.0.2","Status":1}]`,
+		"metrics.json": `{"Timestamp":"2024-02-07 12:40:00 -0500 EST","Gauges":[{"Name":"consul.runtime.num_goroutines","Value":120}]}`,
+	})
+	data, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if data.Index.AgentVersion != "1.17.2" || data.Agent.Config.NodeName != "server-1" || data.Agent.DebugConfig.Datacenter != "dc1" ||
+		data.Agent.Member.Name != "server-1" || len(data.Agent.Members) != 2 || len(data.Metrics.Metrics) != 1 {
+		t.Errorf("unexpected contents %+v", data)
+	}
+	if len(data.Agent.RawDebugConfig) == 0 {
+		t.Errorf("expected the raw DebugConfig of agent.json")
+	}
+
+	_, err = Load(writeBundle(t, map[string]string{"index.json": `{"Version":2}`}))
+	var decodeErr *DecodeError
+	if !errors.As(err, &decodeErr) || decodeErr.File != "agent.json" || !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected a missing agent.json, got %v", err)
+	}
+}
+
+func TestOpen(t *testing.T) {
+	dir := writeBundle(t, map[string]string{
+		"index.json": `{"Version":2,"AgentVersion":"1.17.2","Interval":"10s","Duration":"20s","Targets":["agent","metrics","logs"]}`,
+		"agent.json": `{"Config":{"NodeName":"client-1","Server":false}}`,
+		"metrics.json": `{"Timestamp":"2024-02-07 12:40:00 -0500 EST","Gauges":[{"Name":"consul.runtime.num_goroutines","Value":120}],` +
+			`"Counters":[{"Name":"consul.rpc.request","Count":4,"Rate":0.4,"Sum":4,"Min":1,"Max":1,"Mean":1}]}`,
+		"consul.log": "2024-02-07T12:40:00.000-0500 [INFO]  agent: Synced node info\n" +
+			"2024-02-07T12:40:05.000-0500 [ERROR] agent.client: RPC failed to server: error=\"rpc error\"\n",
+	})
+	var decoded []string
+	b, err := Open(dir, WithProgress(func(p Progress) {
+		if p.Done {
+			decoded = append(decoded, p.File)
+		}
+	}))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if b.Index().AgentVersion != "1.17.2" || b.Debug().Agent.Config.NodeName != "" {
+		t.Errorf("expected only index.json to be decoded on open")
+	}
+
+	agent, err := b.Agent()
+	if err != nil || agent.Config.NodeName != "client-1" {
+		t.Fatalf("Agent: %v, %+v", err, agent)
+	}
+	if _, err = b.Raft(); err == nil {
+		t.Errorf("expected no raft configuration for a client agent")
+	}
+	if len(decoded) != 2 || decoded[0] != "index.json" || decoded[1] != "agent.json" {
+		t.Errorf("unexpected progress %v", decoded)
+	}
+
+	logs, err := b.Logs(LogFilter{Levels: []string{LevelError}})
+	if err != nil {
+		t.Fatalf("Logs: %v", err)
+	}
+	var messages []string
+	for logs.Next() {
+		messages = append(messages, logs.Entry().Message)
+	}
+	if err = logs.Err(); err != nil {
+		t.Fatalf("iterating logs: %v", err)
+	}
+	if err = logs.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) != 1 || messages[0] != `RPC failed to server: error="rpc error"` {
+		t.Errorf("unexpected ERROR entries %q", messages)
+	}
+
+	samples, err := b.Samples()
+	if err != nil {
+		t.Fatalf("Samples: %v", err)
+	}
+	var got []Sample
+	for samples.Next() {
+		got = append(got, samples.Sample())
+	}
+	if err = samples.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].Type != TypeGauge || got[0].Value != 120 || got[1].Type != TypeCounter || got[1].Count != 4 {
+		t.Errorf("unexpected samples %+v", got)
+	}
+}
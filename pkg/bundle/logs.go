@@ -0,0 +1,61 @@
+package bundle
+
+import (
+	"consul-debug-read/internal/read/loader"
+	"time"
+)
+
+// Log levels of consul.log entries.
+const (
+	LevelTrace = loader.LevelTrace
+	LevelDebug = loader.LevelDebug
+	LevelInfo  = loader.LevelInfo
+	LevelWarn  = loader.LevelWarn
+	LevelError = loader.LevelError
+)
+
+// LogFilter selects consul.log entries. Zero values select everything.
+type LogFilter struct {
+	Levels []string
+	// Source is the exact logger name, e.g. agent.server.raft.
+	Source string
+	Since  time.Time
+	Until  time.Time
+}
+
+// LogIterator iterates over the consul.log entries matching a LogFilter:
+//
+//	for it.Next() {
+//		entry := it.Entry()
+//	}
+//	if err := it.Err(); err != nil {
+type LogIterator struct {
+	it *loader.LogIterator
+}
+
+// Logs returns an iterator over the consul.log entries matching filter, which must be closed.
+// With WithCache, consul.log is parsed into the cache once and later reads come from the cache.
+func (b *Bundle) Logs(filter LogFilter) (*LogIterator, error) {
+	it, err := b.b.Logs(loader.LogFilter(filter))
+	if err != nil {
+		return nil, err
+	}
+	return &LogIterator{it: it}, nil
+}
+
+// LogEntries returns every consul.log entry matching filter.
+func (b *Bundle) LogEntries(filter LogFilter) ([]LogEntry, error) {
+	return b.b.LogEntries(loader.LogFilter(filter))
+}
+
+// Next advances to the next entry, returning false when there are no more or on error.
+func (it *LogIterator) Next() bool { return it.it.Next() }
+
+// Entry returns the current entry, its message redacted with the bundle's WithRedactor.
+func (it *LogIterator) Entry() LogEntry { return it.it.Entry() }
+
+// Err returns the first error met while iterating.
+func (it *LogIterator) Err() error { return it.it.Err() }
+
+// Close closes consul.log.
+func (it *LogIterator) Close() error { return it.it.Close() }
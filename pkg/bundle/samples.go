@@ -0,0 +1,56 @@
+package bundle
+
+import (
+	"consul-debug-read/internal/read/loader"
+	"time"
+)
+
+// Metric sample types.
+const (
+	TypeGauge   = loader.TypeGauge
+	TypePoints  = loader.TypePoints
+	TypeCounter = loader.TypeCounter
+	TypeSample  = loader.TypeSample
+)
+
+// Sample is one metric value of one metrics.json capture. Value is the gauge or points value, the
+// counter count or the sample mean, as shown by the metrics commands; the aggregate fields are only
+// set for counters and samples.
+type Sample struct {
+	Timestamp time.Time
+	Name      string
+	Type      string
+	Labels    map[string]string
+	Value     float64
+
+	Count  int
+	Rate   float64
+	Sum    float64
+	Min    float64
+	Max    float64
+	Mean   float64
+	Stddev float64
+}
+
+// SampleIterator iterates over every metric sample of metrics.json in capture order.
+type SampleIterator struct {
+	it *loader.SampleIterator
+}
+
+// Samples returns an iterator over the metric samples of metrics.json.
+func (b *Bundle) Samples() (*SampleIterator, error) {
+	it, err := b.b.Samples()
+	if err != nil {
+		return nil, err
+	}
+	return &SampleIterator{it: it}, nil
+}
+
+// Next advances to the next sample, returning false when there are no more or on error.
+func (it *SampleIterator) Next() bool { return it.it.Next() }
+
+// Sample returns the current sample.
+func (it *SampleIterator) Sample() Sample { return Sample(it.it.Sample()) }
+
+// Err returns the first error met while iterating, such as an unparsable capture timestamp.
+func (it *SampleIterator) Err() error { return it.it.Err() }
@@ -0,0 +1,231 @@
+// Package redact removes secrets from consul debug bundle contents before they are displayed or
+// exported: ACL tokens and accessor IDs, gossip encryption keys, private keys and license text.
+//
+// A Redactor applies Patterns, which match either the names of fields whose whole value is a
+// secret, or secrets within free text such as log messages:
+//
+//	r := redact.New(append(redact.DefaultPatterns(), redact.Pattern{
+//		Name:  "vault-tokens",
+//		Value: regexp.MustCompile(`\bhvs\.[A-Za-z0-9]{24,}`),
+//	})...)
+//	fmt.Println(r.String("token=1b4c5e2f-0a6b-4c83-9b1e-5d7e8f9a0b1c"))
+//
+// A nil *Redactor redacts nothing, so callers can hold one unconditionally.
+package redact
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+// Marker replaces every redacted secret.
+const Marker = "<redacted>"
+
+// consulHidden is what Consul itself replaces secrets with in a debug bundle.
+const consulHidden = "hidden"
+
+// Pattern matches secrets. At least one of Field and Value is set.
+type Pattern struct {
+	Name string
+	// Field matches the names of JSON fields and map keys whose whole string value is a secret,
+	// such as ACLAgentToken or EncryptKey.
+	Field *regexp.Regexp
+	// Value matches secrets within text. When it has a group named secret, only that group is
+	// redacted, keeping context such as the "token=" before it.
+	Value *regexp.Regexp
+}
+
+const uuid = `[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`
+
+// DefaultPatterns returns the patterns a Redactor uses by default, as a new slice callers may
+// extend.
+func DefaultPatterns() []Pattern {
+	return []Pattern{
+		{
+			Name:  "tokens",
+			Field: regexp.MustCompile(`(?i)(token|secret|secret_?id|password|encrypt_?key)$|^encrypt$`),
+			Value: regexp.MustCompile(`(?i)\b[\w.-]*(?:token|secret|password)[\w.-]*["']?\s*[:=]\s*["']?(?P<secret>[^\s"'&,;]+)`),
+		},
+		{
+			Name:  "bearer-tokens",
+			Value: regexp.MustCompile(`(?i)\bbearer\s+(?P<secret>[A-Za-z0-9._~+/-]+=*)`),
+		},
+		{
+			Name:  "accessor-ids",
+			Field: regexp.MustCompile(`(?i)^accessor_?id$`),
+			Value: regexp.MustCompile(`(?i)\baccessor_?id["']?\s*[:=]\s*["']?(?P<secret>` + uuid + `)`),
+		},
+		{
+			Name:  "license",
+			Field: regexp.MustCompile(`(?i)^license$`),
+			Value: regexp.MustCompile(`\b02MV4[A-Z0-9]{40,}\b`),
+		},
+		{
+			Name:  "private-keys",
+			Field: regexp.MustCompile(`(?i)private_?key$`),
+			Value: regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`),
+		},
+	}
+}
+
+// Redactor redacts the secrets matched by its patterns. It is safe for concurrent use.
+type Redactor struct {
+	patterns []Pattern
+}
+
+// New returns a Redactor applying patterns.
+func New(patterns ...Pattern) *Redactor {
+	return &Redactor{patterns: patterns}
+}
+
+// Default returns a Redactor applying DefaultPatterns.
+func Default() *Redactor {
+	return New(DefaultPatterns()...)
+}
+
+// IsRedacted reports whether value is a redacted secret, by a Redactor or by Consul itself.
+func IsRedacted(value string) bool {
+	return value == Marker || value == consulHidden
+}
+
+// Field reports whether the field or map key name holds a secret.
+func (r *Redactor) Field(name string) bool {
+	if r == nil {
+		return false
+	}
+	for _, p := range r.patterns {
+		if p.Field != nil && p.Field.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// String redacts the secrets within s.
+func (r *Redactor) String(s string) string {
+	if r == nil {
+		return s
+	}
+	for _, p := range r.patterns {
+		if p.Value != nil {
+			s = redactMatches(p.Value, s)
+		}
+	}
+	return s
+}
+
+func redactMatches(re *regexp.Regexp, s string) string {
+	matches := re.FindAllStringSubmatchIndex(s, -1)
+	if len(matches) == 0 {
+		return s
+	}
+	group := re.SubexpIndex("secret")
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		start, end := m[0], m[1]
+		if group > 0 {
+			start, end = m[2*group], m[2*group+1]
+		}
+		if start < 0 || start < last {
+			continue
+		}
+		b.WriteString(s[last:start])
+		b.WriteString(Marker)
+		last = end
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+// Value redacts the value of the field or map key name: all of it when name holds a secret,
+// otherwise the secrets within it.
+func (r *Redactor) Value(name, value string) string {
+	if r.Field(name) && value != "" && !IsRedacted(value) {
+		return Marker
+	}
+	return r.String(value)
+}
+
+// JSON redacts the string values of a JSON document, keeping its field order but not its
+// whitespace. Every string in a field holding a secret is redacted, including within arrays.
+func (r *Redactor) JSON(data []byte) ([]byte, error) {
+	if r == nil {
+		return data, nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var out bytes.Buffer
+	if err := r.redactJSON(dec, &out, ""); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+func (r *Redactor) redactJSON(dec *json.Decoder, out *bytes.Buffer, name string) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	switch t := tok.(type) {
+	case json.Delim:
+		switch t {
+		case '{':
+			out.WriteByte('{')
+			for i := 0; dec.More(); i++ {
+				if i > 0 {
+					out.WriteByte(',')
+				}
+				key, err := dec.Token()
+				if err != nil {
+					return err
+				}
+				writeJSONString(out, key.(string))
+				out.WriteByte(':')
+				if err = r.redactJSON(dec, out, key.(string)); err != nil {
+					return err
+				}
+			}
+			out.WriteByte('}')
+		case '[':
+			out.WriteByte('[')
+			for i := 0; dec.More(); i++ {
+				if i > 0 {
+					out.WriteByte(',')
+				}
+				if err = r.redactJSON(dec, out, name); err != nil {
+					return err
+				}
+			}
+			out.WriteByte(']')
+		}
+		// Consume the closing delimiter.
+		_, err = dec.Token()
+		return err
+	case string:
+		writeJSONString(out, r.Value(name, t))
+	case json.Number:
+		out.WriteString(t.String())
+	case bool:
+		if t {
+			out.WriteString("true")
+		} else {
+			out.WriteString("false")
+		}
+	case nil:
+		out.WriteString("null")
+	}
+	return nil
+}
+
+// writeJSONString writes s as a JSON string, leaving <, > and & unescaped so the Marker stays
+// readable.
+func writeJSONString(out *bytes.Buffer, s string) {
+	enc := json.NewEncoder(out)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s)
+	// Encode terminates the value with a newline.
+	out.Truncate(out.Len() - 1)
+}
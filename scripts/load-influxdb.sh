@@ -0,0 +1,24 @@
+#!/bin/bash
+
+set -e
+
+WORKING_DIR=metrics/influx
+
+# Retrieve newly minted auth token for influxdb operations
+influx_token=$(grep -E '^\s*token\s*=' "${HOME}/.influxdbv2/configs" | awk '{printf $3}' | tr -d '"')
+
+mkdir -p "${WORKING_DIR}"
+echo "influxdb_load: exporting bundle metrics to ${WORKING_DIR}/metrics.lp"
+consul-debug-read metrics export -format=influx-line -output="${WORKING_DIR}"/metrics.lp
+
+echo "influxdb_load: writing metrics to consul-debug-metrics bucket"
+influx write \
+   --org hashicorp \
+   --bucket consul-debug-metrics \
+   --token "$influx_token" \
+   --precision ns \
+   --file "${WORKING_DIR}"/metrics.lp
+
+echo "influxdb_load complete"
+echo "    ==> visit http://localhost:8086 (influxdb ui) to explore metrics"
+echo "        ==> un: consul | pw: hashicorp"